@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"astron-xmod-shim/internal/core/orchestrator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListGoalSets 列出已注册的目标集合（内置与声明式），部署请求通过 goalSetName 选择
+func ListGoalSets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    orchestrator.GlobalOrchestrator.ListGoalSets(),
+	})
+}
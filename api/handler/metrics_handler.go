@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"astron-xmod-shim/pkg/log"
+	"astron-xmod-shim/pkg/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prometheusContentType Prometheus 文本格式
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics 以 Prometheus 文本格式输出指标
+func Metrics(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", prometheusContentType)
+	if err := metrics.Default.WriteText(c.Writer); err != nil {
+		log.Error("write metrics failed: %v", err)
+	}
+}
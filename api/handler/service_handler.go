@@ -2,10 +2,15 @@ package handler
 
 import (
 	"astron-xmod-shim/internal/core/orchestrator"
+	"astron-xmod-shim/internal/core/spec"
+	"astron-xmod-shim/internal/core/webhook"
 	dto "astron-xmod-shim/internal/dto/deploy"
 	"astron-xmod-shim/pkg/log"
 	"astron-xmod-shim/pkg/utils"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -22,14 +27,27 @@ type DeleteServiceResponse struct {
 
 // GetServiceStatusResponse 获取服务状态响应结构体
 type GetServiceStatusResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    struct {
-		ServiceID  string `json:"serviceId"`
-		Status     string `json:"status"`   // 运行中/阻塞中/失败/初始化中/不存在/停止中
-		Endpoint   string `json:"endpoint"` // openai like endpoint
-		UpdateTime string `json:"updateTime"`
-	} `json:"data"`
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    ServiceStatusData `json:"data"`
+}
+
+// ServiceStatusData 服务状态
+type ServiceStatusData struct {
+	ServiceID  string `json:"serviceId"`
+	Status     string `json:"status"`   // 运行中/阻塞中/失败/初始化中/不存在/停止中
+	Endpoint   string `json:"endpoint"` // openai like endpoint
+	UpdateTime string `json:"updateTime"`
+	Retries    int    `json:"retries"`   // 本轮收敛的重试次数
+	LastError  string `json:"lastError"` // 最近一次收敛失败的错误
+
+	GoalSetName    string             `json:"goalSetName"`
+	CurrentGoal    string             `json:"currentGoal"`              // 正在等待达成的目标，全部达成时为空
+	ActiveGoals    []string           `json:"activeGoals"`              // 依赖已达成、可并发执行的目标
+	CompletedGoals []string           `json:"completedGoals"`           // 已达成的目标
+	Goals          []dto.GoalProgress `json:"goals"`                    // 各目标的检查/执行记录与依赖，构成目标依赖图
+	RollbackReason string             `json:"rollbackReason,omitempty"` // 本轮收敛由回滚发起时的原因
+	Verification   string             `json:"verification,omitempty"`   // 当前版本部署后冒烟测试：passed / skipped
 }
 
 func DoDeploy(c *gin.Context) {
@@ -44,10 +62,9 @@ func DoDeploy(c *gin.Context) {
 	}
 
 	depSpec.ServiceId = utils.GenerateSimpleID()
-	depSpec.GoalSetName = "opensource-llm-deploy"
 	err := orchestrator.GlobalOrchestrator.Provision(depSpec)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(provisionErrorStatus(err), gin.H{
 			"code":    1,
 			"message": "deploy submit failed: " + err.Error(),
 		})
@@ -60,6 +77,20 @@ func DoDeploy(c *gin.Context) {
 	})
 }
 
+// provisionErrorStatus 将提交部署期望的错误映射为 HTTP 状态码
+func provisionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, orchestrator.ErrShimletNotRegistered), errors.Is(err, orchestrator.ErrEngineNotRegistered),
+		errors.Is(err, orchestrator.ErrInvalidParallelism), errors.Is(err, orchestrator.ErrGoalSetNotRegistered),
+		errors.Is(err, orchestrator.ErrInvalidCallbackURL), errors.Is(err, orchestrator.ErrTeardownGoalSet):
+		return http.StatusBadRequest
+	case errors.Is(err, orchestrator.ErrShimletChanged), errors.Is(err, spec.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // GetServiceStatus 处理获取模型服务状态的请求
 func GetServiceStatus(c *gin.Context) {
 	// 从URL路径中获取serviceId
@@ -84,12 +115,7 @@ func GetServiceStatus(c *gin.Context) {
 		response := GetServiceStatusResponse{
 			Code:    1,
 			Message: "get service status failed",
-			Data: struct {
-				ServiceID  string `json:"serviceId"`
-				Status     string `json:"status"`
-				Endpoint   string `json:"endpoint"`
-				UpdateTime string `json:"updateTime"`
-			}{serviceID, "", "", ""},
+			Data:    ServiceStatusData{ServiceID: serviceID},
 		}
 		c.JSON(http.StatusInternalServerError, response)
 		return
@@ -100,16 +126,29 @@ func GetServiceStatus(c *gin.Context) {
 	// 获取当前时间
 	updateTime := time.Now().Format("2006-01-02 15:04:05")
 
+	data := ServiceStatusData{
+		ServiceID:  serviceID,
+		Status:     string(status.Status),
+		Endpoint:   status.EndPoint,
+		UpdateTime: updateTime,
+	}
+	if serviceProgress, ok := orchestrator.GlobalOrchestrator.GetServiceProgress(serviceID); ok {
+		data.Retries = serviceProgress.Retries
+		data.LastError = serviceProgress.LastError
+		data.GoalSetName = serviceProgress.GoalSetName
+		data.CurrentGoal = serviceProgress.CurrentGoal()
+		data.ActiveGoals = serviceProgress.ActiveGoals()
+		data.CompletedGoals = serviceProgress.CompletedGoals()
+		data.Goals = serviceProgress.Goals
+		data.RollbackReason = serviceProgress.RollbackReason
+	}
+	data.Verification = orchestrator.GlobalOrchestrator.GetServiceVerification(serviceID)
+
 	// 返回成功响应
 	response := GetServiceStatusResponse{
 		Code:    0,
 		Message: "success",
-		Data: struct {
-			ServiceID  string `json:"serviceId"`
-			Status     string `json:"status"`
-			Endpoint   string `json:"endpoint"`
-			UpdateTime string `json:"updateTime"`
-		}{serviceID, string(status.Status), status.EndPoint, updateTime},
+		Data:    data,
 	}
 	c.JSON(http.StatusOK, response)
 }
@@ -131,9 +170,7 @@ func DeleteService(c *gin.Context) {
 
 	log.Info("Deleting service", "serviceID", serviceID)
 
-	spec := &dto.RequirementSpec{ServiceId: serviceID, GoalSetName: "opensource-llm-delete", ResourceRequirements: &dto.ResourceRequirements{}}
-
-	err := orchestrator.GlobalOrchestrator.Provision(spec)
+	err := orchestrator.GlobalOrchestrator.SubmitDelete(serviceID)
 	if err != nil {
 		log.Error("Delete service failed", "error", err)
 		response := DeleteServiceResponse{
@@ -186,12 +223,11 @@ func UpdateService(c *gin.Context) {
 	depSpec.ServiceId = serviceID
 
 	log.Info("Updating service", "serviceID", serviceID)
-	depSpec.GoalSetName = "opensource-llm-deploy"
 	// 复用部署逻辑进行更新
 	err := orchestrator.GlobalOrchestrator.Provision(depSpec)
 	if err != nil {
 		log.Error("Update service failed", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(provisionErrorStatus(err), gin.H{
 			"code":    1,
 			"message": "update submit failed: " + err.Error(),
 			"data":    map[string]string{"serviceId": serviceID},
@@ -206,3 +242,147 @@ func UpdateService(c *gin.Context) {
 		"data":    map[string]string{"serviceId": serviceID},
 	})
 }
+
+// ListServices 查询服务列表，支持过滤、排序与游标分页
+// 查询参数：modelName、phase、shimlet、label（可重复，格式 key=value）、sortBy、order、limit、cursor；
+// phase 过滤与排序使用 reconciler 最近观测到的阶段
+func ListServices(c *gin.Context) {
+	query := &dto.ServiceListQuery{
+		ModelName:   c.Query("modelName"),
+		Phase:       dto.DeployPhase(c.Query("phase")),
+		ShimletName: c.Query("shimlet"),
+		SortBy:      c.Query("sortBy"),
+		Order:       c.Query("order"),
+		Cursor:      c.Query("cursor"),
+	}
+
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    1,
+				"message": "invalid limit: " + rawLimit,
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	for _, rawLabel := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(rawLabel, "=")
+		if !ok || key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    1,
+				"message": "invalid label selector, expect key=value: " + rawLabel,
+			})
+			return
+		}
+		if query.Labels == nil {
+			query.Labels = make(map[string]string)
+		}
+		query.Labels[key] = value
+	}
+
+	result, err := orchestrator.GlobalOrchestrator.ListServices(query)
+	if err != nil {
+		log.Warn("List services failed: %v", err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, orchestrator.ErrInvalidListQuery) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
+			"code":    1,
+			"message": "list services failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// RetryService 清除失败终态，按原部署期望重新收敛
+func RetryService(c *gin.Context) {
+	serviceID := c.Param("serviceId")
+
+	err := orchestrator.GlobalOrchestrator.RetryService(serviceID)
+	if errors.Is(err, orchestrator.ErrServiceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    1,
+			"message": "service not found",
+			"data":    map[string]string{"serviceId": serviceID},
+		})
+		return
+	}
+	if err != nil {
+		log.Error("Retry service %s failed: %v", serviceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    1,
+			"message": "retry submit failed: " + err.Error(),
+			"data":    map[string]string{"serviceId": serviceID},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "retry submit success",
+		"data":    map[string]string{"serviceId": serviceID},
+	})
+}
+
+// RollbackService 回滚到最近一次收敛成功的部署期望
+func RollbackService(c *gin.Context) {
+	serviceID := c.Param("serviceId")
+
+	err := orchestrator.GlobalOrchestrator.RollbackService(serviceID)
+	if errors.Is(err, orchestrator.ErrServiceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    1,
+			"message": "service not found",
+			"data":    map[string]string{"serviceId": serviceID},
+		})
+		return
+	}
+	if errors.Is(err, orchestrator.ErrNoRollbackTarget) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    1,
+			"message": "rollback submit failed: " + err.Error(),
+			"data":    map[string]string{"serviceId": serviceID},
+		})
+		return
+	}
+	if err != nil {
+		log.Error("Rollback service %s failed: %v", serviceID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    1,
+			"message": "rollback submit failed: " + err.Error(),
+			"data":    map[string]string{"serviceId": serviceID},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "rollback submit success",
+		"data":    map[string]string{"serviceId": serviceID},
+	})
+}
+
+// ListWebhookDeliveries 查询服务的回调投递记录
+func ListWebhookDeliveries(c *gin.Context) {
+	serviceID := c.Param("serviceId")
+
+	deliveries := make([]dto.WebhookDelivery, 0)
+	if webhook.GlobalNotifier != nil {
+		deliveries = webhook.GlobalNotifier.Deliveries(serviceID)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    deliveries,
+	})
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"astron-xmod-shim/internal/core/orchestrator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListShimlets 列出已配置的 shimlet 实例（如多个 Kubernetes 集群），汇总各实例的服务
+func ListShimlets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    orchestrator.GlobalOrchestrator.ListShimlets(),
+	})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"astron-xmod-shim/internal/core/watch"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// watchHeartbeatInterval 心跳间隔，防止代理因空闲断开连接
+const watchHeartbeatInterval = 15 * time.Second
+
+// WatchService 以 SSE 推送单个服务的事件
+func WatchService(c *gin.Context) {
+	serveWatch(c, c.Param("serviceId"))
+}
+
+// WatchServices 以 SSE 推送全部服务的事件
+func WatchServices(c *gin.Context) {
+	serveWatch(c, "")
+}
+
+// serveWatch 推送事件流，事件 id 为事件序号
+// 断线重连时通过 Last-Event-ID 请求头或 since 查询参数从指定序号之后续读
+func serveWatch(c *gin.Context, serviceID string) {
+	if watch.GlobalHub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"code":    1,
+			"message": "watch is not enabled",
+		})
+		return
+	}
+
+	rawSince := c.GetHeader("Last-Event-ID")
+	if rawSince == "" {
+		rawSince = c.Query("since")
+	}
+	var since uint64
+	if rawSince != "" {
+		parsed, err := strconv.ParseUint(rawSince, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    1,
+				"message": "invalid event sequence: " + rawSince,
+			})
+			return
+		}
+		since = parsed
+	}
+
+	watcher, backlog, err := watch.GlobalHub.Watch(serviceID, since)
+	if errors.Is(err, watch.ErrHistoryExpired) {
+		c.JSON(http.StatusGone, gin.H{
+			"code":    1,
+			"message": err.Error() + ", query current status and watch again without since",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    1,
+			"message": "watch failed: " + err.Error(),
+		})
+		return
+	}
+	defer watch.GlobalHub.Stop(watcher)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, ev := range backlog {
+		renderEvent(c, ev)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case ev, ok := <-watcher.Events():
+			if !ok {
+				if watcher.Overflowed() {
+					log.Warn("watch client of service %q too slow, disconnected", serviceID)
+				}
+				return false
+			}
+			renderEvent(c, ev)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func renderEvent(c *gin.Context, ev event.ServiceEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(ev.Seq, 10),
+		Event: string(ev.Type),
+		Data:  ev,
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
 )
 
 // Logging 基于zap的HTTP请求日志中间件
@@ -33,12 +32,7 @@ func Logging() gin.HandlerFunc {
 		}
 
 		// 记录请求信息
-		logger("HTTP请求处理完成",
-			zap.String("method", method),
-			zap.String("path", path),
-			zap.Int("status", statusCode),
-			zap.String("client_ip", clientIP),
-			zap.Duration("duration", duration),
-		)
+		logger("HTTP请求处理完成 method=%s path=%s status=%d client_ip=%s duration=%s",
+			method, path, statusCode, clientIP, duration)
 	}
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"astron-xmod-shim/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	httpRequests = metrics.Default.NewCounterVec("xmod_http_requests_total",
+		"Total number of HTTP requests per method, route and status code.", "method", "route", "code")
+	httpDuration = metrics.Default.NewHistogramVec("xmod_http_request_duration_seconds",
+		"Duration of HTTP requests per method and route.", nil, "method", "route")
+)
+
+// Metrics 记录 HTTP 请求数与耗时，route 使用路由模板（如 /api/v1/modserv/:serviceId）避免标签基数膨胀
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(startTime).Seconds())
+	}
+}
@@ -3,8 +3,6 @@ package route
 import (
 	"astron-xmod-shim/api/handler"
 	"astron-xmod-shim/pkg/http"
-
-	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes 注册所有业务路由
@@ -34,17 +32,32 @@ func RegisterRoutes(server *http.Server) {
 				// 指标相关路由
 				metrics := modserv.Group("/metrics")
 				{
-					metrics.GET("", func(c *gin.Context) {
-						// 实现指标处理逻辑
-					})
+					metrics.GET("", handler.Metrics)
 				}
 
+				// 服务列表路由
+				modserv.GET("/services", handler.ListServices)
+				// 已配置的 shimlet 实例
+				modserv.GET("/shimlets", handler.ListShimlets)
+				// 已注册的目标集合
+				modserv.GET("/goalsets", handler.ListGoalSets)
+				// 全部服务的事件流（SSE）
+				modserv.GET("/watch", handler.WatchServices)
+
 				// 删除服务路由
 				modserv.DELETE("/:serviceId", handler.DeleteService)
 				// 获取服务状态路由
 				modserv.GET("/:serviceId", handler.GetServiceStatus)
 				// 更新服务路由
 				modserv.PUT("/:serviceId", handler.UpdateService)
+				// 清除失败终态并重试
+				modserv.POST("/:serviceId/retry", handler.RetryService)
+				// 回滚到最近一次正常的部署期望
+				modserv.POST("/:serviceId/rollback", handler.RollbackService)
+				// 回调投递记录
+				modserv.GET("/:serviceId/webhooks", handler.ListWebhookDeliveries)
+				// 单个服务的事件流（SSE）
+				modserv.GET("/:serviceId/watch", handler.WatchService)
 			}
 		}
 	}
@@ -21,13 +21,13 @@ func Init() error {
 	// 3. 初始化通用HTTP服务器
 	httpServer := http.NewServer(globalCfg.Server.Port)
 
+	// 注册日志与指标中间件，需在注册路由之前，否则不会作用于已注册的路由
+	engine := httpServer.GetEngine()
+	engine.Use(middleware.Logging(), middleware.Metrics())
+
 	// 注册业务路由
 	route.RegisterRoutes(httpServer)
 
-	// 注册日志中间件
-	engine := httpServer.GetEngine()
-	engine.Use(middleware.Logging())
-
 	log.Info("HTTP服务器初始化完毕")
 
 	// 启动服务器
@@ -0,0 +1,53 @@
+// sim-plugin serves the built-in sim shimlet over the plugin protocol. It is a reference
+// for out-of-process shimlets and can be wired into conf.yaml as:
+//
+//	shimlets:
+//	  sim-remote:
+//	    config-path: "./conf/sim/sim-shimlet.yaml"
+//	    plugin:
+//	      path: "./bin/sim-plugin"
+//
+// With --listen it serves a socket instead of stdin/stdout, for plugins configured by address.
+package main
+
+import (
+	"astron-xmod-shim/internal/core/shimlet/remote"
+	"astron-xmod-shim/internal/core/shimlet/shimlets"
+	cfg "astron-xmod-shim/internal/dto/config"
+	"astron-xmod-shim/pkg/log"
+	"flag"
+	stdlog "log"
+	"net"
+	"os"
+	"strings"
+)
+
+func main() {
+	listen := flag.String("listen", "", "serve on unix:///path or tcp://host:port instead of stdin/stdout")
+	flag.Parse()
+
+	// stdout carries the protocol, keep logging quiet
+	if err := log.Init(&cfg.LogConfig{Level: "error"}); err != nil {
+		stdlog.Fatalf("init log: %v", err)
+	}
+	server := remote.NewServer(&shimlets.SimShimlet{})
+
+	if *listen == "" {
+		if err := server.ServeConn(os.Stdin, os.Stdout); err != nil {
+			stdlog.Fatalf("serve stdio: %v", err)
+		}
+		return
+	}
+
+	network, address := "tcp", strings.TrimPrefix(*listen, "tcp://")
+	if strings.HasPrefix(*listen, "unix://") {
+		network, address = "unix", strings.TrimPrefix(*listen, "unix://")
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		stdlog.Fatalf("listen %s: %v", *listen, err)
+	}
+	if err := server.Serve(listener); err != nil {
+		stdlog.Fatalf("serve %s: %v", *listen, err)
+	}
+}
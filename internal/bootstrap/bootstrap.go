@@ -3,14 +3,21 @@ package bootstrap
 import (
 	"astron-xmod-shim/api/server"
 	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/eventbus"
 	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/goal/goalset"
 	"astron-xmod-shim/internal/core/orchestrator"
+	"astron-xmod-shim/internal/core/progress"
 	"astron-xmod-shim/internal/core/reconciler"
 	"astron-xmod-shim/internal/core/shimlet"
+	"astron-xmod-shim/internal/core/shimlet/remote"
 	_ "astron-xmod-shim/internal/core/shimlet/shimlets"
 	"astron-xmod-shim/internal/core/spec"
+	"astron-xmod-shim/internal/core/watch"
+	"astron-xmod-shim/internal/core/webhook"
 	"astron-xmod-shim/internal/core/workqueue"
 	"astron-xmod-shim/pkg/log"
+	"astron-xmod-shim/pkg/metrics"
 	"fmt"
 )
 
@@ -18,6 +25,9 @@ func Init(configPath string) error {
 	// init config
 	config.SetConfigPath(configPath)
 	cfg := config.Get()
+	if cfg == nil {
+		return fmt.Errorf("load config from %s failed", configPath)
+	}
 
 	// init log
 	if err := log.Init(&cfg.Log); err != nil {
@@ -25,29 +35,78 @@ func Init(configPath string) error {
 	}
 	log.Info("log configured", "cfg: ", cfg.Log)
 
-	// shimlet registry already initialed from init()
+	// shimlet registry already initialed from init()，配置了 plugin 的 shimlet 由外部插件进程实现
 	shimReg := shimlet.Registry
+	remote.RegisterPlugins(shimReg, cfg.Shimlets)
+	// 内置目标集合已在 init() 中注册，声明式目标集合在启动时加载并校验
+	if err := goalset.LoadDeclarative(cfg.GoalSets.Dir); err != nil {
+		return fmt.Errorf("goal sets init error: %w", err)
+	}
 	pipeReg := goal.Registry
 
 	//  init specStore
-	specStore := spec.NewMemoryStore()
+	specStore, err := spec.NewStore(cfg)
+	if err != nil {
+		return fmt.Errorf("spec store init error: %w", err)
+	}
 
 	// init reconciler
 	workerNum := 5
 	workQueue := workqueue.New()
 
-	reconciler := reconciler.NewReconciler(specStore, workerNum, workQueue)
+	// init event bus，日志订阅者输出全部服务事件
+	bus := eventbus.New()
+	if _, err := bus.Subscribe(eventbus.LogSubscriberName, eventbus.LogHandler, eventbus.OptionsFromConfig(&cfg.EventBus)); err != nil {
+		return fmt.Errorf("event bus init error: %w", err)
+	}
 
-	//  init workqueue
+	// 部署阶段变化回调，投递在独立协程中重试，订阅者本身不会积压
+	webhook.GlobalNotifier = webhook.NewNotifier(&cfg.Webhook, specStore)
+	goal.OnTeardown(webhook.GlobalNotifier.Forget)
+	webhookOpts := eventbus.OptionsFromConfig(&cfg.EventBus)
+	webhookOpts.Filter = webhook.GlobalNotifier.Filter
+	if _, err := bus.Subscribe(webhook.SubscriberName, webhook.GlobalNotifier.Handle, webhookOpts); err != nil {
+		return fmt.Errorf("event bus init error: %w", err)
+	}
 
-	// 初始化全局Tracer单例
-	infraShim, _ := shimReg.GetSingleton(cfg.CurrentShimlet)
+	// watch 保留最近的事件供 SSE 断线续读，Handle 不阻塞，使用丢弃最旧事件的策略
+	watch.GlobalHub = watch.NewHub(cfg.EventBus.HistorySize)
+	watchOpts := eventbus.OptionsFromConfig(&cfg.EventBus)
+	watchOpts.Policy = eventbus.PolicyDropOldest
+	if _, err := bus.Subscribe(watch.SubscriberName, watch.GlobalHub.Handle, watchOpts); err != nil {
+		return fmt.Errorf("event bus init error: %w", err)
+	}
 
-	// TODO 利用shimlet get 出服务列表
-	_, _ = infraShim.ListDeployedServices()
+	tracker := progress.NewTracker()
+	metrics.Default.NewGaugeFunc("xmod_services", "Number of services per observed deploy phase.", []string{"phase"},
+		func() []metrics.Sample {
+			counts := tracker.PhaseCounts()
+			samples := make([]metrics.Sample, 0, len(counts))
+			for phase, count := range counts {
+				samples = append(samples, metrics.Sample{LabelValues: []string{string(phase)}, Value: float64(count)})
+			}
+			return samples
+		})
+	reconciler := reconciler.NewReconciler(specStore, workerNum, workQueue, tracker, bus)
+
+	//  init workqueue
+
+	// 重新投递已持久化的部署期望，由 reconciler 继续收敛；
+	// 未完成的下线在收敛后删除部署期望
+	for _, deploySpec := range specStore.List() {
+		workQueue.Add(deploySpec.ServiceId)
+	}
+	log.Info("re-enqueued %d specs from spec store", workQueue.Len())
 
 	// init orchestrator
-	orchestrator.GlobalOrchestrator = orchestrator.NewOrchestrator(shimReg, pipeReg, workQueue, specStore)
+	orchestrator.GlobalOrchestrator = orchestrator.NewOrchestrator(shimReg, pipeReg, workQueue, specStore, tracker, bus)
+
+	// 利用 shimlet 列出已部署服务，接管没有部署期望的孤儿服务
+	for _, shimletName := range orchestrator.GlobalOrchestrator.ShimletsInUse(cfg.CurrentShimlet) {
+		if err := orchestrator.GlobalOrchestrator.AdoptDeployedServices(shimletName, cfg.Adoption.OrphanPolicy); err != nil {
+			log.Error("adopt deployed services from %s failed: %v", shimletName, err)
+		}
+	}
 
 	// start reconciler
 	reconciler.Start()
@@ -73,7 +73,8 @@ func GetConfFromFileDir[T any](configPath string) (*T, error) {
 		return nil, fmt.Errorf("路径不是文件: %s", configPath)
 	}
 
-	v := viper.New()
+	// 使用不会出现在键中的分隔符，避免 kubernetes.io/hostname 这类带点的 map 键被拆成多级
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
 	v.SetConfigFile(configPath)
 	v.SetConfigType("yaml")
 
@@ -2,8 +2,11 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
+	confSpec "astron-xmod-shim/internal/dto/config"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -45,3 +48,55 @@ log:
 	assert.Equal(t, "info", cfg.Log.Level)
 	assert.Equal(t, "./logs", cfg.Log.Path)
 }
+
+// 测试 shimlet 配置中带点的 map 键与 Pod 调度结构的解析
+func TestGetConfFromFileDir_K8sDefaults(t *testing.T) {
+	content := `
+kube-config: "/tmp/kubeconfig"
+defaults:
+  namespace: "llm"
+  image-pull-secrets: ["registry-cred"]
+  node-selector:
+    kubernetes.io/hostname: "gpu-node-1"
+  affinity:
+    nodeAffinity:
+      requiredDuringSchedulingIgnoredDuringExecution:
+        nodeSelectorTerms:
+          - matchExpressions:
+              - key: nvidia.com/gpu.present
+                operator: In
+                values: ["true"]
+  tolerations:
+    - key: dedicated
+      operator: Equal
+      value: llm
+      effect: NoSchedule
+      tolerationSeconds: 30
+  labels:
+    app.kubernetes.io/part-of: "xmod"
+`
+	path := filepath.Join(t.TempDir(), "k8s-shimlet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	k8sCfg, err := GetConfFromFileDir[confSpec.K8sConfig](path)
+	require.NoError(t, err)
+	defaults := k8sCfg.Defaults
+	assert.Equal(t, "llm", defaults.Namespace)
+	assert.Equal(t, []string{"registry-cred"}, defaults.ImagePullSecrets)
+	assert.Equal(t, map[string]string{"kubernetes.io/hostname": "gpu-node-1"}, defaults.NodeSelector)
+	assert.Equal(t, map[string]string{"app.kubernetes.io/part-of": "xmod"}, defaults.Labels)
+
+	require.NotNil(t, defaults.Affinity)
+	require.NotNil(t, defaults.Affinity.NodeAffinity)
+	terms := defaults.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Len(t, terms, 1)
+	require.Len(t, terms[0].MatchExpressions, 1)
+	assert.Equal(t, "nvidia.com/gpu.present", terms[0].MatchExpressions[0].Key)
+	assert.Equal(t, []string{"true"}, terms[0].MatchExpressions[0].Values)
+
+	require.Len(t, defaults.Tolerations, 1)
+	assert.Equal(t, "dedicated", defaults.Tolerations[0].Key)
+	assert.EqualValues(t, "NoSchedule", defaults.Tolerations[0].Effect)
+	require.NotNil(t, defaults.Tolerations[0].TolerationSeconds)
+	assert.EqualValues(t, 30, *defaults.Tolerations[0].TolerationSeconds)
+}
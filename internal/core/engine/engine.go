@@ -0,0 +1,182 @@
+package engine
+
+import (
+	"astron-xmod-shim/internal/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultEngine 请求未指定 engine 时使用的推理引擎
+const DefaultEngine = "vllm"
+
+// ErrEngineNotRegistered 指定的推理引擎未注册
+var ErrEngineNotRegistered = errors.New("engine not registered")
+
+// Engine 推理引擎，根据部署期望渲染推理服务的镜像、启动命令、参数与环境变量
+// shimlet 只负责运行渲染结果，新增引擎不需要修改各个 shimlet
+type Engine interface {
+	ID() string
+	Description() string
+	DefaultImage() string
+	DefaultPort() int
+	HealthPath() string
+	// Env 引擎固定注入的环境变量，shimlet 回读部署期望时据此区分用户环境变量
+	Env() []dto.Env
+	// Render 渲染启动命令与参数，target.Port 已确定
+	Render(spec *dto.RequirementSpec, target Target) (*Spec, error)
+}
+
+// Target 由 shimlet 提供的运行环境
+type Target struct {
+	ModelDir string // 推理服务看到的模型目录（容器内挂载路径与宿主机相同）
+	Host     string // 监听地址，默认 0.0.0.0
+	Port     int    // 监听端口，0 表示使用引擎默认端口
+	Image    string // shimlet 配置的镜像，优先于引擎镜像
+	Local    bool   // 需要完整的启动命令（本机进程，或多节点时由 shimlet 包装启动脚本），Command 第一个元素为可执行文件
+
+	Parallelism Parallelism // 由 Render 根据部署期望补全
+}
+
+// Spec 渲染结果
+type Spec struct {
+	Engine      string
+	Image       string
+	Command     []string // 容器 entrypoint，为空时使用镜像默认值；Local 时为可执行文件及其固定参数
+	Args        []string
+	Env         []dto.Env
+	Port        int
+	HealthPath  string
+	Parallelism Parallelism
+}
+
+// Reg 推理引擎注册中心
+type Reg struct {
+	mu      sync.RWMutex
+	engines map[string]Engine
+}
+
+// Registry 全局推理引擎注册中心，内置引擎在 init() 中注册
+var Registry = NewReg()
+
+// NewReg 创建注册中心
+func NewReg() *Reg {
+	return &Reg{engines: make(map[string]Engine)}
+}
+
+// Register 注册推理引擎，同名覆盖
+func (r *Reg) Register(e Engine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.engines[e.ID()] = e
+}
+
+// Get 获取推理引擎，id 为空时返回默认引擎
+func (r *Reg) Get(id string) (Engine, error) {
+	if id == "" {
+		id = DefaultEngine
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.engines[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEngineNotRegistered, id)
+	}
+	return e, nil
+}
+
+// Has 判断推理引擎是否已注册，空 id 表示默认引擎
+func (r *Reg) Has(id string) bool {
+	_, err := r.Get(id)
+	return err == nil
+}
+
+// IDs 返回全部已注册的引擎 ID（按字典序）
+func (r *Reg) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.engines))
+	for id := range r.engines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Render 使用部署期望指定的引擎渲染推理服务
+// 镜像优先级：engineOptions.image > target.Image（shimlet 配置）> engines.<id>.image（全局配置）> 引擎内置镜像
+func (r *Reg) Render(spec *dto.RequirementSpec, target Target) (*Spec, error) {
+	e, err := r.Get(spec.Engine)
+	if err != nil {
+		return nil, err
+	}
+	if target.Host == "" {
+		target.Host = "0.0.0.0"
+	}
+	if target.Port == 0 {
+		target.Port = e.DefaultPort()
+	}
+	if target.Parallelism, err = ResolveParallelism(spec); err != nil {
+		return nil, err
+	}
+	rendered, err := e.Render(spec, target)
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", e.ID(), err)
+	}
+	rendered.Engine = e.ID()
+	rendered.Port = target.Port
+	rendered.HealthPath = e.HealthPath()
+	rendered.Parallelism = target.Parallelism
+	rendered.Env = append(rendered.Env, e.Env()...)
+
+	rendered.Image = e.DefaultImage()
+	if globalCfg := config.Get(); globalCfg != nil && globalCfg.Engines[e.ID()].Image != "" {
+		rendered.Image = globalCfg.Engines[e.ID()].Image
+	}
+	if target.Image != "" {
+		rendered.Image = target.Image
+	}
+	if opts := spec.EngineOptions; opts != nil {
+		if opts.Image != "" {
+			rendered.Image = opts.Image
+		}
+		rendered.Args = append(rendered.Args, opts.ExtraArgs...)
+	}
+	return rendered, nil
+}
+
+// BuiltinEnvNames 返回全部引擎固定注入的环境变量名
+func (r *Reg) BuiltinEnvNames() map[string]struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make(map[string]struct{})
+	for _, e := range r.engines {
+		for _, env := range e.Env() {
+			names[env.Key] = struct{}{}
+		}
+	}
+	return names
+}
+
+// options 返回引擎参数，未指定时为空值
+func options(spec *dto.RequirementSpec) dto.EngineOptions {
+	if spec.EngineOptions == nil {
+		return dto.EngineOptions{}
+	}
+	return *spec.EngineOptions
+}
+
+// trustRemoteCode 默认信任模型自带代码（如 Qwen 需要）
+func trustRemoteCode(opts dto.EngineOptions) bool {
+	return opts.TrustRemoteCode == nil || *opts.TrustRemoteCode
+}
+
+// dtype 默认 auto
+func dtype(opts dto.EngineOptions) string {
+	if opts.Dtype == "" {
+		return "auto"
+	}
+	return opts.Dtype
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试内置引擎均已注册，空 id 返回默认引擎
+func TestRegistry_Builtins(t *testing.T) {
+	assert.Equal(t, []string{"llamacpp", "sglang", "tgi", "vllm"}, Registry.IDs())
+
+	e, err := Registry.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEngine, e.ID())
+
+	_, err = Registry.Get("no-such-engine")
+	assert.ErrorIs(t, err, ErrEngineNotRegistered)
+	assert.False(t, Registry.Has("no-such-engine"))
+}
+
+// 测试 vLLM 参数渲染，未指定端口时使用引擎默认端口
+func TestRender_VLLM(t *testing.T) {
+	spec := &dto.RequirementSpec{
+		ModelName:     "qwen",
+		ContextLength: 8192,
+		EngineOptions: &dto.EngineOptions{Quantization: "awq", GPUMemoryUtilization: 0.85, ExtraArgs: []string{"--enforce-eager"}},
+	}
+	rendered, err := Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "vllm", rendered.Engine)
+	assert.Equal(t, 8000, rendered.Port)
+	assert.Equal(t, "/health", rendered.HealthPath)
+	assert.Empty(t, rendered.Command, "container uses the image entrypoint")
+	assert.Equal(t, []string{
+		"--host=0.0.0.0",
+		"--port=8000",
+		"--model=/models/qwen",
+		"--dtype=auto",
+		"--served-model-name=qwen",
+		"--trust-remote-code",
+		"--max-model-len=8192",
+		"--quantization=awq",
+		"--gpu-memory-utilization=0.85",
+		"--enforce-eager",
+	}, rendered.Args)
+	assert.Contains(t, rendered.Env, dto.Env{Key: "TRANSFORMERS_OFFLINE", Value: "1"})
+
+	local, err := Registry.Render(spec, Target{ModelDir: "/models/qwen", Port: 31000, Local: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"python3", "-m", "vllm.entrypoints.openai.api_server"}, local.Command)
+	assert.Contains(t, local.Args, "--port=31000")
+}
+
+// 测试 SGLang / TGI 参数渲染
+func TestRender_SGLangAndTGI(t *testing.T) {
+	untrusted := false
+	spec := &dto.RequirementSpec{
+		ModelName:     "qwen",
+		Engine:        "sglang",
+		EngineOptions: &dto.EngineOptions{Dtype: "bfloat16", TrustRemoteCode: &untrusted},
+	}
+	rendered, err := Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"python3", "-m", "sglang.launch_server"}, rendered.Command)
+	assert.Equal(t, []string{
+		"--model-path", "/models/qwen",
+		"--host", "0.0.0.0",
+		"--port", "30000",
+		"--served-model-name", "qwen",
+		"--dtype", "bfloat16",
+	}, rendered.Args)
+
+	spec = &dto.RequirementSpec{ModelName: "qwen", Engine: "tgi", ContextLength: 4096}
+	rendered, err = Registry.Render(spec, Target{ModelDir: "/models/qwen", Port: 8000})
+	require.NoError(t, err)
+	assert.Empty(t, rendered.Command)
+	assert.Equal(t, []string{
+		"--model-id", "/models/qwen",
+		"--hostname", "0.0.0.0",
+		"--port", "8000",
+		"--trust-remote-code",
+		"--max-total-tokens", "4096",
+	}, rendered.Args)
+	assert.Equal(t, []dto.Env{{Key: "HF_HUB_OFFLINE", Value: "1"}}, rendered.Env)
+}
+
+// 测试 llama.cpp 只接受 GGUF 文件，分配显卡时卸载全部层
+func TestRender_LlamaCpp(t *testing.T) {
+	_, err := Registry.Render(&dto.RequirementSpec{ModelName: "qwen", ModelFileDir: "/models/qwen", Engine: "llamacpp"}, Target{ModelDir: "/models/qwen"})
+	assert.Error(t, err)
+
+	spec := &dto.RequirementSpec{
+		ModelName:            "qwen",
+		ModelFileDir:         "local:/models/qwen/qwen-q4_k_m.gguf",
+		Engine:               "llamacpp",
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 1},
+	}
+	rendered, err := Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--host", "0.0.0.0",
+		"--port", "8080",
+		"-m", "/models/qwen/qwen-q4_k_m.gguf",
+		"--alias", "qwen",
+		"--n-gpu-layers", "999",
+	}, rendered.Args)
+}
+
+// 测试镜像优先级：engineOptions.image > shimlet 配置 > 引擎内置镜像
+func TestRender_ImagePrecedence(t *testing.T) {
+	spec := &dto.RequirementSpec{ModelName: "qwen", Engine: "sglang"}
+	rendered, err := Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+	assert.Equal(t, "lmsysorg/sglang:latest", rendered.Image)
+
+	rendered, err = Registry.Render(spec, Target{ModelDir: "/models/qwen", Image: "registry.local/sglang:v0.4"})
+	require.NoError(t, err)
+	assert.Equal(t, "registry.local/sglang:v0.4", rendered.Image)
+
+	spec.EngineOptions = &dto.EngineOptions{Image: "registry.local/sglang:dev"}
+	rendered, err = Registry.Render(spec, Target{ModelDir: "/models/qwen", Image: "registry.local/sglang:v0.4"})
+	require.NoError(t, err)
+	assert.Equal(t, "registry.local/sglang:dev", rendered.Image)
+}
+
+// 测试引擎固定环境变量汇总
+func TestRegistry_BuiltinEnvNames(t *testing.T) {
+	names := Registry.BuiltinEnvNames()
+	assert.Contains(t, names, "TRANSFORMERS_OFFLINE")
+	assert.Contains(t, names, "HF_HOME")
+	assert.Contains(t, names, "HF_HUB_OFFLINE")
+}
+
+// 测试并行方式默认值与校验：流水线并行度默认等于节点数，张量并行度用满显卡
+func TestResolveParallelism(t *testing.T) {
+	p, err := ResolveParallelism(&dto.RequirementSpec{})
+	require.NoError(t, err)
+	assert.Equal(t, Parallelism{TensorParallelSize: 1, PipelineParallelSize: 1, Nodes: 1}, p)
+	assert.False(t, p.MultiNode())
+
+	p, err = ResolveParallelism(&dto.RequirementSpec{ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, Parallelism{TensorParallelSize: 4, PipelineParallelSize: 1, Nodes: 1}, p)
+
+	p, err = ResolveParallelism(&dto.RequirementSpec{
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 8},
+		Parallelism:          &dto.ParallelismSpec{Nodes: 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Parallelism{TensorParallelSize: 8, PipelineParallelSize: 2, Nodes: 2}, p)
+	assert.True(t, p.MultiNode())
+
+	_, err = ResolveParallelism(&dto.RequirementSpec{
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 2},
+		Parallelism:          &dto.ParallelismSpec{TensorParallelSize: 4},
+	})
+	assert.ErrorIs(t, err, ErrInvalidParallelism)
+
+	_, err = ResolveParallelism(&dto.RequirementSpec{Parallelism: &dto.ParallelismSpec{Nodes: -1}})
+	assert.ErrorIs(t, err, ErrInvalidParallelism)
+}
+
+// 测试各引擎的并行参数，以及不支持的并行方式
+func TestRender_Parallelism(t *testing.T) {
+	spec := &dto.RequirementSpec{
+		ModelName:            "qwen",
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 8},
+		Parallelism:          &dto.ParallelismSpec{Nodes: 2},
+	}
+	rendered, err := Registry.Render(spec, Target{ModelDir: "/models/qwen", Local: true})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Args, "--tensor-parallel-size=8")
+	assert.Contains(t, rendered.Args, "--pipeline-parallel-size=2")
+	assert.Contains(t, rendered.Args, "--distributed-executor-backend=ray")
+	assert.Equal(t, 2, rendered.Parallelism.Nodes)
+
+	spec.Engine = "tgi"
+	_, err = Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	assert.ErrorIs(t, err, ErrInvalidParallelism)
+
+	spec.Parallelism = nil
+	rendered, err = Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.Args, "--num-shard")
+
+	spec.Engine = "sglang"
+	spec.Parallelism = &dto.ParallelismSpec{TensorParallelSize: 4, PipelineParallelSize: 2}
+	rendered, err = Registry.Render(spec, Target{ModelDir: "/models/qwen"})
+	require.NoError(t, err)
+	assert.Subset(t, rendered.Args, []string{"--tp-size", "4", "--pp-size", "2"})
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Registry.Register(&LlamaCpp{})
+}
+
+// LlamaCpp llama.cpp server，加载单个 GGUF 文件
+type LlamaCpp struct{}
+
+func (l *LlamaCpp) ID() string           { return "llamacpp" }
+func (l *LlamaCpp) Description() string  { return "llama.cpp server (GGUF)" }
+func (l *LlamaCpp) DefaultImage() string { return "ghcr.io/ggml-org/llama.cpp:server" }
+func (l *LlamaCpp) DefaultPort() int     { return 8080 }
+func (l *LlamaCpp) HealthPath() string   { return "/health" }
+func (l *LlamaCpp) Env() []dto.Env       { return nil }
+
+// Render modelFileDir 需指向 .gguf 文件，文件位于挂载的模型目录下；分配了显卡时全部层卸载到 GPU
+func (l *LlamaCpp) Render(spec *dto.RequirementSpec, target Target) (*Spec, error) {
+	if err := unsupported(l.ID(), target.Parallelism, false, false); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(spec.ModelFileDir), ".gguf") {
+		return nil, fmt.Errorf("llama.cpp needs a .gguf model file, got %s", spec.ModelFileDir)
+	}
+	modelFile := filepath.Join(target.ModelDir, filepath.Base(spec.ModelFileDir))
+	args := []string{
+		"--host", target.Host,
+		"--port", strconv.Itoa(target.Port),
+		"-m", modelFile,
+		"--alias", spec.ModelName,
+	}
+	if spec.ContextLength > 0 {
+		args = append(args, "-c", strconv.Itoa(spec.ContextLength))
+	}
+	if rr := spec.ResourceRequirements; rr != nil && rr.AcceleratorCount > 0 {
+		args = append(args, "--n-gpu-layers", "999")
+	}
+	if spec.Parallelism != nil && spec.Parallelism.TensorParallelSize > 1 {
+		// 显式要求张量并行时按行切分权重，默认按层切分到多张显卡
+		args = append(args, "--split-mode", "row")
+	}
+
+	rendered := &Spec{Args: args}
+	if target.Local {
+		rendered.Command = []string{"llama-server"}
+	}
+	return rendered, nil
+}
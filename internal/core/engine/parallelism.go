@@ -0,0 +1,68 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidParallelism 并行方式与分配的显卡不匹配
+var ErrInvalidParallelism = errors.New("invalid parallelism")
+
+// Parallelism 补全默认值后的并行方式
+type Parallelism struct {
+	TensorParallelSize   int
+	PipelineParallelSize int
+	Nodes                int // 每个副本的节点（Pod）数
+}
+
+// MultiNode 是否跨多个 Pod 部署
+func (p Parallelism) MultiNode() bool { return p.Nodes > 1 }
+
+// ResolveParallelism 补全并校验部署期望中的并行方式：
+// 流水线并行度默认等于节点数，张量并行度默认用满每个流水线阶段分到的显卡
+func ResolveParallelism(spec *dto.RequirementSpec) (Parallelism, error) {
+	var requested dto.ParallelismSpec
+	if spec.Parallelism != nil {
+		requested = *spec.Parallelism
+	}
+	if requested.TensorParallelSize < 0 || requested.PipelineParallelSize < 0 || requested.Nodes < 0 {
+		return Parallelism{}, fmt.Errorf("%w: sizes must not be negative", ErrInvalidParallelism)
+	}
+
+	p := Parallelism{TensorParallelSize: 1, PipelineParallelSize: 1, Nodes: 1}
+	if requested.Nodes > 0 {
+		p.Nodes = requested.Nodes
+	}
+	p.PipelineParallelSize = p.Nodes
+	if requested.PipelineParallelSize > 0 {
+		p.PipelineParallelSize = requested.PipelineParallelSize
+	}
+
+	gpus := 0
+	if rr := spec.ResourceRequirements; rr != nil && rr.AcceleratorCount > 0 {
+		gpus = rr.AcceleratorCount * p.Nodes
+	}
+	switch {
+	case requested.TensorParallelSize > 0:
+		p.TensorParallelSize = requested.TensorParallelSize
+	case gpus >= p.PipelineParallelSize:
+		p.TensorParallelSize = gpus / p.PipelineParallelSize
+	}
+	if gpus > 0 && p.TensorParallelSize*p.PipelineParallelSize > gpus {
+		return Parallelism{}, fmt.Errorf("%w: tensor parallel %d x pipeline parallel %d needs %d accelerators, %d allocated",
+			ErrInvalidParallelism, p.TensorParallelSize, p.PipelineParallelSize, p.TensorParallelSize*p.PipelineParallelSize, gpus)
+	}
+	return p, nil
+}
+
+// unsupported 校验引擎不支持的并行方式
+func unsupported(engineID string, p Parallelism, pipeline, multiNode bool) error {
+	if !pipeline && p.PipelineParallelSize > 1 {
+		return fmt.Errorf("%w: %s does not support pipeline parallelism", ErrInvalidParallelism, engineID)
+	}
+	if !multiNode && p.MultiNode() {
+		return fmt.Errorf("%w: %s does not support multi-node deployments", ErrInvalidParallelism, engineID)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	Registry.Register(&SGLang{})
+}
+
+// SGLang SGLang 推理服务
+type SGLang struct{}
+
+func (s *SGLang) ID() string           { return "sglang" }
+func (s *SGLang) Description() string  { return "SGLang server" }
+func (s *SGLang) DefaultImage() string { return "lmsysorg/sglang:latest" }
+func (s *SGLang) DefaultPort() int     { return 30000 }
+func (s *SGLang) HealthPath() string   { return "/health" }
+
+func (s *SGLang) Env() []dto.Env {
+	return []dto.Env{
+		{Key: "TRANSFORMERS_OFFLINE", Value: "1"},
+		{Key: "HF_HOME", Value: "/tmp"},
+	}
+}
+
+// Render 镜像没有指定服务入口，容器与本机进程都显式给出启动命令；多节点需要 SGLang 自身的分布式初始化，暂不支持
+func (s *SGLang) Render(spec *dto.RequirementSpec, target Target) (*Spec, error) {
+	if err := unsupported(s.ID(), target.Parallelism, true, false); err != nil {
+		return nil, err
+	}
+	opts := options(spec)
+	args := []string{
+		"--model-path", target.ModelDir,
+		"--host", target.Host,
+		"--port", strconv.Itoa(target.Port),
+		"--served-model-name", spec.ModelName,
+		"--dtype", dtype(opts),
+	}
+	if trustRemoteCode(opts) {
+		args = append(args, "--trust-remote-code")
+	}
+	if spec.ContextLength > 0 {
+		args = append(args, "--context-length", strconv.Itoa(spec.ContextLength))
+	}
+	if opts.Quantization != "" {
+		args = append(args, "--quantization", opts.Quantization)
+	}
+	if opts.GPUMemoryUtilization > 0 {
+		args = append(args, "--mem-fraction-static", fmt.Sprintf("%g", opts.GPUMemoryUtilization))
+	}
+	if p := target.Parallelism; p.TensorParallelSize > 1 {
+		args = append(args, "--tp-size", strconv.Itoa(p.TensorParallelSize))
+	}
+	if p := target.Parallelism; p.PipelineParallelSize > 1 {
+		args = append(args, "--pp-size", strconv.Itoa(p.PipelineParallelSize))
+	}
+	return &Spec{
+		Command: []string{"python3", "-m", "sglang.launch_server"},
+		Args:    args,
+	}, nil
+}
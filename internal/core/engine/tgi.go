@@ -0,0 +1,61 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"strconv"
+)
+
+func init() {
+	Registry.Register(&TGI{})
+}
+
+// TGI Hugging Face Text Generation Inference
+type TGI struct{}
+
+func (t *TGI) ID() string          { return "tgi" }
+func (t *TGI) Description() string { return "Hugging Face Text Generation Inference" }
+func (t *TGI) DefaultImage() string {
+	return "ghcr.io/huggingface/text-generation-inference:latest"
+}
+func (t *TGI) DefaultPort() int   { return 80 }
+func (t *TGI) HealthPath() string { return "/health" }
+
+func (t *TGI) Env() []dto.Env {
+	return []dto.Env{
+		{Key: "HF_HUB_OFFLINE", Value: "1"}, // 只加载本地模型
+	}
+}
+
+// Render 镜像的 entrypoint 为 text-generation-launcher；TGI 不支持 auto 精度，此时交由其自行选择
+func (t *TGI) Render(spec *dto.RequirementSpec, target Target) (*Spec, error) {
+	if err := unsupported(t.ID(), target.Parallelism, false, false); err != nil {
+		return nil, err
+	}
+	opts := options(spec)
+	args := []string{
+		"--model-id", target.ModelDir,
+		"--hostname", target.Host,
+		"--port", strconv.Itoa(target.Port),
+	}
+	if d := dtype(opts); d != "auto" {
+		args = append(args, "--dtype", d)
+	}
+	if trustRemoteCode(opts) {
+		args = append(args, "--trust-remote-code")
+	}
+	if spec.ContextLength > 0 {
+		args = append(args, "--max-total-tokens", strconv.Itoa(spec.ContextLength))
+	}
+	if opts.Quantization != "" {
+		args = append(args, "--quantize", opts.Quantization)
+	}
+	if p := target.Parallelism; p.TensorParallelSize > 1 {
+		args = append(args, "--num-shard", strconv.Itoa(p.TensorParallelSize))
+	}
+
+	rendered := &Spec{Args: args}
+	if target.Local {
+		rendered.Command = []string{"text-generation-launcher"}
+	}
+	return rendered, nil
+}
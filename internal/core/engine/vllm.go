@@ -0,0 +1,69 @@
+package engine
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"fmt"
+	"strconv"
+)
+
+func init() {
+	Registry.Register(&VLLM{})
+}
+
+// VLLM vLLM OpenAI 兼容服务
+type VLLM struct{}
+
+func (v *VLLM) ID() string          { return "vllm" }
+func (v *VLLM) Description() string { return "vLLM OpenAI-compatible server" }
+func (v *VLLM) DefaultImage() string {
+	return "artifacts.iflytek.com/docker-private/aiaas/vllm-openai:v0.4.2"
+}
+func (v *VLLM) DefaultPort() int   { return 8000 }
+func (v *VLLM) HealthPath() string { return "/health" }
+
+func (v *VLLM) Env() []dto.Env {
+	return []dto.Env{
+		{Key: "TRANSFORMERS_OFFLINE", Value: "1"}, // 禁止从 Hugging Face 下载
+		{Key: "HF_HOME", Value: "/tmp"},           // 避免默认缓存目录的权限问题
+	}
+}
+
+// Render 镜像的 entrypoint 即 OpenAI API server，容器中只需传参数；支持张量、流水线并行与基于 Ray 的多节点部署
+func (v *VLLM) Render(spec *dto.RequirementSpec, target Target) (*Spec, error) {
+	opts := options(spec)
+	args := []string{
+		"--host=" + target.Host,
+		"--port=" + strconv.Itoa(target.Port),
+		"--model=" + target.ModelDir, // 与模型挂载路径一致
+		"--dtype=" + dtype(opts),
+		"--served-model-name=" + spec.ModelName,
+	}
+	if trustRemoteCode(opts) {
+		args = append(args, "--trust-remote-code")
+	}
+	if spec.ContextLength > 0 {
+		args = append(args, "--max-model-len="+strconv.Itoa(spec.ContextLength))
+	}
+	if opts.Quantization != "" {
+		args = append(args, "--quantization="+opts.Quantization)
+	}
+	if opts.GPUMemoryUtilization > 0 {
+		args = append(args, fmt.Sprintf("--gpu-memory-utilization=%g", opts.GPUMemoryUtilization))
+	}
+	if p := target.Parallelism; p.TensorParallelSize > 1 {
+		args = append(args, "--tensor-parallel-size="+strconv.Itoa(p.TensorParallelSize))
+	}
+	if p := target.Parallelism; p.PipelineParallelSize > 1 {
+		args = append(args, "--pipeline-parallel-size="+strconv.Itoa(p.PipelineParallelSize))
+	}
+	if target.Parallelism.MultiNode() {
+		// 跨节点时由 Ray 集群调度各节点上的 worker
+		args = append(args, "--distributed-executor-backend=ray")
+	}
+
+	rendered := &Spec{Args: args}
+	if target.Local {
+		rendered.Command = []string{"python3", "-m", "vllm.entrypoints.openai.api_server"}
+	}
+	return rendered, nil
+}
@@ -0,0 +1,265 @@
+package eventbus
+
+import (
+	confSpec "astron-xmod-shim/internal/dto/config"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy 订阅者缓冲区已满时的处理策略
+type Policy string
+
+const (
+	PolicyDropNewest Policy = "drop-newest" // 丢弃新事件
+	PolicyDropOldest Policy = "drop-oldest" // 丢弃缓冲区中最旧的事件
+	PolicyBlock      Policy = "block"       // 阻塞发布方，超过 BlockTimeout 后丢弃新事件
+)
+
+const (
+	defaultBufferSize   = 256
+	defaultBlockTimeout = 100 * time.Millisecond
+)
+
+// Handler 事件处理函数，在订阅者自己的协程中串行调用
+type Handler func(event.ServiceEvent)
+
+// SubscribeOptions 订阅参数
+type SubscribeOptions struct {
+	BufferSize   int
+	Policy       Policy
+	BlockTimeout time.Duration                 // 仅 block 策略生效
+	Filter       func(event.ServiceEvent) bool // 为空时接收全部事件
+}
+
+// OptionsFromConfig 根据全局配置生成订阅参数
+func OptionsFromConfig(cfg *confSpec.EventBusConfig) SubscribeOptions {
+	return SubscribeOptions{
+		BufferSize:   cfg.BufferSize,
+		Policy:       Policy(cfg.Policy),
+		BlockTimeout: time.Duration(cfg.BlockTimeoutMs) * time.Millisecond,
+	}
+}
+
+// SubscriberStats 订阅者投递统计
+type SubscriberStats struct {
+	Name      string `json:"name"`
+	Policy    Policy `json:"policy"`
+	Pending   int    `json:"pending"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+type subscriber struct {
+	name    string
+	opts    SubscribeOptions
+	handler Handler
+	ch      chan event.ServiceEvent
+	sendMu  sync.Mutex // drop-oldest 需要"取出+放入"的原子性
+	done    chan struct{}
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// Bus 进程内的服务事件发布/订阅总线
+// 发布不会因订阅者处理慢而阻塞 reconcile 循环（block 策略除外，且有超时上限）
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[string]*subscriber
+	closed bool
+
+	// publishMu 保证序号分配与入队的原子性，订阅者按序号递增的顺序收到事件
+	publishMu sync.Mutex
+	seq       uint64
+}
+
+// New 创建事件总线
+func New() *Bus {
+	return &Bus{
+		subs: make(map[string]*subscriber),
+	}
+}
+
+// Subscribe 注册订阅者，返回取消订阅函数
+func (b *Bus) Subscribe(name string, handler Handler, opts SubscribeOptions) (func(), error) {
+	if handler == nil {
+		return nil, fmt.Errorf("subscriber %s: handler is nil", name)
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+	switch opts.Policy {
+	case "":
+		opts.Policy = PolicyDropNewest
+	case PolicyDropNewest, PolicyDropOldest, PolicyBlock:
+	default:
+		return nil, fmt.Errorf("subscriber %s: unsupported policy %s", name, opts.Policy)
+	}
+	if opts.Policy == PolicyBlock && opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = defaultBlockTimeout
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, fmt.Errorf("event bus closed")
+	}
+	if _, exists := b.subs[name]; exists {
+		return nil, fmt.Errorf("subscriber %s already registered", name)
+	}
+
+	sub := &subscriber{
+		name:    name,
+		opts:    opts,
+		handler: handler,
+		ch:      make(chan event.ServiceEvent, opts.BufferSize),
+		done:    make(chan struct{}),
+	}
+	b.subs[name] = sub
+	go sub.run()
+
+	return func() { b.unsubscribe(name) }, nil
+}
+
+func (b *Bus) unsubscribe(name string) {
+	b.mu.Lock()
+	sub, ok := b.subs[name]
+	if ok {
+		delete(b.subs, name)
+		close(sub.ch)
+	}
+	b.mu.Unlock()
+	if ok {
+		<-sub.done
+	}
+}
+
+// Publish 分配序号与时间戳后投递给全部订阅者，返回实际发布的事件
+// nil Bus 上调用为空操作，便于未启用事件的场景（如单元测试）
+func (b *Bus) Publish(ev event.ServiceEvent) event.ServiceEvent {
+	if b == nil {
+		return ev
+	}
+	b.publishMu.Lock()
+	defer b.publishMu.Unlock()
+	b.seq++
+	ev.Seq = b.seq
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ev
+	}
+	for _, sub := range b.subs {
+		if sub.opts.Filter != nil && !sub.opts.Filter(ev) {
+			continue
+		}
+		sub.offer(ev)
+	}
+	return ev
+}
+
+// Stats 返回各订阅者的投递统计
+func (b *Bus) Stats() []SubscriberStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	stats := make([]SubscriberStats, 0, len(b.subs))
+	for _, sub := range b.subs {
+		stats = append(stats, SubscriberStats{
+			Name:      sub.name,
+			Policy:    sub.opts.Policy,
+			Pending:   len(sub.ch),
+			Delivered: sub.delivered.Load(),
+			Dropped:   sub.dropped.Load(),
+		})
+	}
+	return stats
+}
+
+// Close 关闭总线，等待订阅者处理完已缓冲的事件
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[string]*subscriber)
+	for _, sub := range subs {
+		close(sub.ch)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		<-sub.done
+	}
+}
+
+// offer 按订阅者的策略投递事件
+func (s *subscriber) offer(ev event.ServiceEvent) {
+	select {
+	case s.ch <- ev:
+		return
+	default:
+	}
+
+	switch s.opts.Policy {
+	case PolicyDropOldest:
+		s.sendMu.Lock()
+		defer s.sendMu.Unlock()
+		for {
+			select {
+			case s.ch <- ev:
+				return
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	case PolicyBlock:
+		timer := time.NewTimer(s.opts.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- ev:
+		case <-timer.C:
+			s.drop(ev)
+		}
+	default:
+		s.drop(ev)
+	}
+}
+
+func (s *subscriber) drop(ev event.ServiceEvent) {
+	if s.dropped.Add(1) == 1 {
+		log.Warn("event subscriber %s is full, dropping events (first: seq %d)", s.name, ev.Seq)
+	}
+}
+
+func (s *subscriber) run() {
+	defer close(s.done)
+	for ev := range s.ch {
+		s.handle(ev)
+	}
+}
+
+// handle 调用处理函数，防止订阅者 panic 影响其他订阅者
+func (s *subscriber) handle(ev event.ServiceEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("event subscriber %s panic on seq %d: %v", s.name, ev.Seq, r)
+		}
+	}()
+	s.handler(ev)
+	s.delivered.Add(1)
+}
@@ -0,0 +1,131 @@
+package eventbus
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	confSpec "astron-xmod-shim/internal/dto/config"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// 测试事件按序号顺序投递给订阅者，过滤器生效
+func TestBus_PublishAndFilter(t *testing.T) {
+	bus := New()
+	var mu sync.Mutex
+	var all, errs []event.ServiceEvent
+	_, err := bus.Subscribe("all", func(ev event.ServiceEvent) {
+		mu.Lock()
+		all = append(all, ev)
+		mu.Unlock()
+	}, SubscribeOptions{})
+	require.NoError(t, err)
+	_, err = bus.Subscribe("errors", func(ev event.ServiceEvent) {
+		mu.Lock()
+		errs = append(errs, ev)
+		mu.Unlock()
+	}, SubscribeOptions{Filter: func(ev event.ServiceEvent) bool { return ev.Type == event.EventError }})
+	require.NoError(t, err)
+
+	_, err = bus.Subscribe("all", func(event.ServiceEvent) {}, SubscribeOptions{})
+	assert.Error(t, err)
+
+	bus.Publish(event.ServiceEvent{Type: event.EventPhaseChanged, ServiceID: "svc"})
+	bus.Publish(event.ServiceEvent{Type: event.EventError, ServiceID: "svc"})
+	bus.Close()
+
+	require.Len(t, all, 2)
+	assert.Equal(t, uint64(1), all[0].Seq)
+	assert.Equal(t, uint64(2), all[1].Seq)
+	assert.False(t, all[0].Timestamp.IsZero())
+	require.Len(t, errs, 1)
+	assert.Equal(t, event.EventError, errs[0].Type)
+}
+
+// 测试并发发布时每个订阅者都按序号递增的顺序收到事件
+func TestBus_ConcurrentPublishOrdered(t *testing.T) {
+	const publishers, perPublisher = 8, 200
+	bus := New()
+	seqs := map[string][]uint64{}
+	var mu sync.Mutex
+	for _, name := range []string{"a", "b"} {
+		_, err := bus.Subscribe(name, func(ev event.ServiceEvent) {
+			mu.Lock()
+			seqs[name] = append(seqs[name], ev.Seq)
+			mu.Unlock()
+		}, SubscribeOptions{BufferSize: publishers * perPublisher})
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perPublisher; j++ {
+				bus.Publish(event.ServiceEvent{Type: event.EventPhaseChanged, ServiceID: "svc"})
+			}
+		}()
+	}
+	wg.Wait()
+	bus.Close()
+
+	for name, got := range seqs {
+		require.Len(t, got, publishers*perPublisher, name)
+		for i, seq := range got {
+			require.Equal(t, uint64(i+1), seq, "subscriber %s received seq out of order", name)
+		}
+	}
+}
+
+// 测试缓冲区满时的丢弃策略不会阻塞发布方
+func TestBus_DropPolicies(t *testing.T) {
+	for _, policy := range []Policy{PolicyDropNewest, PolicyDropOldest, PolicyBlock} {
+		t.Run(string(policy), func(t *testing.T) {
+			bus := New()
+			release := make(chan struct{})
+			var mu sync.Mutex
+			var seqs []uint64
+			_, err := bus.Subscribe("slow", func(ev event.ServiceEvent) {
+				<-release
+				mu.Lock()
+				seqs = append(seqs, ev.Seq)
+				mu.Unlock()
+			}, SubscribeOptions{BufferSize: 2, Policy: policy, BlockTimeout: 10 * time.Millisecond})
+			require.NoError(t, err)
+
+			start := time.Now()
+			for i := 0; i < 10; i++ {
+				bus.Publish(event.ServiceEvent{ServiceID: "svc"})
+			}
+			assert.Less(t, time.Since(start), time.Second)
+
+			stats := bus.Stats()
+			require.Len(t, stats, 1)
+			assert.NotZero(t, stats[0].Dropped)
+
+			close(release)
+			bus.Close()
+			if policy == PolicyDropOldest {
+				// 最新的事件被保留
+				assert.Equal(t, uint64(10), seqs[len(seqs)-1])
+			}
+		})
+	}
+}
+
+// 测试 nil 总线上发布为空操作
+func TestBus_NilPublish(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() { bus.Publish(event.ServiceEvent{ServiceID: "svc"}) })
+}
@@ -0,0 +1,25 @@
+package eventbus
+
+import (
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+)
+
+// LogSubscriberName 日志订阅者名称
+const LogSubscriberName = "log"
+
+// LogHandler 将服务事件输出到日志
+func LogHandler(ev event.ServiceEvent) {
+	switch ev.Type {
+	case event.EventPhaseChanged:
+		log.Info("[event %d] service %s phase %s -> %s", ev.Seq, ev.ServiceID, ev.From, ev.To)
+	case event.EventGoalCompleted:
+		log.Info("[event %d] service %s goal %s completed", ev.Seq, ev.ServiceID, ev.Goal)
+	case event.EventError:
+		log.Warn("[event %d] service %s error (goal: %s): %s", ev.Seq, ev.ServiceID, ev.Goal, ev.Error)
+	case event.EventRolledBack:
+		log.Warn("[event %d] service %s rolled back: %s", ev.Seq, ev.ServiceID, ev.Error)
+	default:
+		log.Info("[event %d] service %s %s", ev.Seq, ev.ServiceID, ev.Type)
+	}
+}
@@ -3,12 +3,19 @@ package goal
 import (
 	"astron-xmod-shim/internal/core/shimlet"
 	dto "astron-xmod-shim/internal/dto/deploy"
+	"sync"
 )
 
+// Context 一次 reconcile 中各目标共享的上下文。互不依赖的目标并发执行，
+// 键值与执行结果通过方法并发安全地读写；修改 DeploySpec 的目标需被读取它的目标依赖
 type Context struct {
-	Data       map[string]any // 存储键值对，比如 app_id, url 等
+	Data map[string]any // 存储键值对，比如 app_id, url 等
+	// DeploySpec 本轮部署期望的拷贝，修改在本轮结束后由 reconciler 写回存储
 	DeploySpec *dto.RequirementSpec
 	Shimlet    shimlet.Shimlet
+
+	mu      sync.RWMutex
+	details map[string]any // 各目标记录的执行结果，由 reconciler 写入收敛进度
 }
 
 // NewContext 创建一个新的上下文实例
@@ -20,18 +27,40 @@ func NewContext() *Context {
 
 // Set 向上下文中存入一个值
 func (c *Context) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.Data[key] = value
 }
 
 // Get 从上下文中取出一个值（返回 any，需类型断言）
 func (c *Context) Get(key string) any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.Data[key]
 }
 
 // GetString 安全地获取字符串值，如果不存在或类型不对，返回空字符串
 func (c *Context) GetString(key string) string {
-	if v, ok := c.Data[key].(string); ok {
+	if v, ok := c.Get(key).(string); ok {
 		return v
 	}
 	return ""
 }
+
+// SetDetail 记录目标的执行结果（如冒烟测试耗时），可通过进度接口查询
+func (c *Context) SetDetail(goalName string, detail any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.details == nil {
+		c.details = make(map[string]any)
+	}
+	c.details[goalName] = detail
+}
+
+// Detail 返回目标记录的执行结果
+func (c *Context) Detail(goalName string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	detail, ok := c.details[goalName]
+	return detail, ok
+}
@@ -1 +1,88 @@
 package goal
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试当前目标设置了 HoldPhase 时，运行中的服务上报 HoldPhase
+func TestGoalSet_ObservedPhase(t *testing.T) {
+	gs := &GoalSet{Goals: []Goal{{Name: "deploy"}, {Name: "verify", HoldPhase: dto.PhaseVerifying}}}
+	verifying := dto.ServiceProgress{Goals: []dto.GoalProgress{{Name: "deploy", Achieved: true}, {Name: "verify"}}}
+	deploying := dto.ServiceProgress{Goals: []dto.GoalProgress{{Name: "deploy"}, {Name: "verify"}}}
+	done := dto.ServiceProgress{Goals: []dto.GoalProgress{{Name: "deploy", Achieved: true}, {Name: "verify", Achieved: true}}}
+
+	assert.Equal(t, dto.PhaseVerifying, gs.ObservedPhase(dto.PhaseRunning, verifying))
+	assert.Equal(t, dto.PhasePending, gs.ObservedPhase(dto.PhasePending, verifying), "only running services are held")
+	assert.Equal(t, dto.PhaseRunning, gs.ObservedPhase(dto.PhaseRunning, deploying))
+	assert.Equal(t, dto.PhaseRunning, gs.ObservedPhase(dto.PhaseRunning, done))
+
+	// 依赖图中与其他目标并行的验证目标
+	dag := &GoalSet{Goals: []Goal{{Name: "deploy"}, {Name: "warmup"}, {Name: "verify", HoldPhase: dto.PhaseVerifying, DependsOn: []string{"deploy"}}}}
+	warming := dto.ServiceProgress{Goals: []dto.GoalProgress{{Name: "deploy", Achieved: true}, {Name: "warmup"}, {Name: "verify"}}}
+	assert.Equal(t, dto.PhaseVerifying, dag.ObservedPhase(dto.PhaseRunning, warming))
+}
+
+// 测试未声明依赖时按顺序依赖前一个目标，声明依赖后按依赖图执行
+func TestGoalSet_Graph(t *testing.T) {
+	sequential := &GoalSet{Goals: []Goal{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	assert.Equal(t, []dto.GoalNode{{Name: "a"}, {Name: "b", DependsOn: []string{"a"}}, {Name: "c", DependsOn: []string{"b"}}}, sequential.Graph())
+
+	dag := &GoalSet{Goals: []Goal{{Name: "a"}, {Name: "b"}, {Name: "c", DependsOn: []string{"a", "b"}}}}
+	assert.Equal(t, []dto.GoalNode{{Name: "a"}, {Name: "b"}, {Name: "c", DependsOn: []string{"a", "b"}}}, dag.Graph())
+	assert.NoError(t, dag.Validate())
+}
+
+// 测试拓扑顺序：依赖排在依赖它的目标之前（补偿时按其逆序执行）
+func TestGoalSet_TopologicalOrder(t *testing.T) {
+	sequential := &GoalSet{Goals: []Goal{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	assert.Equal(t, []int{0, 1, 2}, sequential.TopologicalOrder())
+
+	// 目标定义在依赖之前
+	dag := &GoalSet{Goals: []Goal{{Name: "apply", DependsOn: []string{"quota", "pull"}}, {Name: "pull"}, {Name: "quota"}}}
+	assert.Equal(t, []int{2, 1, 0}, dag.TopologicalOrder())
+}
+
+// 测试注册时校验目标依赖
+func TestGoalSetBuilder_Validate(t *testing.T) {
+	_, err := NewGoalSetBuilder("cycle").
+		AddGoal(Goal{Name: "a", DependsOn: []string{"c"}}).
+		AddGoal(Goal{Name: "b", DependsOn: []string{"a"}}).
+		AddGoal(Goal{Name: "c", DependsOn: []string{"b"}}).
+		Build()
+	assert.True(t, errors.Is(err, ErrDependencyCycle))
+	assert.ErrorContains(t, err, "a -> c -> b -> a")
+
+	_, err = NewGoalSetBuilder("self").AddGoal(Goal{Name: "a", DependsOn: []string{"a"}}).Build()
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+
+	_, err = NewGoalSetBuilder("unknown").AddGoal(Goal{Name: "a", DependsOn: []string{"b"}}).Build()
+	assert.ErrorContains(t, err, "unknown goal b")
+
+	_, err = NewGoalSetBuilder("duplicate").AddGoal(Goal{Name: "a"}).AddGoal(Goal{Name: "a"}).Build()
+	assert.ErrorContains(t, err, "duplicate goal a")
+
+	_, err = NewGoalSetBuilder("empty").Build()
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		NewGoalSetBuilder("cycle").AddGoal(Goal{Name: "a", DependsOn: []string{"a"}}).BuildAndRegister()
+	})
+	_, registered := Registry["cycle"]
+	assert.False(t, registered)
+}
+
+// 测试目标执行结果的记录
+func TestContext_Detail(t *testing.T) {
+	ctx := NewContext()
+	_, ok := ctx.Detail("verify")
+	assert.False(t, ok)
+
+	ctx.SetDetail("verify", 42)
+	detail, ok := ctx.Detail("verify")
+	assert.True(t, ok)
+	assert.Equal(t, 42, detail)
+}
@@ -1,27 +1,231 @@
 package goal
 
-import "time"
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Goal struct {
 	Name       string
 	IsAchieved func(ctx *Context) bool
 	Ensure     func(ctx *Context) error
+	// HoldPhase 目标为当前目标（依赖已达成而自身未达成的目标）且运行时已 running 时上报的部署阶段，
+	// 为空时上报运行时阶段；用于部署后验证，验证通过前服务不视为 running
+	HoldPhase dto.DeployPhase
+	// Type 声明式目标使用的原语类型，Go 代码中定义的目标为空
+	Type string
+	// DependsOn 依赖的目标，依赖在本轮 reconcile 中全部达成后才执行，互不依赖的目标并发执行。
+	// 目标集合中没有任何目标声明依赖时，每个目标依赖前一个目标，按顺序执行
+	DependsOn []string
+	// Compensate 补偿动作，为空时不补偿。回滚时对本轮调用过 Ensure 的目标按依赖的逆序执行，
+	// 撤销 Ensure 在运行时之外产生的副作用（如释放预占的配额、撤销通知）
+	Compensate func(ctx *Context) error
 }
 type GoalSet struct {
 	Name       string
 	Goals      []Goal
 	MaxRetries int
 	Timeout    time.Duration
+	// Source 声明式目标集合的定义文件，Go 代码中注册的目标集合为空
+	Source string
+	// Rollback 收敛成功后将部署期望记录为最近一次正常版本，更新超时后自动回滚到该版本
+	Rollback bool
+	// Teardown 下线目标集合：收敛后删除服务的部署期望、进度与各模块按服务保存的状态
+	Teardown bool
 }
 
 var Registry = map[string]*GoalSet{}
 
+// teardownHooks 服务下线收敛后调用，清理各模块按服务保存的状态（如目标执行记录）
+var teardownHooks []func(serviceID string)
+
+// OnTeardown 注册服务下线后的清理函数，在 init() 或启动阶段（工作协程启动前）调用
+func OnTeardown(hook func(serviceID string)) {
+	teardownHooks = append(teardownHooks, hook)
+}
+
+// RunTeardownHooks 服务下线后依次调用全部清理函数
+func RunTeardownHooks(serviceID string) {
+	for _, hook := range teardownHooks {
+		hook(serviceID)
+	}
+}
+
+// GoalNames 按顺序返回目标名称
+func (gs *GoalSet) GoalNames() []string {
+	names := make([]string, 0, len(gs.Goals))
+	for _, g := range gs.Goals {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// sequential 没有任何目标声明依赖时，目标集合按顺序执行
+func (gs *GoalSet) sequential() bool {
+	for _, g := range gs.Goals {
+		if len(g.DependsOn) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Dependencies 返回第 i 个目标依赖的目标
+func (gs *GoalSet) Dependencies(i int) []string {
+	if gs.sequential() {
+		if i == 0 {
+			return nil
+		}
+		return []string{gs.Goals[i-1].Name}
+	}
+	return gs.Goals[i].DependsOn
+}
+
+// Graph 按顺序返回目标及其依赖
+func (gs *GoalSet) Graph() []dto.GoalNode {
+	nodes := make([]dto.GoalNode, 0, len(gs.Goals))
+	for i, g := range gs.Goals {
+		nodes = append(nodes, dto.GoalNode{Name: g.Name, DependsOn: gs.Dependencies(i)})
+	}
+	return nodes
+}
+
+// TopologicalOrder 按依赖的先后返回目标下标：依赖排在依赖它的目标之前，其余按定义顺序
+func (gs *GoalSet) TopologicalOrder() []int {
+	index := make(map[string]int, len(gs.Goals))
+	for i, g := range gs.Goals {
+		index[g.Name] = i
+	}
+	order := make([]int, 0, len(gs.Goals))
+	visited := make([]bool, len(gs.Goals))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, dep := range gs.Dependencies(i) {
+			visit(index[dep])
+		}
+		order = append(order, i)
+	}
+	for i := range gs.Goals {
+		visit(i)
+	}
+	return order
+}
+
+// Validate 校验目标名称与依赖：名称唯一、依赖的目标存在且不存在循环依赖
+func (gs *GoalSet) Validate() error {
+	if len(gs.Goals) == 0 {
+		return fmt.Errorf("goal set %s has no goals", gs.Name)
+	}
+	index := make(map[string]int, len(gs.Goals))
+	for i, g := range gs.Goals {
+		if g.Name == "" {
+			return fmt.Errorf("goal set %s: goals[%d] has no name", gs.Name, i)
+		}
+		if _, ok := index[g.Name]; ok {
+			return fmt.Errorf("goal set %s: duplicate goal %s", gs.Name, g.Name)
+		}
+		index[g.Name] = i
+	}
+	for i, g := range gs.Goals {
+		seen := make(map[string]bool)
+		for _, dep := range gs.Dependencies(i) {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("goal set %s: goal %s depends on unknown goal %s", gs.Name, g.Name, dep)
+			}
+			if seen[dep] {
+				return fmt.Errorf("goal set %s: goal %s depends on %s twice", gs.Name, g.Name, dep)
+			}
+			seen[dep] = true
+		}
+	}
+
+	// 深度优先遍历，遇到仍在访问路径上的目标即存在循环
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(gs.Goals))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch states[i] {
+		case visiting:
+			start := 0
+			for path[start] != gs.Goals[i].Name {
+				start++
+			}
+			cycle := append(path[start:], gs.Goals[i].Name)
+			return fmt.Errorf("goal set %s: %w: %s", gs.Name, ErrDependencyCycle, strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+		states[i] = visiting
+		path = append(path, gs.Goals[i].Name)
+		for _, dep := range gs.Dependencies(i) {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = visited
+		return nil
+	}
+	for i := range gs.Goals {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrDependencyCycle 目标之间存在循环依赖
+var ErrDependencyCycle = errors.New("goal dependency cycle")
+
+// ObservedPhase 根据收敛进度修正运行时上报的部署阶段：
+// 运行时已 running 但当前目标之一设置了 HoldPhase 时，上报 HoldPhase
+func (gs *GoalSet) ObservedPhase(runtimePhase dto.DeployPhase, serviceProgress dto.ServiceProgress) dto.DeployPhase {
+	if runtimePhase != dto.PhaseRunning {
+		return runtimePhase
+	}
+	// 以目标集合的依赖关系为准判断当前目标
+	deps := make(map[string][]string, len(gs.Goals))
+	for _, node := range gs.Graph() {
+		deps[node.Name] = node.DependsOn
+	}
+	goals := make([]dto.GoalProgress, len(serviceProgress.Goals))
+	copy(goals, serviceProgress.Goals)
+	for i := range goals {
+		goals[i].DependsOn = deps[goals[i].Name]
+	}
+	serviceProgress.Goals = goals
+
+	for _, current := range serviceProgress.ActiveGoals() {
+		for _, g := range gs.Goals {
+			if g.Name == current && g.HoldPhase != "" {
+				return g.HoldPhase
+			}
+		}
+	}
+	return runtimePhase
+}
+
 // GoalSetBuilder 构建器
 type GoalSetBuilder struct {
 	name       string
 	goals      []Goal
 	maxRetries int
 	timeout    time.Duration
+	source     string
+	rollback   bool
+	teardown   bool
 }
 
 func NewGoalSetBuilder(name string) *GoalSetBuilder {
@@ -48,12 +252,46 @@ func (b *GoalSetBuilder) WithTimeout(d time.Duration) *GoalSetBuilder {
 	return b
 }
 
-func (b *GoalSetBuilder) BuildAndRegister() {
+// WithSource 记录声明式目标集合的定义文件
+func (b *GoalSetBuilder) WithSource(path string) *GoalSetBuilder {
+	b.source = path
+	return b
+}
 
-	Registry[b.name] = &GoalSet{
+// WithRollback 启用回滚：记录最近一次正常的部署期望，更新超时后自动回滚
+func (b *GoalSetBuilder) WithRollback() *GoalSetBuilder {
+	b.rollback = true
+	return b
+}
+
+// WithTeardown 标记为下线目标集合：收敛后释放服务的部署期望与进度
+func (b *GoalSetBuilder) WithTeardown() *GoalSetBuilder {
+	b.teardown = true
+	return b
+}
+
+// Build 构建目标集合并校验目标依赖
+func (b *GoalSetBuilder) Build() (*GoalSet, error) {
+	gs := &GoalSet{
 		Name:       b.name,
 		Goals:      b.goals,
 		MaxRetries: b.maxRetries,
 		Timeout:    b.timeout,
+		Source:     b.source,
+		Rollback:   b.rollback,
+		Teardown:   b.teardown,
+	}
+	if err := gs.Validate(); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// BuildAndRegister 构建并注册目标集合；内置目标集合在 init() 中注册，依赖有误（如循环依赖）属于编程错误，直接 panic
+func (b *GoalSetBuilder) BuildAndRegister() {
+	gs, err := b.Build()
+	if err != nil {
+		panic(err)
 	}
+	Registry[b.name] = gs
 }
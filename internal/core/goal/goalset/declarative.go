@@ -0,0 +1,164 @@
+package goalset
+
+import (
+	"astron-xmod-shim/internal/core/goal"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	"astron-xmod-shim/pkg/log"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultDeclarativeMaxRetries = 10
+	defaultDeclarativeTimeout    = time.Hour
+)
+
+// LoadDeclarative 加载目录中的声明式目标集合定义（*.yaml / *.yml）并注册。
+// 全部文件校验通过后才注册，任一文件有误时返回全部错误，不注册任何目标集合
+func LoadDeclarative(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read goal set dir: %w", err)
+	}
+
+	var (
+		goalSets []*goal.GoalSet
+		names    []string
+		errs     []error
+		sources  = make(map[string]string)
+	)
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		fileCfg, err := readGoalSetFile(path)
+		if err == nil {
+			err = validateGoalSetFile(fileCfg)
+		}
+		var goalSet *goal.GoalSet
+		if err == nil {
+			// 构建时校验目标依赖（未知目标、循环依赖）
+			goalSet, err = newDeclarativeGoalSet(fileCfg, path).Build()
+		}
+		if err == nil {
+			if _, ok := goal.Registry[fileCfg.Name]; ok {
+				err = fmt.Errorf("goal set %s is already registered", fileCfg.Name)
+			} else if other, ok := sources[fileCfg.Name]; ok {
+				err = fmt.Errorf("goal set %s is already defined in %s", fileCfg.Name, other)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		sources[fileCfg.Name] = path
+		names = append(names, fileCfg.Name)
+		goalSets = append(goalSets, goalSet)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, goalSet := range goalSets {
+		goal.Registry[goalSet.Name] = goalSet
+	}
+	sort.Strings(names)
+	log.Info("loaded %d declarative goal sets from %s: %v", len(names), dir, names)
+	return nil
+}
+
+// readGoalSetFile 读取目标集合定义文件，不允许出现未定义的字段
+func readGoalSetFile(path string) (*confSpec.GoalSetFileConfig, error) {
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read goal set: %w", err)
+	}
+	fileCfg := &confSpec.GoalSetFileConfig{}
+	if err := v.UnmarshalExact(fileCfg); err != nil {
+		return nil, fmt.Errorf("parse goal set: %w", err)
+	}
+	return fileCfg, nil
+}
+
+// validateGoalSetFile 校验目标集合及其中的每个目标
+func validateGoalSetFile(fileCfg *confSpec.GoalSetFileConfig) error {
+	switch {
+	case fileCfg.Name == "":
+		return errors.New("name is required")
+	case fileCfg.MaxRetries != nil && *fileCfg.MaxRetries < 0:
+		return fmt.Errorf("invalid max-retries: %d", *fileCfg.MaxRetries)
+	case fileCfg.TimeoutMs < 0:
+		return fmt.Errorf("invalid timeout-ms: %d", fileCfg.TimeoutMs)
+	case len(fileCfg.Goals) == 0:
+		return errors.New("at least one goal is required")
+	}
+
+	seen := make(map[string]bool, len(fileCfg.Goals))
+	for i := range fileCfg.Goals {
+		goalCfg := &fileCfg.Goals[i]
+		// 引用内置目标时默认使用内置目标的名称
+		if goalCfg.Name == "" && goalCfg.Type == primitiveBuiltin {
+			goalCfg.Name = goalCfg.Params.Goal
+		}
+		if goalCfg.Name == "" {
+			return fmt.Errorf("goals[%d]: name is required", i)
+		}
+		if seen[goalCfg.Name] {
+			return fmt.Errorf("goals[%d]: duplicate goal name %s", i, goalCfg.Name)
+		}
+		seen[goalCfg.Name] = true
+		if err := validateGoalConfig(*goalCfg); err != nil {
+			return fmt.Errorf("goal %s: %w", goalCfg.Name, err)
+		}
+		if goalCfg.Compensate != nil {
+			if err := validateCompensateConfig(*goalCfg); err != nil {
+				return fmt.Errorf("goal %s: %w", goalCfg.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newDeclarativeGoalSet 由校验通过的定义构造目标集合
+func newDeclarativeGoalSet(fileCfg *confSpec.GoalSetFileConfig, path string) *goal.GoalSetBuilder {
+	maxRetries := defaultDeclarativeMaxRetries
+	if fileCfg.MaxRetries != nil {
+		maxRetries = *fileCfg.MaxRetries
+	}
+	timeout := defaultDeclarativeTimeout
+	if fileCfg.TimeoutMs > 0 {
+		timeout = time.Duration(fileCfg.TimeoutMs) * time.Millisecond
+	}
+
+	builder := goal.NewGoalSetBuilder(fileCfg.Name).
+		WithMaxRetries(maxRetries).
+		WithTimeout(timeout).
+		WithSource(path)
+	if fileCfg.Rollback {
+		builder.WithRollback()
+	}
+	if fileCfg.Teardown {
+		builder.WithTeardown()
+	}
+	for _, goalCfg := range fileCfg.Goals {
+		g := primitives[goalCfg.Type].build(fileCfg.Name, goalCfg)
+		if goalCfg.Compensate != nil {
+			g.Compensate = buildCompensate(fileCfg.Name, goalCfg)
+		}
+		builder.AddGoal(g)
+	}
+	return builder
+}
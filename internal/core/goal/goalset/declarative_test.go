@@ -0,0 +1,374 @@
+package goalset
+
+import (
+	"astron-xmod-shim/internal/core/goal"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeGoalSets 将目标集合定义写入临时目录
+func writeGoalSets(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+// 测试加载声明式目标集合：引用内置目标、默认值与目标原语
+func TestLoadDeclarative(t *testing.T) {
+	dir := writeGoalSets(t, map[string]string{
+		"quota.yaml": `
+name: quota-checked-deploy
+max-retries: 3
+goals:
+  - type: builtin
+    params:
+      goal: map-model-path
+  - name: quota-check
+    type: http-check
+    retries: 2
+    timeout-ms: 5000
+    params:
+      url: "http://quota.local/check?model={{model_name}}"
+      method: post
+      expect-status: 204
+  - name: apply
+    type: shimlet-apply
+  - name: wait-running
+    type: wait-for-phase
+    hold-phase: verifying
+    timeout-ms: 600000
+`,
+		"README.md": "not a goal set",
+	})
+	t.Cleanup(func() { delete(goal.Registry, "quota-checked-deploy") })
+
+	require.NoError(t, LoadDeclarative(dir))
+	goalSet, ok := goal.Registry["quota-checked-deploy"]
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "quota.yaml"), goalSet.Source)
+	assert.Equal(t, 3, goalSet.MaxRetries)
+	assert.Equal(t, time.Hour, goalSet.Timeout)
+	assert.Equal(t, []string{"map-model-path", "quota-check", "apply", "wait-running"}, goalSet.GoalNames())
+	assert.Equal(t, primitiveHTTPCheck, goalSet.Goals[1].Type)
+	assert.Equal(t, dto.PhaseVerifying, goalSet.Goals[3].HoldPhase)
+}
+
+// 测试任一文件有误时返回全部错误，且不注册任何目标集合
+func TestLoadDeclarative_Invalid(t *testing.T) {
+	dir := writeGoalSets(t, map[string]string{
+		"a-valid.yaml": `
+name: valid-set
+goals:
+  - name: apply
+    type: shimlet-apply
+`,
+		"b-builtin-clash.yaml": `
+name: opensource-llm-deploy
+goals:
+  - name: apply
+    type: shimlet-apply
+`,
+		"c-unknown-field.yaml": `
+name: typo-set
+goals:
+  - name: apply
+    type: shimlet-apply
+    retrys: 3
+`,
+		"d-bad-param.yaml": `
+name: bad-param-set
+goals:
+  - name: notify
+    type: exec-hook
+    params:
+      url: "http://example.com"
+      command: ["notify.sh"]
+`,
+		"e-placeholder.yaml": `
+name: placeholder-set
+goals:
+  - name: check
+    type: http-check
+    params:
+      url: "http://quota.local/{{model}}"
+`,
+	})
+
+	err := LoadDeclarative(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "goal set opensource-llm-deploy is already registered")
+	assert.Contains(t, err.Error(), "retrys")
+	assert.Contains(t, err.Error(), "param url is not supported by exec-hook")
+	assert.Contains(t, err.Error(), "unknown placeholder {{model}}")
+	_, ok := goal.Registry["valid-set"]
+	assert.False(t, ok)
+
+	assert.Error(t, LoadDeclarative(filepath.Join(dir, "missing")))
+	assert.NoError(t, LoadDeclarative(""))
+}
+
+// 测试 depends-on：声明依赖的目标集合按依赖图执行，循环依赖与未知依赖在加载时报错
+func TestLoadDeclarative_DependsOn(t *testing.T) {
+	dir := writeGoalSets(t, map[string]string{
+		"dag.yaml": `
+name: dag-deploy
+goals:
+  - type: builtin
+    params:
+      goal: map-model-path
+  - name: quota-check
+    type: exec-hook
+    params:
+      command: ["true"]
+  - name: apply
+    type: shimlet-apply
+    depends-on: [map-model-path, quota-check]
+`,
+	})
+	t.Cleanup(func() { delete(goal.Registry, "dag-deploy") })
+	require.NoError(t, LoadDeclarative(dir))
+	goalSet := goal.Registry["dag-deploy"]
+	assert.Equal(t, []dto.GoalNode{
+		{Name: "map-model-path", DependsOn: nil},
+		{Name: "quota-check", DependsOn: nil},
+		{Name: "apply", DependsOn: []string{"map-model-path", "quota-check"}},
+	}, goalSet.Graph())
+
+	dir = writeGoalSets(t, map[string]string{
+		"cycle.yaml": `
+name: cycle-set
+goals:
+  - name: a
+    type: shimlet-apply
+    depends-on: [b]
+  - name: b
+    type: wait-for-phase
+    depends-on: [a]
+`,
+		"unknown.yaml": `
+name: unknown-dep-set
+goals:
+  - name: apply
+    type: shimlet-apply
+    depends-on: [quota-check]
+`,
+	})
+	err := LoadDeclarative(dir)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, goal.ErrDependencyCycle)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+	assert.Contains(t, err.Error(), "unknown goal quota-check")
+	_, ok := goal.Registry["unknown-dep-set"]
+	assert.False(t, ok)
+}
+
+// 测试 rollback 与 compensate：补偿动作复用 exec-hook，按目标名记录结果
+func TestLoadDeclarative_Compensate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "released")
+	dir := writeGoalSets(t, map[string]string{
+		"rollback.yaml": `
+name: rollback-deploy
+rollback: true
+goals:
+  - name: reserve-quota
+    type: exec-hook
+    params:
+      command: ["true"]
+    compensate:
+      type: exec-hook
+      params:
+        command: ["/bin/sh", "-c", "echo $XMOD_SERVICE_ID > ` + out + `"]
+  - name: apply
+    type: shimlet-apply
+`,
+	})
+	t.Cleanup(func() { delete(goal.Registry, "rollback-deploy") })
+	require.NoError(t, LoadDeclarative(dir))
+	goalSet := goal.Registry["rollback-deploy"]
+	assert.True(t, goalSet.Rollback)
+	require.NotNil(t, goalSet.Goals[0].Compensate)
+	assert.Nil(t, goalSet.Goals[1].Compensate)
+
+	ctx := declarativeContext("hook-compensate", &dto.RuntimeStatus{Status: dto.PhaseRunning})
+	t.Cleanup(func() { forgetGoalRuns("hook-compensate") })
+	require.NoError(t, goalSet.Goals[0].Compensate(ctx))
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "hook-compensate\n", string(content))
+	_, ok := ctx.Detail("reserve-quota-compensate")
+	assert.True(t, ok)
+
+	dir = writeGoalSets(t, map[string]string{
+		"bad-type.yaml": `
+name: bad-compensate-type
+goals:
+  - name: apply
+    type: shimlet-apply
+    compensate:
+      type: shimlet-apply
+`,
+		"bad-param.yaml": `
+name: bad-compensate-param
+goals:
+  - name: notify
+    type: exec-hook
+    params:
+      command: ["true"]
+    compensate:
+      type: http-check
+      params:
+        command: ["true"]
+`,
+	})
+	err = LoadDeclarative(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `goal apply: compensate: unsupported type "shimlet-apply"`)
+	assert.Contains(t, err.Error(), "goal notify: compensate: param command is not supported by http-check")
+}
+
+// 测试目标参数与选项的校验
+func TestValidateGoalConfig(t *testing.T) {
+	cases := map[string]confSpec.GoalConfig{
+		"unknown type":       {Name: "x", Type: "webhook"},
+		"unknown builtin":    {Name: "x", Type: primitiveBuiltin, Params: confSpec.GoalParamsConfig{Goal: "missing"}},
+		"unknown phase":      {Name: "x", Type: primitiveWaitForPhase, Params: confSpec.GoalParamsConfig{Phase: "ready"}},
+		"retries on wait":    {Name: "x", Type: primitiveWaitForPhase, Retries: 1},
+		"timeout on apply":   {Name: "x", Type: primitiveShimletApply, TimeoutMs: 100},
+		"relative url":       {Name: "x", Type: primitiveHTTPCheck, Params: confSpec.GoalParamsConfig{URL: "/health"}},
+		"bad method":         {Name: "x", Type: primitiveHTTPCheck, Params: confSpec.GoalParamsConfig{URL: "http://a", Method: "FETCH"}},
+		"bad expect status":  {Name: "x", Type: primitiveHTTPCheck, Params: confSpec.GoalParamsConfig{URL: "http://a", ExpectStatus: 42}},
+		"missing command":    {Name: "x", Type: primitiveExecHook},
+		"bad env":            {Name: "x", Type: primitiveExecHook, Params: confSpec.GoalParamsConfig{Command: []string{"true"}, Env: []string{"NOVALUE"}}},
+		"missing key":        {Name: "x", Type: primitiveSetContextValue, Params: confSpec.GoalParamsConfig{Value: "v"}},
+		"unknown hold phase": {Name: "x", Type: primitiveShimletApply, HoldPhase: "checking"},
+	}
+	for name, cfg := range cases {
+		assert.Error(t, validateGoalConfig(cfg), name)
+	}
+	assert.NoError(t, validateGoalConfig(confSpec.GoalConfig{Name: "x", Type: primitiveHTTPCheck,
+		Params: confSpec.GoalParamsConfig{URL: "{{endpoint}}/health", Headers: map[string]string{"x-service": "{{service_id}}"}}}))
+}
+
+func declarativeContext(serviceID string, status *dto.RuntimeStatus) *goal.Context {
+	ctx := goal.NewContext()
+	ctx.DeploySpec = &dto.RequirementSpec{ServiceId: serviceID, ModelName: "qwen", ModelFileDir: "/models/qwen", UpdateTime: time.Now()}
+	ctx.Shimlet = &stubShimlet{status: status}
+	return ctx
+}
+
+// 测试 set-context-value 与 http-check：上下文值与 endpoint 通过占位符传入请求
+func TestPrimitives_HTTPCheck(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.String()+" "+r.Header.Get("X-Tenant")+" "+string(body))
+		if len(requests) == 1 {
+			http.Error(w, "quota service warming up", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+
+	status := &dto.RuntimeStatus{Status: dto.PhaseRunning}
+	ctx := declarativeContext("hook-http", status)
+	t.Cleanup(func() { forgetGoalRuns("hook-http") })
+
+	setTenant := buildSetContextValueGoal("set", confSpec.GoalConfig{Name: "tenant", Type: primitiveSetContextValue,
+		Params: confSpec.GoalParamsConfig{Key: "tenant", Value: "team-{{model_name}}"}})
+	assert.False(t, setTenant.IsAchieved(ctx))
+	require.NoError(t, setTenant.Ensure(ctx))
+	assert.True(t, setTenant.IsAchieved(ctx))
+
+	check := buildHTTPCheckGoal("set", confSpec.GoalConfig{Name: "check", Type: primitiveHTTPCheck, Retries: 1, RetryIntervalMs: 1,
+		Params: confSpec.GoalParamsConfig{
+			URL:     "{{endpoint}}/check?service={{service_id}}",
+			Method:  "post",
+			Headers: map[string]string{"x-tenant": "{{ctx.tenant}}"},
+			Body:    "{{model_path}}",
+		}})
+
+	// endpoint 尚未暴露时等待
+	require.NoError(t, check.Ensure(ctx))
+	assert.False(t, check.IsAchieved(ctx))
+	assert.Empty(t, requests)
+
+	// 第一次请求返回 503，重试后通过
+	status.EndPoint = server.URL + "/"
+	require.NoError(t, check.Ensure(ctx))
+	assert.True(t, check.IsAchieved(ctx))
+	require.Len(t, requests, 2)
+	assert.Equal(t, "POST /check?service=hook-http team-qwen /models/qwen", requests[1])
+
+	detail, ok := ctx.Detail("check")
+	require.True(t, ok)
+	result := detail.(*dto.HookResult)
+	assert.True(t, result.Passed)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "ok", result.Output)
+
+	// 部署期望更新后重新检查
+	ctx.DeploySpec.UpdateTime = ctx.DeploySpec.UpdateTime.Add(time.Second)
+	assert.False(t, check.IsAchieved(ctx))
+}
+
+// 测试 exec-hook：命令通过环境变量获取服务信息，失败时记录退出码与输出
+func TestPrimitives_ExecHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.out")
+	ctx := declarativeContext("hook-exec", &dto.RuntimeStatus{Status: dto.PhaseRunning})
+	t.Cleanup(func() { forgetGoalRuns("hook-exec") })
+
+	hook := buildExecHookGoal("set", confSpec.GoalConfig{Name: "notify", Type: primitiveExecHook,
+		Params: confSpec.GoalParamsConfig{
+			Command: []string{"/bin/sh", "-c", `echo "$XMOD_SERVICE_ID $XMOD_MODEL_NAME $TARGET" > "$1"`, "hook", out},
+			Env:     []string{"TARGET={{model_path}}"},
+		}})
+	require.NoError(t, hook.Ensure(ctx))
+	assert.True(t, hook.IsAchieved(ctx))
+	content, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "hook-exec qwen /models/qwen\n", string(content))
+
+	failing := buildExecHookGoal("set", confSpec.GoalConfig{Name: "failing", Type: primitiveExecHook,
+		Params: confSpec.GoalParamsConfig{Command: []string{"/bin/sh", "-c", "echo quota exceeded; exit 3"}}})
+	require.Error(t, failing.Ensure(ctx))
+	assert.False(t, failing.IsAchieved(ctx))
+	detail, _ := ctx.Detail("failing")
+	result := detail.(*dto.HookResult)
+	require.NotNil(t, result.ExitCode)
+	assert.Equal(t, 3, *result.ExitCode)
+	assert.Equal(t, "quota exceeded", result.Output)
+}
+
+// 测试 wait-for-phase：服务失败或等待超时时报错
+func TestPrimitives_WaitForPhase(t *testing.T) {
+	status := &dto.RuntimeStatus{Status: dto.PhasePending}
+	ctx := declarativeContext("hook-wait", status)
+	t.Cleanup(func() { forgetGoalRuns("hook-wait") })
+
+	wait := buildWaitForPhaseGoal("set", confSpec.GoalConfig{Name: "wait", Type: primitiveWaitForPhase, TimeoutMs: 50})
+	assert.False(t, wait.IsAchieved(ctx))
+	require.NoError(t, wait.Ensure(ctx))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.ErrorContains(t, wait.Ensure(ctx), "timed out")
+
+	status.Status = dto.PhaseFailed
+	assert.ErrorContains(t, wait.Ensure(ctx), "failed while waiting")
+
+	status.Status = dto.PhaseRunning
+	assert.True(t, wait.IsAchieved(ctx))
+}
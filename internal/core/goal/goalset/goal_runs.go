@@ -0,0 +1,65 @@
+package goalset
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"strings"
+	"sync"
+	"time"
+)
+
+// goalRunRecord 目标在某一部署期望版本（UpdateTime）下的执行记录
+type goalRunRecord struct {
+	updateTime time.Time
+	at         time.Time
+}
+
+// goalRuns 记录只需对每个部署期望版本执行一次的目标（HTTP 检查、exec 钩子等），
+// 以及等待类目标开始等待的时间；部署期望更新后记录失效，目标重新执行
+var goalRuns = struct {
+	mu      sync.Mutex
+	records map[string]goalRunRecord
+}{records: make(map[string]goalRunRecord)}
+
+// goalRunKey 记录的键：服务 ID + 目标名称
+func goalRunKey(spec *dto.RequirementSpec, name string) string {
+	return spec.ServiceId + "/" + name
+}
+
+// goalRunPassed 判断目标是否已对当前部署期望执行成功
+func goalRunPassed(spec *dto.RequirementSpec, name string) bool {
+	goalRuns.mu.Lock()
+	defer goalRuns.mu.Unlock()
+	record, ok := goalRuns.records[goalRunKey(spec, name)]
+	return ok && record.updateTime.Equal(spec.UpdateTime)
+}
+
+// recordGoalRun 记录目标已对当前部署期望执行成功
+func recordGoalRun(spec *dto.RequirementSpec, name string) {
+	goalRuns.mu.Lock()
+	defer goalRuns.mu.Unlock()
+	goalRuns.records[goalRunKey(spec, name)] = goalRunRecord{updateTime: spec.UpdateTime, at: time.Now()}
+}
+
+// goalRunSince 返回目标对当前部署期望首次执行的时间，首次调用时记录为当前时间
+func goalRunSince(spec *dto.RequirementSpec, name string) time.Time {
+	goalRuns.mu.Lock()
+	defer goalRuns.mu.Unlock()
+	key := goalRunKey(spec, name)
+	record, ok := goalRuns.records[key]
+	if !ok || !record.updateTime.Equal(spec.UpdateTime) {
+		record = goalRunRecord{updateTime: spec.UpdateTime, at: time.Now()}
+		goalRuns.records[key] = record
+	}
+	return record.at
+}
+
+// forgetGoalRuns 服务下线后清除该服务的全部执行记录
+func forgetGoalRuns(serviceID string) {
+	goalRuns.mu.Lock()
+	defer goalRuns.mu.Unlock()
+	for key := range goalRuns.records {
+		if strings.HasPrefix(key, serviceID+"/") {
+			delete(goalRuns.records, key)
+		}
+	}
+}
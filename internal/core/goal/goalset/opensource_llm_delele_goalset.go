@@ -8,6 +8,8 @@ import (
 
 func init() {
 	NewLLMDeleteGoalSet()
+	// 服务下线后清除只对每个部署期望版本执行一次的目标记录
+	goal.OnTeardown(forgetGoalRuns)
 }
 
 var deployDeleted = goal.Goal{Name: "deployFinish",
@@ -23,6 +25,7 @@ var deployDeleted = goal.Goal{Name: "deployFinish",
 		if err != nil {
 			return err
 		}
+		forgetGoalRuns(ctx.DeploySpec.ServiceId)
 		return nil
 	}}
 
@@ -32,5 +35,6 @@ func NewLLMDeleteGoalSet() {
 		AddGoal(deployDeleted).
 		WithMaxRetries(10).           // 失败最多重试 3 次
 		WithTimeout(5 * time.Minute). // 整体超时 2 分钟
+		WithTeardown().               // 下线完成后删除部署期望与进度
 		BuildAndRegister()
 }
@@ -2,9 +2,11 @@ package goalset
 
 import (
 	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/engine"
 	"astron-xmod-shim/internal/core/goal"
 	dto "astron-xmod-shim/internal/dto/deploy"
 	"astron-xmod-shim/pkg/log"
+	"encoding/json"
 	"path/filepath"
 	"reflect"
 	"time"
@@ -14,6 +16,14 @@ func init() {
 	NewLLMDeployGoalSet()
 }
 
+// engineOrDefault 未指定推理引擎时为默认引擎
+func engineOrDefault(id string) string {
+	if id == "" {
+		return engine.DefaultEngine
+	}
+	return id
+}
+
 // areResourceRequirementsEqual 比较两个 ResourceRequirements 是否相等
 // 处理 nil 和零值的情况
 func areResourceRequirementsEqual(expected, actual *dto.ResourceRequirements) bool {
@@ -35,6 +45,107 @@ func areResourceRequirementsEqual(expected, actual *dto.ResourceRequirements) bo
 	return reflect.DeepEqual(expected, actual)
 }
 
+// areEnvEqual 按键值比较期望中的环境变量，忽略顺序。运行时上报的额外变量（镜像 ENV 等）
+// 不算漂移；推理引擎注入的变量各 shimlet 可能不上报，也不比较
+func areEnvEqual(expected, actual []dto.Env) bool {
+	actualValues := make(map[string]string, len(actual))
+	for _, e := range actual {
+		actualValues[e.Key] = e.Value
+	}
+	expectedValues := make(map[string]string, len(expected))
+	for _, e := range expected {
+		expectedValues[e.Key] = e.Value
+	}
+	engineEnvNames := engine.Registry.BuiltinEnvNames()
+	for key, value := range expectedValues {
+		if _, ok := engineEnvNames[key]; ok {
+			continue
+		}
+		if got, ok := actualValues[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// areOptionsEqual 比较两个请求级参数（K8s、推理引擎参数、并行方式）是否相等。
+// 两侧先经 JSON 归一化：nil 指针、空结构体、空 map 与空列表都视为未指定
+func areOptionsEqual(expected, actual any) bool {
+	return normalizeOptions(expected) == normalizeOptions(actual)
+}
+
+// normalizeOptions 把参数编码为去掉空值后的 JSON，编码失败时返回空串
+func normalizeOptions(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return ""
+	}
+	data, err = json.Marshal(pruneEmpty(decoded))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// pruneEmpty 递归去掉 null、空对象与空数组；false 与 0 保留，因为它们可能是显式取值
+func pruneEmpty(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			pruned := pruneEmpty(item)
+			if pruned == nil {
+				delete(val, k)
+				continue
+			}
+			val[k] = pruned
+		}
+		if len(val) == 0 {
+			return nil
+		}
+		return val
+	case []any:
+		if len(val) == 0 {
+			return nil
+		}
+		for i, item := range val {
+			val[i] = pruneEmpty(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// specDrift 返回期望与实际部署不一致的首个字段名，一致时返回空串
+func specDrift(expected, actual *dto.RequirementSpec) string {
+	switch {
+	case expected.ModelName != actual.ModelName:
+		return "modelName"
+	case expected.ReplicaCount != actual.ReplicaCount:
+		return "replicaCount"
+	case !areResourceRequirementsEqual(expected.ResourceRequirements, actual.ResourceRequirements):
+		return "resourceRequirements"
+	// 运行时未上报引擎时不比较
+	case actual.Engine != "" && engineOrDefault(expected.Engine) != engineOrDefault(actual.Engine):
+		return "engine"
+	case !areOptionsEqual(expected.EngineOptions, actual.EngineOptions):
+		return "engineOptions"
+	case !areOptionsEqual(expected.Parallelism, actual.Parallelism):
+		return "parallelism"
+	case !areOptionsEqual(expected.K8s, actual.K8s):
+		return "k8s"
+	case expected.ContextLength != actual.ContextLength:
+		return "contextLength"
+	case !areEnvEqual(expected.Env, actual.Env):
+		return "env"
+	}
+	return ""
+}
+
 // 构造 mapModelNameToPath Goal
 var modelPathReady = goal.Goal{
 	Name: "map-model-path",
@@ -75,16 +186,10 @@ var specConsistencyCheck = goal.Goal{
 			return false
 		}
 
-		// 比较期望的spec和实际的spec是否一致
-		// 这里我们主要比较关键字段：ModelName, ModelFileDir, ResourceRequirements, ReplicaCount
-		expectedSpec := ctx.DeploySpec
-		actualSpec := status.DeploySpec
-
-		// 比较关键字段
-		if expectedSpec.ModelName != actualSpec.ModelName ||
-			expectedSpec.ReplicaCount != actualSpec.ReplicaCount ||
-			!areResourceRequirementsEqual(expectedSpec.ResourceRequirements, actualSpec.ResourceRequirements) {
-			log.Info("Spec inconsistency detected for service %s", ctx.DeploySpec.ServiceId)
+		// 比较期望的spec和实际的spec是否一致：模型、副本、资源、推理引擎及其参数、
+		// 并行方式、K8s 覆盖项、上下文长度与环境变量任一变化都需要重新应用
+		if field := specDrift(ctx.DeploySpec, &status.DeploySpec); field != "" {
+			log.Info("Spec inconsistency detected for service %s: %s changed", ctx.DeploySpec.ServiceId, field)
 			return false
 		}
 
@@ -133,7 +238,9 @@ func NewLLMDeployGoalSet() {
 		AddGoal(deployFinished).
 		AddGoal(specConsistencyCheck). // 添加spec一致性检查Goal
 		AddGoal(serviceExposed).
-		WithMaxRetries(10).           // 失败最多重试 10 次
-		WithTimeout(5 * time.Minute). // 整体超时 5 分钟
+		AddGoal(smokeTest).     // 真实推理请求通过后部署才完成
+		WithMaxRetries(10).     // 失败最多重试 10 次
+		WithTimeout(time.Hour). // 整体超时 1 小时，覆盖大模型的加载时间
+		WithRollback().         // 更新超时后回滚到最近一次正常的部署期望
 		BuildAndRegister()
 }
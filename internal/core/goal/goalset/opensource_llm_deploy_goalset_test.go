@@ -0,0 +1,120 @@
+package goalset
+
+import (
+	"testing"
+
+	"astron-xmod-shim/internal/core/goal"
+	dto "astron-xmod-shim/internal/dto/deploy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecDrift(t *testing.T) {
+	base := func() *dto.RequirementSpec {
+		return &dto.RequirementSpec{ModelName: "qwen", ReplicaCount: 1}
+	}
+	enabled := false
+
+	tests := []struct {
+		name     string
+		expected func(*dto.RequirementSpec)
+		actual   func(*dto.RequirementSpec)
+		want     string
+	}{
+		{name: "identical"},
+		{
+			name:     "empty options equal nil",
+			expected: func(s *dto.RequirementSpec) { s.K8s = &dto.K8sOptions{Expose: &dto.K8sExpose{}}; s.Env = []dto.Env{} },
+			actual:   func(s *dto.RequirementSpec) { s.EngineOptions = &dto.EngineOptions{} },
+		},
+		{
+			name:     "env order ignored",
+			expected: func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}} },
+			actual:   func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "B", Value: "2"}, {Key: "A", Value: "1"}} },
+		},
+		{
+			name:     "k8s namespace",
+			expected: func(s *dto.RequirementSpec) { s.K8s = &dto.K8sOptions{Namespace: "team-b"} },
+			actual:   func(s *dto.RequirementSpec) { s.K8s = &dto.K8sOptions{Namespace: "team-a"} },
+			want:     "k8s",
+		},
+		{
+			name:     "k8s probes disabled",
+			expected: func(s *dto.RequirementSpec) { s.K8s = &dto.K8sOptions{Probes: &dto.K8sProbes{Enabled: &enabled}} },
+			want:     "k8s",
+		},
+		{
+			name: "engine options",
+			expected: func(s *dto.RequirementSpec) {
+				s.EngineOptions = &dto.EngineOptions{ExtraArgs: []string{"--enforce-eager"}}
+			},
+			want: "engineOptions",
+		},
+		{
+			name:     "parallelism",
+			expected: func(s *dto.RequirementSpec) { s.Parallelism = &dto.ParallelismSpec{TensorParallelSize: 2} },
+			want:     "parallelism",
+		},
+		{
+			name:     "context length",
+			expected: func(s *dto.RequirementSpec) { s.ContextLength = 8192 },
+			want:     "contextLength",
+		},
+		{
+			name:     "env value",
+			expected: func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "A", Value: "2"}} },
+			actual:   func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "A", Value: "1"}} },
+			want:     "env",
+		},
+		{
+			name:     "env missing",
+			expected: func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "A", Value: "1"}} },
+			want:     "env",
+		},
+		{
+			name:     "extra runtime env ignored",
+			expected: func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "A", Value: "1"}} },
+			actual: func(s *dto.RequirementSpec) {
+				s.Env = []dto.Env{{Key: "A", Value: "1"}, {Key: "PATH", Value: "/usr/bin"}}
+			},
+		},
+		{
+			name:     "engine env not reported",
+			expected: func(s *dto.RequirementSpec) { s.Env = []dto.Env{{Key: "HF_HOME", Value: "/data/hf"}} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, actual := base(), base()
+			if tt.expected != nil {
+				tt.expected(expected)
+			}
+			if tt.actual != nil {
+				tt.actual(actual)
+			}
+			assert.Equal(t, tt.want, specDrift(expected, actual))
+		})
+	}
+}
+
+// 测试运行时上报额外 env 时，一致性检查不会反复重新应用
+func TestSpecConsistencyCheck_ExtraRuntimeEnv(t *testing.T) {
+	ctx := goal.NewContext()
+	ctx.DeploySpec = &dto.RequirementSpec{
+		ServiceId:    "svc-1",
+		ModelName:    "qwen",
+		ReplicaCount: 1,
+		Env:          []dto.Env{{Key: "FOO", Value: "bar"}, {Key: "TRANSFORMERS_OFFLINE", Value: "0"}},
+	}
+	status := &dto.RuntimeStatus{Status: dto.PhaseRunning, DeploySpec: dto.RequirementSpec{
+		ServiceId:    "svc-1",
+		ModelName:    "qwen",
+		ReplicaCount: 1,
+		Env:          []dto.Env{{Key: "PATH", Value: "/usr/bin"}, {Key: "CUDA_VERSION", Value: "12.4.1"}, {Key: "FOO", Value: "bar"}},
+	}}
+	ctx.Shimlet = &stubShimlet{status: status}
+	assert.True(t, specConsistencyCheck.IsAchieved(ctx))
+
+	status.DeploySpec.Env[2].Value = "baz"
+	assert.False(t, specConsistencyCheck.IsAchieved(ctx))
+}
@@ -0,0 +1,589 @@
+package goalset
+
+import (
+	"astron-xmod-shim/internal/core/goal"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 声明式目标原语
+const (
+	primitiveBuiltin         = "builtin"
+	primitiveShimletApply    = "shimlet-apply"
+	primitiveWaitForPhase    = "wait-for-phase"
+	primitiveHTTPCheck       = "http-check"
+	primitiveExecHook        = "exec-hook"
+	primitiveSetContextValue = "set-context-value"
+)
+
+const (
+	defaultRetryInterval   = time.Second
+	defaultHTTPTimeout     = 10 * time.Second
+	defaultExecHookTimeout = 60 * time.Second
+	maxHookOutput          = 512
+)
+
+// builtinGoals 可被声明式目标集合引用的内置目标
+var builtinGoals = map[string]goal.Goal{
+	"map-model-path":         modelPathReady,
+	"deploy":                 deployFinished,
+	"spec-consistency-check": specConsistencyCheck,
+	"expose-service":         serviceExposed,
+	"smoke-test":             smokeTest,
+	"delete":                 deployDeleted,
+}
+
+// primitive 目标原语：允许的参数与选项，以及由配置构造目标的方法
+type primitive struct {
+	params  []string // 允许的参数（params 下的键）
+	timeout bool     // 是否支持 timeout-ms
+	retries bool     // 是否支持 retries / retry-interval-ms
+	build   func(goalSetName string, cfg confSpec.GoalConfig) goal.Goal
+}
+
+var primitives = map[string]primitive{
+	primitiveBuiltin:         {params: []string{"goal"}, build: buildBuiltinGoal},
+	primitiveShimletApply:    {retries: true, build: buildShimletApplyGoal},
+	primitiveWaitForPhase:    {params: []string{"phase"}, timeout: true, build: buildWaitForPhaseGoal},
+	primitiveHTTPCheck:       {params: []string{"url", "method", "headers", "body", "expect-status"}, timeout: true, retries: true, build: buildHTTPCheckGoal},
+	primitiveExecHook:        {params: []string{"command", "env"}, timeout: true, retries: true, build: buildExecHookGoal},
+	primitiveSetContextValue: {params: []string{"key", "value"}, build: buildSetContextValueGoal},
+}
+
+// primitiveTypes 按名称排序的原语类型，用于错误提示
+func primitiveTypes() []string {
+	types := make([]string, 0, len(primitives))
+	for name := range primitives {
+		types = append(types, name)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// setParams 返回配置中已设置的参数
+func setParams(params confSpec.GoalParamsConfig) []string {
+	var names []string
+	add := func(name string, set bool) {
+		if set {
+			names = append(names, name)
+		}
+	}
+	add("goal", params.Goal != "")
+	add("phase", params.Phase != "")
+	add("url", params.URL != "")
+	add("method", params.Method != "")
+	add("headers", len(params.Headers) > 0)
+	add("body", params.Body != "")
+	add("expect-status", params.ExpectStatus != 0)
+	add("command", len(params.Command) > 0)
+	add("env", len(params.Env) > 0)
+	add("key", params.Key != "")
+	add("value", params.Value != "")
+	return names
+}
+
+// validateGoalConfig 校验声明式目标的原语类型、参数与选项
+func validateGoalConfig(cfg confSpec.GoalConfig) error {
+	p, ok := primitives[cfg.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %q, expected one of %s", cfg.Type, strings.Join(primitiveTypes(), ", "))
+	}
+	for _, name := range setParams(cfg.Params) {
+		if !slices.Contains(p.params, name) {
+			return fmt.Errorf("param %s is not supported by %s", name, cfg.Type)
+		}
+	}
+	switch {
+	case cfg.TimeoutMs < 0:
+		return fmt.Errorf("invalid timeout-ms: %d", cfg.TimeoutMs)
+	case cfg.TimeoutMs > 0 && !p.timeout:
+		return fmt.Errorf("timeout-ms is not supported by %s", cfg.Type)
+	case cfg.Retries < 0 || cfg.RetryIntervalMs < 0:
+		return fmt.Errorf("invalid retries: %d, retry-interval-ms: %d", cfg.Retries, cfg.RetryIntervalMs)
+	case (cfg.Retries > 0 || cfg.RetryIntervalMs > 0) && !p.retries:
+		return fmt.Errorf("retries are not supported by %s", cfg.Type)
+	case cfg.HoldPhase != "" && !dto.DeployPhase(cfg.HoldPhase).Valid():
+		return fmt.Errorf("unknown hold-phase: %s", cfg.HoldPhase)
+	}
+
+	params := cfg.Params
+	switch cfg.Type {
+	case primitiveBuiltin:
+		if _, ok := builtinGoals[params.Goal]; !ok {
+			return fmt.Errorf("unknown builtin goal %q", params.Goal)
+		}
+	case primitiveWaitForPhase:
+		if params.Phase != "" && !dto.DeployPhase(params.Phase).Valid() {
+			return fmt.Errorf("unknown phase: %s", params.Phase)
+		}
+	case primitiveHTTPCheck:
+		if err := validateCheckURL(params.URL); err != nil {
+			return err
+		}
+		if params.Method != "" && !slices.Contains([]string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions}, strings.ToUpper(params.Method)) {
+			return fmt.Errorf("unsupported method: %s", params.Method)
+		}
+		if params.ExpectStatus != 0 && (params.ExpectStatus < 100 || params.ExpectStatus > 599) {
+			return fmt.Errorf("invalid expect-status: %d", params.ExpectStatus)
+		}
+		templates := []string{params.Body}
+		for _, value := range params.Headers {
+			templates = append(templates, value)
+		}
+		if err := checkTemplates(templates...); err != nil {
+			return err
+		}
+	case primitiveExecHook:
+		if len(params.Command) == 0 || params.Command[0] == "" {
+			return errors.New("command is required")
+		}
+		for _, env := range params.Env {
+			if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+				return fmt.Errorf("invalid env %q, expected KEY=VALUE", env)
+			}
+		}
+		if err := checkTemplates(append(slices.Clone(params.Command), params.Env...)...); err != nil {
+			return err
+		}
+	case primitiveSetContextValue:
+		if params.Key == "" {
+			return errors.New("key is required")
+		}
+		if err := checkTemplates(params.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compensateTypes 可用作补偿动作的原语
+var compensateTypes = []string{primitiveHTTPCheck, primitiveExecHook}
+
+// compensateGoalConfig 将目标的补偿动作转换为同类原语的目标配置，名称为 <目标名>-compensate
+func compensateGoalConfig(cfg confSpec.GoalConfig) confSpec.GoalConfig {
+	compensate := cfg.Compensate
+	return confSpec.GoalConfig{
+		Name:            cfg.Name + "-compensate",
+		Type:            compensate.Type,
+		Retries:         compensate.Retries,
+		RetryIntervalMs: compensate.RetryIntervalMs,
+		TimeoutMs:       compensate.TimeoutMs,
+		Params:          compensate.Params,
+	}
+}
+
+// validateCompensateConfig 校验目标的补偿动作
+func validateCompensateConfig(cfg confSpec.GoalConfig) error {
+	if !slices.Contains(compensateTypes, cfg.Compensate.Type) {
+		return fmt.Errorf("compensate: unsupported type %q, expected one of %s", cfg.Compensate.Type, strings.Join(compensateTypes, ", "))
+	}
+	if err := validateGoalConfig(compensateGoalConfig(cfg)); err != nil {
+		return fmt.Errorf("compensate: %w", err)
+	}
+	return nil
+}
+
+// buildCompensate 补偿动作复用 http-check / exec-hook 的执行逻辑，结果同样记录在上下文的 detail 中；
+// 引用 {{endpoint}} 而服务没有 endpoint 时跳过
+func buildCompensate(goalSetName string, cfg confSpec.GoalConfig) func(ctx *goal.Context) error {
+	compensateCfg := compensateGoalConfig(cfg)
+	return primitives[compensateCfg.Type].build(goalSetName, compensateCfg).Ensure
+}
+
+// validateCheckURL 校验 HTTP 检查的地址：以 {{endpoint}} 等占位符开头，或为 http(s) 绝对地址
+func validateCheckURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("url is required")
+	}
+	if err := checkTemplates(rawURL); err != nil {
+		return err
+	}
+	if strings.HasPrefix(rawURL, "{{") {
+		return nil
+	}
+	parsed, err := url.Parse(placeholderPattern.ReplaceAllString(rawURL, "x"))
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid url %q, expected an http(s) address", rawURL)
+	}
+	return nil
+}
+
+// placeholderPattern 匹配 {{name}} 形式的占位符
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// placeholders 支持的占位符，另支持 {{ctx.<key>}} 引用前序目标写入的上下文值
+var placeholders = []string{"service_id", "model_name", "model_path", "shimlet", "endpoint"}
+
+// errEndpointPending 模板引用了 {{endpoint}}，但服务尚未暴露 endpoint
+var errEndpointPending = errors.New("endpoint not available yet")
+
+// checkTemplates 校验模板中的占位符
+func checkTemplates(templates ...string) error {
+	for _, template := range templates {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+			name := match[1]
+			if key, ok := strings.CutPrefix(name, "ctx."); ok && key != "" {
+				continue
+			}
+			if !slices.Contains(placeholders, name) {
+				return fmt.Errorf("unknown placeholder {{%s}} in %q", name, template)
+			}
+		}
+	}
+	return nil
+}
+
+// renderTemplate 替换模板中的占位符；引用的 endpoint 尚不可用时返回 errEndpointPending
+func renderTemplate(ctx *goal.Context, template string) (string, error) {
+	var renderErr error
+	endpoint := ""
+	rendered := placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if key, ok := strings.CutPrefix(name, "ctx."); ok {
+			if value := ctx.Get(key); value != nil {
+				return fmt.Sprint(value)
+			}
+			return ""
+		}
+		switch name {
+		case "service_id":
+			return ctx.DeploySpec.ServiceId
+		case "model_name":
+			return ctx.DeploySpec.ModelName
+		case "model_path":
+			return ctx.DeploySpec.ModelFileDir
+		case "shimlet":
+			return ctx.DeploySpec.ShimletName
+		case "endpoint":
+			if endpoint == "" && renderErr == nil {
+				endpoint, renderErr = serviceEndpoint(ctx)
+			}
+			return endpoint
+		}
+		return match
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return rendered, nil
+}
+
+// renderTemplates 依次替换多个模板中的占位符
+func renderTemplates(ctx *goal.Context, templates []string) ([]string, error) {
+	rendered := make([]string, 0, len(templates))
+	for _, template := range templates {
+		value, err := renderTemplate(ctx, template)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, value)
+	}
+	return rendered, nil
+}
+
+// serviceEndpoint 返回服务当前的 endpoint
+func serviceEndpoint(ctx *goal.Context) (string, error) {
+	status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+	if err != nil {
+		return "", err
+	}
+	if status.EndPoint == "" {
+		return "", errEndpointPending
+	}
+	return strings.TrimSuffix(status.EndPoint, "/"), nil
+}
+
+// hookOptions 目标的重试与超时选项
+type hookOptions struct {
+	retries       int
+	retryInterval time.Duration
+	timeout       time.Duration
+}
+
+func newHookOptions(cfg confSpec.GoalConfig, defaultTimeout time.Duration) hookOptions {
+	opts := hookOptions{retries: cfg.Retries, retryInterval: defaultRetryInterval, timeout: defaultTimeout}
+	if cfg.RetryIntervalMs > 0 {
+		opts.retryInterval = time.Duration(cfg.RetryIntervalMs) * time.Millisecond
+	}
+	if cfg.TimeoutMs > 0 {
+		opts.timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+	return opts
+}
+
+// attempt 执行 fn，失败后按 retries 立即重试，返回尝试次数与最后一次的错误
+func (o hookOptions) attempt(fn func() error) (int, error) {
+	var err error
+	for i := 0; i <= o.retries; i++ {
+		if i > 0 {
+			time.Sleep(o.retryInterval)
+		}
+		if err = fn(); err == nil {
+			return i + 1, nil
+		}
+	}
+	return o.retries + 1, err
+}
+
+// applyGoalOptions 设置目标的名称、原语类型、依赖与 HoldPhase
+func applyGoalOptions(g goal.Goal, cfg confSpec.GoalConfig) goal.Goal {
+	g.Name = cfg.Name
+	g.Type = cfg.Type
+	g.DependsOn = cfg.DependsOn
+	if cfg.HoldPhase != "" {
+		g.HoldPhase = dto.DeployPhase(cfg.HoldPhase)
+	}
+	return g
+}
+
+// buildBuiltinGoal 引用内置目标
+func buildBuiltinGoal(_ string, cfg confSpec.GoalConfig) goal.Goal {
+	return applyGoalOptions(builtinGoals[cfg.Params.Goal], cfg)
+}
+
+// buildShimletApplyGoal 运行时中不存在服务时调用 shimlet 部署；
+// 部署期望更新后的重新部署需组合内置目标 spec-consistency-check
+func buildShimletApplyGoal(_ string, cfg confSpec.GoalConfig) goal.Goal {
+	opts := newHookOptions(cfg, 0)
+	return applyGoalOptions(goal.Goal{
+		IsAchieved: func(ctx *goal.Context) bool {
+			status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+			return err == nil && status.Status != dto.PhaseUnknown
+		},
+		Ensure: func(ctx *goal.Context) error {
+			_, err := opts.attempt(func() error { return ctx.Shimlet.Apply(ctx.DeploySpec) })
+			return err
+		},
+	}, cfg)
+}
+
+// buildWaitForPhaseGoal 等待服务进入指定的部署阶段，服务失败或等待超过 timeout-ms 时报错
+func buildWaitForPhaseGoal(goalSetName string, cfg confSpec.GoalConfig) goal.Goal {
+	phase := dto.PhaseRunning
+	if cfg.Params.Phase != "" {
+		phase = dto.DeployPhase(cfg.Params.Phase)
+	}
+	opts := newHookOptions(cfg, 0)
+	runName := goalSetName + "/" + cfg.Name
+	return applyGoalOptions(goal.Goal{
+		IsAchieved: func(ctx *goal.Context) bool {
+			status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+			return err == nil && status.Status == phase
+		},
+		Ensure: func(ctx *goal.Context) error {
+			status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+			if err != nil {
+				return err
+			}
+			if status.Status == dto.PhaseFailed {
+				return fmt.Errorf("service %s failed while waiting for phase %s", ctx.DeploySpec.ServiceId, phase)
+			}
+			if opts.timeout > 0 && time.Since(goalRunSince(ctx.DeploySpec, runName)) > opts.timeout {
+				return fmt.Errorf("timed out after %s waiting for phase %s, current phase %s", opts.timeout, phase, status.Status)
+			}
+			// 等待中，不计入重试次数
+			return nil
+		},
+	}, cfg)
+}
+
+// buildHTTPCheckGoal 对每个部署期望版本发起一次 HTTP 请求，响应状态码符合预期后达成
+func buildHTTPCheckGoal(goalSetName string, cfg confSpec.GoalConfig) goal.Goal {
+	params := cfg.Params
+	method := http.MethodGet
+	if params.Method != "" {
+		method = strings.ToUpper(params.Method)
+	}
+	opts := newHookOptions(cfg, defaultHTTPTimeout)
+	client := &http.Client{Timeout: opts.timeout}
+	runName := goalSetName + "/" + cfg.Name
+	return applyGoalOptions(goal.Goal{
+		IsAchieved: func(ctx *goal.Context) bool {
+			return goalRunPassed(ctx.DeploySpec, runName)
+		},
+		Ensure: func(ctx *goal.Context) error {
+			target, err := renderTemplate(ctx, params.URL)
+			var body string
+			if err == nil {
+				body, err = renderTemplate(ctx, params.Body)
+			}
+			headers := make(map[string]string, len(params.Headers))
+			for key, template := range params.Headers {
+				if err == nil {
+					headers[key], err = renderTemplate(ctx, template)
+				}
+			}
+			if errors.Is(err, errEndpointPending) {
+				// 等待服务暴露 endpoint
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return runHTTPCheck(ctx, cfg.Name, runName, client, opts, method, target, body, headers, params.ExpectStatus)
+		},
+	}, cfg)
+}
+
+// runHTTPCheck 发起请求并记录结果
+func runHTTPCheck(ctx *goal.Context, goalName, runName string, client *http.Client, opts hookOptions,
+	method, target, body string, headers map[string]string, expectStatus int) error {
+	result := &dto.HookResult{Target: method + " " + target}
+	attempts, err := opts.attempt(func() error {
+		start := time.Now()
+		result.StatusCode, result.Output = 0, ""
+		defer func() { result.LatencyMs = time.Since(start).Milliseconds() }()
+
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		output, _ := io.ReadAll(io.LimitReader(resp.Body, maxHookOutput))
+		result.StatusCode = resp.StatusCode
+		result.Output = strings.TrimSpace(string(output))
+		if expectStatus != 0 && resp.StatusCode != expectStatus {
+			return fmt.Errorf("status %d, expected %d", resp.StatusCode, expectStatus)
+		}
+		if expectStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+			return fmt.Errorf("status %d, expected 2xx", resp.StatusCode)
+		}
+		return nil
+	})
+	return recordHookResult(ctx, goalName, runName, result, attempts, err)
+}
+
+// buildExecHookGoal 对每个部署期望版本在 shim 所在主机执行一次命令，退出码为 0 后达成。
+// 命令可通过环境变量 XMOD_SERVICE_ID、XMOD_MODEL_NAME、XMOD_MODEL_PATH、XMOD_SHIMLET 获取服务信息
+func buildExecHookGoal(goalSetName string, cfg confSpec.GoalConfig) goal.Goal {
+	params := cfg.Params
+	opts := newHookOptions(cfg, defaultExecHookTimeout)
+	runName := goalSetName + "/" + cfg.Name
+	return applyGoalOptions(goal.Goal{
+		IsAchieved: func(ctx *goal.Context) bool {
+			return goalRunPassed(ctx.DeploySpec, runName)
+		},
+		Ensure: func(ctx *goal.Context) error {
+			command, err := renderTemplates(ctx, params.Command)
+			var env []string
+			if err == nil {
+				env, err = renderTemplates(ctx, params.Env)
+			}
+			if errors.Is(err, errEndpointPending) {
+				// 等待服务暴露 endpoint
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return runExecHook(ctx, cfg.Name, runName, opts, command, env)
+		},
+	}, cfg)
+}
+
+// runExecHook 执行命令并记录结果
+func runExecHook(ctx *goal.Context, goalName, runName string, opts hookOptions, command, env []string) error {
+	spec := ctx.DeploySpec
+	result := &dto.HookResult{Target: strings.Join(command, " ")}
+	attempts, err := opts.attempt(func() error {
+		start := time.Now()
+		defer func() { result.LatencyMs = time.Since(start).Milliseconds() }()
+
+		execCtx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+		defer cancel()
+		cmd := exec.CommandContext(execCtx, command[0], command[1:]...)
+		cmd.Env = append(os.Environ(),
+			"XMOD_SERVICE_ID="+spec.ServiceId,
+			"XMOD_MODEL_NAME="+spec.ModelName,
+			"XMOD_MODEL_PATH="+spec.ModelFileDir,
+			"XMOD_SHIMLET="+spec.ShimletName)
+		cmd.Env = append(cmd.Env, env...)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		runErr := cmd.Run()
+		result.Output = tail(strings.TrimSpace(output.String()), maxHookOutput)
+		result.ExitCode = nil
+		if cmd.ProcessState != nil {
+			exitCode := cmd.ProcessState.ExitCode()
+			result.ExitCode = &exitCode
+		}
+		if execCtx.Err() != nil {
+			return fmt.Errorf("timed out after %s", opts.timeout)
+		}
+		return runErr
+	})
+	return recordHookResult(ctx, goalName, runName, result, attempts, err)
+}
+
+// recordHookResult 记录 HTTP 检查或 exec 钩子的结果，成功时标记当前部署期望已执行
+func recordHookResult(ctx *goal.Context, goalName, runName string, result *dto.HookResult, attempts int, err error) error {
+	result.Attempts = attempts
+	result.CheckedAt = time.Now()
+	result.Passed = err == nil
+	if err != nil {
+		result.Error = err.Error()
+	}
+	ctx.SetDetail(goalName, result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", result.Target, err)
+	}
+	recordGoalRun(ctx.DeploySpec, runName)
+	log.Info("Goal %s of service %s passed: %s", goalName, ctx.DeploySpec.ServiceId, result.Target)
+	return nil
+}
+
+// tail 返回 s 末尾最多 n 个字节
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
+
+// buildSetContextValueGoal 向上下文写入键值，供后续目标通过 {{ctx.<key>}} 引用
+func buildSetContextValueGoal(_ string, cfg confSpec.GoalConfig) goal.Goal {
+	params := cfg.Params
+	return applyGoalOptions(goal.Goal{
+		IsAchieved: func(ctx *goal.Context) bool {
+			value, err := renderTemplate(ctx, params.Value)
+			return err == nil && ctx.Get(params.Key) == value
+		},
+		Ensure: func(ctx *goal.Context) error {
+			value, err := renderTemplate(ctx, params.Value)
+			if errors.Is(err, errEndpointPending) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			ctx.Set(params.Key, value)
+			return nil
+		},
+	}, cfg)
+}
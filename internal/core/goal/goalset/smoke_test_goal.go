@@ -0,0 +1,302 @@
+package goalset
+
+import (
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/goal"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	smokeTestGoalName       = "smoke-test"
+	defaultSmokeTestPrompt  = "ping"
+	defaultSmokeTestTokens  = 4
+	defaultSmokeTestTimeout = 60 * time.Second
+)
+
+// smokeTestSettings 冒烟测试配置
+type smokeTestSettings struct {
+	enabled   bool
+	prompt    string
+	maxTokens int
+	timeout   time.Duration
+}
+
+// loadSmokeTestSettings 读取全局配置中的冒烟测试配置，补全默认值
+func loadSmokeTestSettings() smokeTestSettings {
+	settings := smokeTestSettings{
+		enabled:   true,
+		prompt:    defaultSmokeTestPrompt,
+		maxTokens: defaultSmokeTestTokens,
+		timeout:   defaultSmokeTestTimeout,
+	}
+	globalCfg := config.Get()
+	if globalCfg == nil {
+		return settings
+	}
+	smokeCfg := globalCfg.SmokeTest
+	if smokeCfg.Enabled != nil {
+		settings.enabled = *smokeCfg.Enabled
+	}
+	if smokeCfg.Prompt != "" {
+		settings.prompt = smokeCfg.Prompt
+	}
+	if smokeCfg.MaxTokens > 0 {
+		settings.maxTokens = smokeCfg.MaxTokens
+	}
+	if smokeCfg.TimeoutMs > 0 {
+		settings.timeout = time.Duration(smokeCfg.TimeoutMs) * time.Millisecond
+	}
+	return settings
+}
+
+// inCluster 判断 shim 是否运行在 K8s 集群内，测试中会替换
+var inCluster = func() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+}
+
+// clusterInternalEndpoint 判断 endpoint 是否为只能在集群内访问的 Service 域名（如 cluster-ip 暴露方式）
+func clusterInternalEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".svc.cluster.local")
+}
+
+// smokeTest 服务 running 后向 endpoint 发起真实推理请求：
+// /v1/models 中需包含部署的模型，且一次流式 chat completion 能返回 token；
+// 通过前服务处于 verifying 阶段，耗时与首 token 时间记录在目标进度中。
+// 通过的版本记录在部署期望中，shim 重启后不会重复验证；
+// shim 运行在集群外而 endpoint 只能在集群内访问时跳过验证并记录原因，跳过的版本单独标记，
+// 不视为通过，shim 运行在集群内时重新验证
+var smokeTest = goal.Goal{
+	Name:      smokeTestGoalName,
+	HoldPhase: dto.PhaseVerifying,
+	IsAchieved: func(ctx *goal.Context) bool {
+		if !loadSmokeTestSettings().enabled {
+			return true
+		}
+		return ctx.DeploySpec.Verified() || (ctx.DeploySpec.VerificationSkippedCurrent() && !inCluster())
+	},
+	Ensure: func(ctx *goal.Context) error {
+		status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+		if err != nil {
+			return err
+		}
+		switch {
+		case status.Status == dto.PhaseFailed:
+			return fmt.Errorf("service %s failed before the smoke test", ctx.DeploySpec.ServiceId)
+		case status.Status != dto.PhaseRunning || status.EndPoint == "":
+			// 等待服务就绪，不计入重试次数
+			return nil
+		}
+
+		if clusterInternalEndpoint(status.EndPoint) && !inCluster() {
+			result := &dto.SmokeTestResult{
+				Endpoint:   status.EndPoint,
+				SkipReason: "endpoint is only reachable inside the cluster and the shim runs outside it",
+				CheckedAt:  time.Now(),
+			}
+			ctx.SetDetail(smokeTestGoalName, result)
+			ctx.DeploySpec.VerifiedUpdateTime, ctx.DeploySpec.VerificationSkipped = ctx.DeploySpec.UpdateTime, true
+			log.Warn("Smoke test of service %s skipped: %s (%s)", ctx.DeploySpec.ServiceId, result.SkipReason, status.EndPoint)
+			return nil
+		}
+
+		result := runSmokeTest(ctx.DeploySpec, status.EndPoint, loadSmokeTestSettings())
+		ctx.SetDetail(smokeTestGoalName, result)
+		if !result.Passed {
+			return fmt.Errorf("smoke test of %s failed: %s", status.EndPoint, result.Error)
+		}
+		// 由 reconciler 在本轮结束后写回存储
+		ctx.DeploySpec.VerifiedUpdateTime, ctx.DeploySpec.VerificationSkipped = ctx.DeploySpec.UpdateTime, false
+		log.Info("Smoke test of service %s passed: model %s, latency %dms, first token %dms",
+			ctx.DeploySpec.ServiceId, result.Model, result.LatencyMs, result.FirstTokenMs)
+		return nil
+	},
+}
+
+// runSmokeTest 依次请求 /v1/models 与流式 /v1/chat/completions
+func runSmokeTest(spec *dto.RequirementSpec, endpoint string, settings smokeTestSettings) *dto.SmokeTestResult {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	result := &dto.SmokeTestResult{Endpoint: endpoint, CheckedAt: time.Now()}
+	client := &http.Client{Timeout: settings.timeout}
+
+	start := time.Now()
+	models, err := listServedModels(client, endpoint)
+	result.ModelsLatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Model = matchServedModel(spec, models)
+	if result.Model == "" {
+		result.Error = fmt.Sprintf("served models %v do not include %s", models, spec.ModelName)
+		return result
+	}
+
+	firstToken, latency, err := streamChatCompletion(client, endpoint, result.Model, settings)
+	result.FirstTokenMs = firstToken.Milliseconds()
+	result.LatencyMs = latency.Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+// listServedModels 返回 /v1/models 中的模型名
+func listServedModels(client *http.Client, endpoint string) ([]string, error) {
+	resp, err := client.Get(endpoint + "/v1/models")
+	if err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list models: %s", responseError(resp))
+	}
+	var body struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("list models: decode response: %w", err)
+	}
+	models := make([]string, 0, len(body.Data))
+	for _, model := range body.Data {
+		models = append(models, model.ID)
+	}
+	return models, nil
+}
+
+// matchServedModel 返回与部署期望匹配的模型名：多数引擎以 modelName 对外服务，
+// 部分引擎（如 TGI）使用模型目录
+func matchServedModel(spec *dto.RequirementSpec, models []string) string {
+	candidates := []string{spec.ModelName, spec.ModelFileDir}
+	if spec.ModelFileDir != "" {
+		candidates = append(candidates, filepath.Dir(spec.ModelFileDir))
+	}
+	for _, model := range models {
+		for _, candidate := range candidates {
+			if candidate != "" && model == candidate {
+				return model
+			}
+		}
+	}
+	return ""
+}
+
+// chatChunk chat completion（流式分片或完整响应）中用到的字段
+type chatChunk struct {
+	Choices []struct {
+		Text  string `json:"text"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error json.RawMessage `json:"error"`
+}
+
+// content 返回分片中生成的文本
+func (c *chatChunk) content() string {
+	var sb strings.Builder
+	for _, choice := range c.Choices {
+		sb.WriteString(choice.Delta.Content)
+		sb.WriteString(choice.Message.Content)
+		sb.WriteString(choice.Text)
+	}
+	return sb.String()
+}
+
+// streamChatCompletion 发起一次流式 chat completion，返回首个 token 与完整响应的耗时；
+// 不支持流式的服务返回完整响应时，首 token 耗时等于总耗时
+func streamChatCompletion(client *http.Client, endpoint, model string, settings smokeTestSettings) (time.Duration, time.Duration, error) {
+	payload, err := json.Marshal(map[string]any{
+		"model":       model,
+		"messages":    []map[string]string{{"role": "user", "content": settings.prompt}},
+		"max_tokens":  settings.maxTokens,
+		"temperature": 0,
+		"stream":      true,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start := time.Now()
+	resp, err := client.Post(endpoint+"/v1/chat/completions", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return 0, 0, fmt.Errorf("chat completion: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, time.Since(start), fmt.Errorf("chat completion: %s", responseError(resp))
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var chunk chatChunk
+		if err := json.NewDecoder(resp.Body).Decode(&chunk); err != nil {
+			return 0, time.Since(start), fmt.Errorf("chat completion: decode response: %w", err)
+		}
+		latency := time.Since(start)
+		if chunk.content() == "" {
+			return latency, latency, errors.New("chat completion: no tokens generated")
+		}
+		return latency, latency, nil
+	}
+
+	var firstToken time.Duration
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk chatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return firstToken, time.Since(start), fmt.Errorf("chat completion: decode chunk: %w", err)
+		}
+		if len(chunk.Error) > 0 && string(chunk.Error) != "null" {
+			return firstToken, time.Since(start), fmt.Errorf("chat completion: %s", chunk.Error)
+		}
+		if firstToken == 0 && chunk.content() != "" {
+			firstToken = time.Since(start)
+		}
+	}
+	latency := time.Since(start)
+	if err := scanner.Err(); err != nil {
+		return firstToken, latency, fmt.Errorf("chat completion: read stream: %w", err)
+	}
+	if firstToken == 0 {
+		return 0, latency, errors.New("chat completion: no tokens generated")
+	}
+	return firstToken, latency, nil
+}
+
+// responseError 描述非 200 响应，附带响应体开头便于排查
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Sprintf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
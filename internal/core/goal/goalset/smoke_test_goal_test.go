@@ -0,0 +1,162 @@
+package goalset
+
+import (
+	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/shimlet"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// stubShimlet 返回固定运行时状态的 shimlet
+type stubShimlet struct {
+	shimlet.Shimlet
+	status *dto.RuntimeStatus
+}
+
+func (s *stubShimlet) Status(string) (*dto.RuntimeStatus, error) { return s.status, nil }
+
+// fakeOpenAI 模拟推理服务的 /v1/models 与流式 chat completion
+func fakeOpenAI(t *testing.T, servedModel string, chatStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]string{{"id": servedModel}}})
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, servedModel, req.Model)
+		assert.True(t, req.Stream)
+		if chatStatus != http.StatusOK {
+			http.Error(w, `{"error":"model crashed"}`, chatStatus)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"pong\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func smokeTestContext(serviceID string, status *dto.RuntimeStatus) *goal.Context {
+	ctx := goal.NewContext()
+	ctx.DeploySpec = &dto.RequirementSpec{ServiceId: serviceID, ModelName: "qwen", ModelFileDir: "/models/qwen", UpdateTime: time.Now()}
+	ctx.Shimlet = &stubShimlet{status: status}
+	return ctx
+}
+
+// 测试冒烟测试通过后记录耗时，部署期望更新后需重新验证
+func TestSmokeTest_Passed(t *testing.T) {
+	server := fakeOpenAI(t, "qwen", http.StatusOK)
+	ctx := smokeTestContext("smoke-pass", &dto.RuntimeStatus{Status: dto.PhaseRunning, EndPoint: server.URL})
+	t.Cleanup(func() { forgetGoalRuns("smoke-pass") })
+
+	assert.False(t, smokeTest.IsAchieved(ctx))
+	require.NoError(t, smokeTest.Ensure(ctx))
+	assert.True(t, smokeTest.IsAchieved(ctx))
+
+	detail, ok := ctx.Detail(smokeTestGoalName)
+	require.True(t, ok)
+	result := detail.(*dto.SmokeTestResult)
+	assert.True(t, result.Passed)
+	assert.Equal(t, "qwen", result.Model)
+	assert.GreaterOrEqual(t, result.FirstTokenMs, int64(20))
+	assert.GreaterOrEqual(t, result.LatencyMs, result.FirstTokenMs)
+
+	// 通过的版本记录在部署期望中，内存中的执行记录清除（如 shim 重启）后仍视为已验证
+	assert.True(t, ctx.DeploySpec.VerifiedUpdateTime.Equal(ctx.DeploySpec.UpdateTime))
+	assert.True(t, ctx.DeploySpec.Verified())
+	assert.False(t, ctx.DeploySpec.VerificationSkippedCurrent())
+	assert.Equal(t, dto.VerificationStatePassed, ctx.DeploySpec.VerificationState())
+	forgetGoalRuns("smoke-pass")
+	restarted := smokeTestContext("smoke-pass", &dto.RuntimeStatus{Status: dto.PhaseRunning, EndPoint: server.URL})
+	restarted.DeploySpec = ctx.DeploySpec.DeepCopy()
+	assert.True(t, smokeTest.IsAchieved(restarted))
+
+	ctx.DeploySpec.UpdateTime = ctx.DeploySpec.UpdateTime.Add(time.Second)
+	assert.False(t, smokeTest.IsAchieved(ctx))
+}
+
+// 测试 shim 运行在集群外时跳过集群内地址的冒烟测试并记录原因
+func TestSmokeTest_SkipClusterInternalEndpoint(t *testing.T) {
+	saved := inCluster
+	inCluster = func() bool { return false }
+	defer func() { inCluster = saved }()
+
+	ctx := smokeTestContext("smoke-internal", &dto.RuntimeStatus{
+		Status:   dto.PhaseRunning,
+		EndPoint: "http://xmod-svc-1.llm.svc.cluster.local:8000",
+	})
+	require.NoError(t, smokeTest.Ensure(ctx))
+	assert.True(t, smokeTest.IsAchieved(ctx))
+	detail, ok := ctx.Detail(smokeTestGoalName)
+	require.True(t, ok)
+	result := detail.(*dto.SmokeTestResult)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.SkipReason, "inside the cluster")
+
+	// 跳过的版本单独标记，不视为通过；shim 运行在集群内时重新验证
+	assert.False(t, ctx.DeploySpec.Verified())
+	assert.True(t, ctx.DeploySpec.VerificationSkippedCurrent())
+	assert.Equal(t, dto.VerificationStateSkipped, ctx.DeploySpec.VerificationState())
+	inCluster = func() bool { return true }
+	assert.False(t, smokeTest.IsAchieved(ctx))
+
+	assert.True(t, clusterInternalEndpoint("http://xmod-svc-1.llm.svc:8000"))
+	assert.False(t, clusterInternalEndpoint("http://10.0.0.1:30001"))
+	assert.False(t, clusterInternalEndpoint("https://qwen.example.com"))
+}
+
+// 测试服务就绪前等待，模型名不匹配或推理失败时报错
+func TestSmokeTest_Failures(t *testing.T) {
+	// 服务尚未 running：等待，不报错
+	ctx := smokeTestContext("smoke-fail", &dto.RuntimeStatus{Status: dto.PhasePending})
+	require.NoError(t, smokeTest.Ensure(ctx))
+	assert.False(t, smokeTest.IsAchieved(ctx))
+
+	server := fakeOpenAI(t, "llama", http.StatusOK)
+	ctx = smokeTestContext("smoke-fail", &dto.RuntimeStatus{Status: dto.PhaseRunning, EndPoint: server.URL})
+	err := smokeTest.Ensure(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "do not include qwen")
+
+	server = fakeOpenAI(t, "qwen", http.StatusInternalServerError)
+	ctx = smokeTestContext("smoke-fail", &dto.RuntimeStatus{Status: dto.PhaseRunning, EndPoint: server.URL})
+	err = smokeTest.Ensure(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+	detail, _ := ctx.Detail(smokeTestGoalName)
+	assert.False(t, detail.(*dto.SmokeTestResult).Passed)
+	assert.False(t, smokeTest.IsAchieved(ctx))
+}
+
+// 测试 TGI 等以模型目录对外服务的引擎
+func TestMatchServedModel(t *testing.T) {
+	spec := &dto.RequirementSpec{ModelName: "qwen", ModelFileDir: "/models/qwen/qwen-q4.gguf"}
+	assert.Equal(t, "qwen", matchServedModel(spec, []string{"other", "qwen"}))
+	assert.Equal(t, "/models/qwen", matchServedModel(spec, []string{"/models/qwen"}))
+	assert.Empty(t, matchServedModel(spec, []string{"llama"}))
+}
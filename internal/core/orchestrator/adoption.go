@@ -0,0 +1,152 @@
+package orchestrator
+
+import (
+	"astron-xmod-shim/internal/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 孤儿服务处理策略
+const (
+	OrphanPolicyAdopt  = "adopt"
+	OrphanPolicyIgnore = "ignore"
+	OrphanPolicyGC     = "gc"
+)
+
+const (
+	defaultDeployGoalSet = "opensource-llm-deploy"
+	defaultDeleteGoalSet = "opensource-llm-delete"
+
+	// unknownValue shimlet 无法从运行时还原字段时使用的占位值
+	unknownValue = "unknown"
+)
+
+// AdoptDeployedServices 启动时接管指定 shimlet 中已部署的服务
+// 已有部署期望的服务由 spec store 重新投递，这里只处理没有部署期望的孤儿服务：
+//   - adopt: 根据 Shimlet.Status 重建 RequirementSpec，保存并投递到队列
+//   - ignore: 仅记录日志
+//   - gc: 以下线目标集合投递，由 reconciler 回收
+func (o *Orchestrator) AdoptDeployedServices(shimletName, orphanPolicy string) error {
+	if orphanPolicy == "" {
+		orphanPolicy = OrphanPolicyAdopt
+	}
+	switch orphanPolicy {
+	case OrphanPolicyAdopt, OrphanPolicyIgnore, OrphanPolicyGC:
+	default:
+		return fmt.Errorf("unsupported orphan policy: %s", orphanPolicy)
+	}
+
+	runtimeShimlet, err := o.shimReg.GetSingleton(shimletName)
+	if err != nil {
+		return fmt.Errorf("get shimlet %s failed: %w", shimletName, err)
+	}
+	serviceIDs, err := runtimeShimlet.ListDeployedServices()
+	if err != nil {
+		return fmt.Errorf("list deployed services from %s failed: %w", shimletName, err)
+	}
+
+	adopted := 0
+	for _, serviceID := range serviceIDs {
+		if o.specStore.Get(serviceID) != nil {
+			continue
+		}
+
+		switch orphanPolicy {
+		case OrphanPolicyIgnore:
+			log.Info("orphan service %s ignored", serviceID)
+			continue
+		case OrphanPolicyGC:
+			log.Info("orphan service %s will be garbage collected", serviceID)
+			if err := o.specStore.Set(serviceID, &dto.RequirementSpec{
+				ServiceId:            serviceID,
+				GoalSetName:          defaultDeleteGoalSet,
+				ShimletName:          shimletName,
+				ResourceRequirements: &dto.ResourceRequirements{},
+				CreateTime:           time.Now(),
+				UpdateTime:           time.Now(),
+			}); err != nil {
+				// 冲突表示其他副本已为该服务写入部署期望
+				log.Warn("persist gc spec of orphan service %s failed: %v", serviceID, err)
+				continue
+			}
+		case OrphanPolicyAdopt:
+			status, err := runtimeShimlet.Status(serviceID)
+			if err != nil {
+				log.Error("get status of orphan service %s failed, skip adoption: %v", serviceID, err)
+				continue
+			}
+			adoptedSpec, err := rebuildSpec(serviceID, shimletName, status)
+			if err != nil {
+				log.Warn("orphan service %s cannot be adopted: %v", serviceID, err)
+				continue
+			}
+			log.Info("orphan service %s adopted, model: %s", serviceID, adoptedSpec.ModelName)
+			if err := o.specStore.Set(serviceID, adoptedSpec); err != nil {
+				log.Warn("persist adopted spec of orphan service %s failed: %v", serviceID, err)
+				continue
+			}
+		}
+
+		o.queue.Add(serviceID)
+		adopted++
+	}
+
+	log.Info("adoption finished on shimlet %s: %d deployed, %d orphans handled with policy %s",
+		shimletName, len(serviceIDs), adopted, orphanPolicy)
+	return nil
+}
+
+// ShimletsInUse 返回需要接管的 shimlet：current-shimlet、显式配置了 type 的具名实例以及已有部署期望引用的 shimlet
+func (o *Orchestrator) ShimletsInUse(currentShimlet string) []string {
+	seen := map[string]struct{}{currentShimlet: {}}
+	names := []string{currentShimlet}
+	add := func(name string) {
+		if _, ok := seen[name]; ok || name == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	if globalCfg := config.Get(); globalCfg != nil {
+		for name, shimletCfg := range globalCfg.Shimlets {
+			if shimletCfg.Type != "" {
+				add(name)
+			}
+		}
+	}
+	for _, deploySpec := range o.specStore.List() {
+		add(deploySpec.ShimletName)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// rebuildSpec 根据运行时状态重建部署期望
+func rebuildSpec(serviceID, shimletName string, status *dto.RuntimeStatus) (*dto.RequirementSpec, error) {
+	rebuilt := status.DeploySpec
+	if rebuilt.ModelName == "" || rebuilt.ModelName == unknownValue {
+		return nil, fmt.Errorf("model name not recorded in runtime")
+	}
+
+	rebuilt.ServiceId = serviceID
+	if rebuilt.ModelFileDir == unknownValue {
+		// 交给 map-model-path 目标重新映射
+		rebuilt.ModelFileDir = ""
+	}
+	if rebuilt.GoalSetName == "" {
+		rebuilt.GoalSetName = defaultDeployGoalSet
+	}
+	if rebuilt.ShimletName == "" {
+		rebuilt.ShimletName = shimletName
+	}
+	if rebuilt.ResourceRequirements == nil {
+		rebuilt.ResourceRequirements = &dto.ResourceRequirements{}
+	}
+	// 运行时不记录提交时间，以接管时间为准
+	rebuilt.CreateTime = time.Now()
+	rebuilt.UpdateTime = rebuilt.CreateTime
+	return &rebuilt, nil
+}
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"sort"
+)
+
+// ListGoalSets 列出已注册的目标集合，包括内置目标集合与声明式定义的目标集合
+func (o *Orchestrator) ListGoalSets() []dto.GoalSetInfo {
+	infos := make([]dto.GoalSetInfo, 0, len(o.goalSetReg))
+	for _, goalSet := range o.goalSetReg {
+		info := dto.GoalSetInfo{
+			Name:       goalSet.Name,
+			Source:     goalSet.Source,
+			MaxRetries: goalSet.MaxRetries,
+			TimeoutMs:  goalSet.Timeout.Milliseconds(),
+			Rollback:   goalSet.Rollback,
+			Teardown:   goalSet.Teardown,
+			Goals:      make([]dto.GoalInfo, 0, len(goalSet.Goals)),
+		}
+		if info.Source == "" {
+			info.Source = "builtin"
+		}
+		for i, g := range goalSet.Goals {
+			goalType := g.Type
+			if goalType == "" {
+				goalType = "builtin"
+			}
+			info.Goals = append(info.Goals, dto.GoalInfo{
+				Name:       g.Name,
+				Type:       goalType,
+				HoldPhase:  g.HoldPhase,
+				DependsOn:  goalSet.Dependencies(i),
+				Compensate: g.Compensate != nil,
+			})
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
@@ -2,15 +2,22 @@ package orchestrator
 
 import (
 	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/engine"
+	"astron-xmod-shim/internal/core/eventbus"
 	"astron-xmod-shim/internal/core/goal"
 	_ "astron-xmod-shim/internal/core/goal/goalset"
+	"astron-xmod-shim/internal/core/progress"
 	"astron-xmod-shim/internal/core/shimlet"
 	"astron-xmod-shim/internal/core/spec"
 	"astron-xmod-shim/internal/core/typereg"
 	"astron-xmod-shim/internal/core/workqueue"
 	dto "astron-xmod-shim/internal/dto/deploy"
+	event "astron-xmod-shim/internal/dto/eventbus"
 	"astron-xmod-shim/pkg/log"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 )
 
 type Orchestrator struct {
@@ -18,6 +25,8 @@ type Orchestrator struct {
 	goalSetReg map[string]*goal.GoalSet
 	specStore  spec.Store
 	queue      *workqueue.Queue
+	tracker    *progress.Tracker
+	bus        *eventbus.Bus
 }
 
 func NewOrchestrator(
@@ -25,30 +34,94 @@ func NewOrchestrator(
 	pipeReg map[string]*goal.GoalSet,
 	queue *workqueue.Queue,
 	specStore spec.Store,
+	tracker *progress.Tracker,
+	bus *eventbus.Bus,
 ) *Orchestrator {
 	return &Orchestrator{
 		queue:      queue,
 		shimReg:    shimReg,
 		goalSetReg: pipeReg,
 		specStore:  specStore,
+		tracker:    tracker,
+		bus:        bus,
 	}
 }
 
 var GlobalOrchestrator *Orchestrator
 
+// Provision 提交部署或更新的部署期望；下线目标集合只能经 SubmitDelete 提交
 func (o *Orchestrator) Provision(spec *dto.RequirementSpec) error {
+	return o.provision(spec, false)
+}
+
+// SubmitDelete 以默认下线目标集合提交服务的删除，由 reconciler 收敛后释放服务
+func (o *Orchestrator) SubmitDelete(serviceID string) error {
+	return o.provision(&dto.RequirementSpec{
+		ServiceId:            serviceID,
+		GoalSetName:          defaultDeleteGoalSet,
+		ResourceRequirements: &dto.ResourceRequirements{},
+	}, true)
+}
+
+func (o *Orchestrator) provision(spec *dto.RequirementSpec, teardown bool) error {
 
 	// 覆盖掉 nvidia.com/gpu 的 limit
 	spec.ResourceRequirements.AcceleratorType = "nvidia.com/gpu"
 
-	// goalset 已在api handler 层 确定
-	// shimlet 已在启动时配置全局确定
+	// goalset 由请求指定，未指定时沿用原部署期望的目标集合，新服务使用默认部署目标集合（见 resolveSpecGoalSet）
+	// shimlet 由请求指定，未指定时使用 current-shimlet（见 resolveSpecShimlet）
+	// engine 未指定时使用默认引擎，更新时不沿用原引擎（与其他字段一致，以本次提交为准）
+	if !engine.Registry.Has(spec.Engine) {
+		return fmt.Errorf("%w: %s", ErrEngineNotRegistered, spec.Engine)
+	}
+	// 并行方式需与分配的显卡匹配，引擎是否支持在渲染时校验
+	if _, err := engine.ResolveParallelism(spec); err != nil {
+		return err
+	}
+	if err := validateCallbackURL(spec.CallbackURL); err != nil {
+		return err
+	}
 
 	// RequirementSpec 持久化 部署期望
 	spec.ReplicaCount = 1
-	spec.ShimletName = config.Get().CurrentShimlet
+
+	// 更新时保留首次提交时间，未重新指定回调地址时沿用原地址
+	now := time.Now()
+	spec.CreateTime = now
+	existing := o.specStore.Get(spec.ServiceId)
+	// 基于读取到的版本写入，期间被其他副本修改时返回冲突
+	spec.ResourceVersion = ""
+	if existing != nil {
+		spec.ResourceVersion = existing.ResourceVersion
+		if !existing.CreateTime.IsZero() {
+			spec.CreateTime = existing.CreateTime
+		}
+		if spec.CallbackURL == "" {
+			spec.CallbackURL = existing.CallbackURL
+		}
+	}
+	if err := o.resolveSpecShimlet(spec, existing); err != nil {
+		return err
+	}
+	if err := o.resolveSpecGoalSet(spec, existing, teardown); err != nil {
+		return err
+	}
+	// 回滚信息由 shim 维护：沿用已有的最近一次正常版本，目标集合未启用回滚时（如下线）不保留
+	spec.LastKnownGood, spec.RolledBack = nil, false
+	spec.VerifiedUpdateTime, spec.VerificationSkipped = time.Time{}, false
+	spec.ConvergeStartTime, spec.ConvergeRetries = time.Time{}, 0
+	if existing != nil && o.goalSetReg[spec.GoalSetName].Rollback {
+		spec.LastKnownGood = existing.LastKnownGood
+	}
+	spec.UpdateTime = now
 	// 如果这里是更新, 则需要 对应goalset reconcile 检测到 不一致 并调用ensure 闭环
-	o.specStore.Set(spec.ServiceId, spec)
+	if err := o.specStore.Set(spec.ServiceId, spec); err != nil {
+		return err
+	}
+
+	// 新提交开启新一轮收敛，重试次数与超时重新计算
+	o.tracker.Reset(spec.ServiceId, spec.GoalSetName)
+	o.setPhase(spec.ServiceId, spec.GoalSetName, spec.CallbackURL, dto.PhasePending)
 
 	// 投递到队列
 	o.queue.Add(spec.ServiceId)
@@ -56,11 +129,168 @@ func (o *Orchestrator) Provision(spec *dto.RequirementSpec) error {
 	return nil
 }
 
+// ErrServiceNotFound 服务不存在部署期望
+var ErrServiceNotFound = errors.New("service not found")
+
+var (
+	// ErrShimletNotRegistered 指定的 shimlet 未注册
+	ErrShimletNotRegistered = errors.New("shimlet not registered")
+	// ErrShimletChanged 已有服务不能迁移到其他 shimlet，需先删除
+	ErrShimletChanged = errors.New("shimlet of an existing service cannot be changed")
+	// ErrEngineNotRegistered 指定的推理引擎未注册
+	ErrEngineNotRegistered = engine.ErrEngineNotRegistered
+	// ErrInvalidParallelism 并行方式与分配的显卡不匹配
+	ErrInvalidParallelism = engine.ErrInvalidParallelism
+	// ErrGoalSetNotRegistered 指定的目标集合未注册
+	ErrGoalSetNotRegistered = errors.New("goal set not registered")
+	// ErrInvalidCallbackURL 回调地址不是带主机的 http(s) 绝对地址
+	ErrInvalidCallbackURL = errors.New("invalid callback url")
+	// ErrTeardownGoalSet 部署与更新请求不能指定下线目标集合，删除服务需调用删除接口
+	ErrTeardownGoalSet = errors.New("teardown goal set cannot be used to deploy")
+	// ErrNoRollbackTarget 服务没有可回滚到的正常版本（从未收敛成功，或当前即为最近一次正常版本）
+	ErrNoRollbackTarget = errors.New("no known-good spec to roll back to")
+)
+
+// validateCallbackURL 校验请求级回调地址，未指定时沿用原地址
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidCallbackURL, callbackURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w %q, expected an http(s) address", ErrInvalidCallbackURL, callbackURL)
+	}
+	return nil
+}
+
+// resolveSpecGoalSet 确定收敛服务使用的目标集合：
+// 未指定时沿用已有部署期望中的部署目标集合，新服务使用默认部署目标集合；指定时校验是否注册。
+// 非删除提交不能使用下线目标集合，否则一次“部署”就会删除服务
+func (o *Orchestrator) resolveSpecGoalSet(spec, existing *dto.RequirementSpec, teardown bool) error {
+	if spec.GoalSetName == "" {
+		spec.GoalSetName = defaultDeployGoalSet
+		if existing != nil && existing.GoalSetName != "" {
+			if previous, ok := o.goalSetReg[existing.GoalSetName]; ok && !previous.Teardown {
+				spec.GoalSetName = existing.GoalSetName
+			}
+		}
+	}
+	goalSet, ok := o.goalSetReg[spec.GoalSetName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrGoalSetNotRegistered, spec.GoalSetName)
+	}
+	if goalSet.Teardown && !teardown {
+		return fmt.Errorf("%w: %s", ErrTeardownGoalSet, spec.GoalSetName)
+	}
+	return nil
+}
+
+// resolveSpecShimlet 确定服务所属的 shimlet：
+// 未指定时沿用已有部署期望中的 shimlet，新服务使用 current-shimlet；指定时校验是否注册
+func (o *Orchestrator) resolveSpecShimlet(spec, existing *dto.RequirementSpec) error {
+	owner := ""
+	if existing != nil {
+		owner = existing.ShimletName
+	}
+	if spec.ShimletName == "" {
+		spec.ShimletName = owner
+		if spec.ShimletName == "" {
+			spec.ShimletName = config.Get().CurrentShimlet
+		}
+	}
+	if owner != "" && spec.ShimletName != owner {
+		return fmt.Errorf("%w: service %s is managed by %s", ErrShimletChanged, spec.ServiceId, owner)
+	}
+	if !o.shimReg.Has(spec.ShimletName) {
+		return fmt.Errorf("%w: %s", ErrShimletNotRegistered, spec.ShimletName)
+	}
+	return nil
+}
+
+// ShimletOf 返回服务所属的 shimlet 名称，没有部署期望时使用 current-shimlet
+func (o *Orchestrator) ShimletOf(serviceID string) string {
+	if deploySpec := o.specStore.Get(serviceID); deploySpec != nil && deploySpec.ShimletName != "" {
+		return deploySpec.ShimletName
+	}
+	return config.Get().CurrentShimlet
+}
+
+// RetryService 清除服务的失败终态并重新投递，按原部署期望重新收敛
+func (o *Orchestrator) RetryService(serviceID string) error {
+	deploySpec := o.specStore.Get(serviceID)
+	if deploySpec == nil {
+		return ErrServiceNotFound
+	}
+	// 清除持久化的收敛进度，重启后同样按新一轮计算
+	if !deploySpec.ConvergeStartTime.IsZero() || deploySpec.ConvergeRetries != 0 {
+		updated := deploySpec.DeepCopy()
+		updated.ConvergeStartTime, updated.ConvergeRetries = time.Time{}, 0
+		if err := o.specStore.Set(serviceID, updated); err != nil {
+			return err
+		}
+	}
+	o.tracker.Reset(serviceID, deploySpec.GoalSetName)
+	o.setPhase(serviceID, deploySpec.GoalSetName, deploySpec.CallbackURL, dto.PhasePending)
+	o.queue.Add(serviceID)
+	log.Info("service %s resubmitted for retry", serviceID)
+	return nil
+}
+
+// RollbackService 回滚到最近一次收敛成功的部署期望：
+// 由 reconciler 对本轮执行过的目标执行补偿动作，再按该版本重新收敛
+func (o *Orchestrator) RollbackService(serviceID string) error {
+	deploySpec := o.specStore.Get(serviceID)
+	if deploySpec == nil {
+		return ErrServiceNotFound
+	}
+	if deploySpec.RollbackTarget() == nil {
+		return fmt.Errorf("%w: service %s", ErrNoRollbackTarget, serviceID)
+	}
+	o.tracker.RequestRollback(serviceID, "rollback requested via API")
+	o.queue.Add(serviceID)
+	log.Info("service %s rollback requested", serviceID)
+	return nil
+}
+
+// setPhase 记录部署阶段，发生变化时发布事件
+func (o *Orchestrator) setPhase(serviceID, goalSetName, callbackURL string, phase dto.DeployPhase) {
+	if goalSetName == "" {
+		if progress, ok := o.tracker.Get(serviceID); ok {
+			goalSetName = progress.GoalSetName
+		}
+	}
+	if prev, changed := o.tracker.SetPhase(serviceID, goalSetName, phase); changed {
+		o.bus.Publish(event.ServiceEvent{
+			Type:        event.EventPhaseChanged,
+			ServiceID:   serviceID,
+			GoalSetName: goalSetName,
+			From:        prev,
+			To:          phase,
+			CallbackURL: callbackURL,
+		})
+	}
+}
+
+// GetServiceProgress 获取服务的收敛进度
+func (o *Orchestrator) GetServiceProgress(serviceID string) (dto.ServiceProgress, bool) {
+	return o.tracker.Get(serviceID)
+}
+
+// GetServiceVerification 返回服务当前版本部署后冒烟测试的状态（passed / skipped），尚未验证时为空
+func (o *Orchestrator) GetServiceVerification(serviceID string) string {
+	if deploySpec := o.specStore.Get(serviceID); deploySpec != nil {
+		return deploySpec.VerificationState()
+	}
+	return ""
+}
+
 // DeleteService 删除指定的模型服务
 func (o *Orchestrator) DeleteService(serviceID string) error {
-	// 获取当前使用的shimlet
-	currentShimletId := config.Get().CurrentShimlet
-	runtimeShimlet, err := o.shimReg.GetSingleton(currentShimletId)
+	// 通过服务所属的 shimlet 删除
+	runtimeShimlet, err := o.shimReg.GetSingleton(o.ShimletOf(serviceID))
 	if err != nil {
 		log.Error("get runtime shimlet error", err)
 		return err
@@ -69,8 +299,22 @@ func (o *Orchestrator) DeleteService(serviceID string) error {
 	// 调用shimlet的Delete方法删除资源
 	if err := runtimeShimlet.Delete(serviceID); err != nil {
 		log.Error("delete service failed", err)
+		o.bus.Publish(event.ServiceEvent{
+			Type:      event.EventError,
+			ServiceID: serviceID,
+			Error:     err.Error(),
+		})
 		return err
 	}
+	callbackURL := ""
+	if deploySpec := o.specStore.Get(serviceID); deploySpec != nil {
+		callbackURL = deploySpec.CallbackURL
+	}
+	o.setPhase(serviceID, "", callbackURL, dto.PhaseTerminating)
+	// 绕过 reconciler 直接删除：同时删除部署期望与进度，避免被重新部署
+	o.specStore.Delete(serviceID)
+	o.tracker.Delete(serviceID)
+	goal.RunTeardownHooks(serviceID)
 
 	go log.Info("service deleted successfully", "serviceID", serviceID)
 	return nil
@@ -82,8 +326,7 @@ func (o *Orchestrator) GetServiceStatus(serviceID string) (*dto.RuntimeStatus, e
 		return nil, fmt.Errorf("serviceID is required")
 	}
 
-	currentShimletId := config.Get().CurrentShimlet
-	runtimeShimlet, err := o.shimReg.GetSingleton(currentShimletId)
+	runtimeShimlet, err := o.shimReg.GetSingleton(o.ShimletOf(serviceID))
 	if err != nil {
 		return nil, err
 	}
@@ -94,5 +337,15 @@ func (o *Orchestrator) GetServiceStatus(serviceID string) (*dto.RuntimeStatus, e
 	if status.EndPoint != "" {
 		status.EndPoint += "/v1/chat/completions"
 	}
+	// 部署后验证未通过前，运行中的服务上报验证阶段
+	if serviceProgress, ok := o.tracker.Get(serviceID); ok {
+		if goalSet, ok := o.goalSetReg[serviceProgress.GoalSetName]; ok {
+			status.Status = goalSet.ObservedPhase(status.Status, serviceProgress)
+		}
+	}
+	// 超过重试次数或超时的服务，无论运行时状态如何都视为失败
+	if o.tracker.IsFailed(serviceID) {
+		status.Status = dto.PhaseFailed
+	}
 	return status, nil
 }
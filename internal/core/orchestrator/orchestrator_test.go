@@ -1 +1,242 @@
 package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/eventbus"
+	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/progress"
+	"astron-xmod-shim/internal/core/shimlet"
+	"astron-xmod-shim/internal/core/spec"
+	"astron-xmod-shim/internal/core/typereg"
+	"astron-xmod-shim/internal/core/workqueue"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	dir, err := os.MkdirTemp("", "xmod-orchestrator")
+	if err != nil {
+		panic(err)
+	}
+	confPath := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(confPath, []byte("shimlets:\n  counting:\n    config-path: \"\"\n"), 0o644); err != nil {
+		panic(err)
+	}
+	config.SetConfigPath(confPath)
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// countingShimlet 记录 Status 调用，blocked 中的服务查询状态时阻塞到 release 关闭
+type countingShimlet struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	blocked  map[string]bool
+	release  chan struct{}
+}
+
+func (s *countingShimlet) ID() string                              { return "counting" }
+func (s *countingShimlet) Description() string                     { return "counting shimlet" }
+func (s *countingShimlet) InitWithConfig(string) error             { return nil }
+func (s *countingShimlet) Apply(*dto.RequirementSpec) error        { return nil }
+func (s *countingShimlet) Delete(string) error                     { return nil }
+func (s *countingShimlet) ListDeployedServices() ([]string, error) { return nil, nil }
+
+func (s *countingShimlet) Status(serviceID string) (*dto.RuntimeStatus, error) {
+	s.mu.Lock()
+	s.statuses[serviceID]++
+	blocked := s.blocked[serviceID]
+	s.mu.Unlock()
+	if blocked {
+		<-s.release
+	}
+	return &dto.RuntimeStatus{Status: dto.PhaseRunning, EndPoint: "http://" + serviceID}, nil
+}
+
+func (s *countingShimlet) statusCalls() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make(map[string]int, len(s.statuses))
+	for id, n := range s.statuses {
+		calls[id] = n
+	}
+	return calls
+}
+
+// newListOrchestrator 创建只用于查询的 orchestrator，服务部署期望直接写入存储
+func newListOrchestrator(t *testing.T, fake *countingShimlet, serviceIDs ...string) (*Orchestrator, *progress.Tracker) {
+	reg := typereg.New[shimlet.Shimlet]()
+	reg.Register("counting", func() shimlet.Shimlet { return fake })
+	store := spec.NewMemoryStore()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range serviceIDs {
+		require.NoError(t, store.Set(id, &dto.RequirementSpec{
+			ServiceId:   id,
+			ModelName:   "qwen",
+			ShimletName: "counting",
+			CreateTime:  base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	tracker := progress.NewTracker()
+	bus := eventbus.New()
+	t.Cleanup(bus.Close)
+	return NewOrchestrator(reg, goal.Registry, workqueue.New(), store, tracker, bus), tracker
+}
+
+func newSummaries() []dto.ServiceSummary {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []dto.ServiceSummary{
+		{ServiceId: "a", ModelName: "qwen", Phase: dto.PhaseRunning, CreateTime: base},
+		{ServiceId: "b", ModelName: "llama", Phase: dto.PhaseFailed, CreateTime: base.Add(time.Minute)},
+		{ServiceId: "c", ModelName: "qwen", Phase: dto.PhaseRunning, CreateTime: base.Add(2 * time.Minute)},
+		{ServiceId: "d", ModelName: "qwen", Phase: dto.PhaseRunning, CreateTime: base.Add(2 * time.Minute)},
+		{ServiceId: "e", ModelName: "glm", Phase: dto.PhasePending, CreateTime: base.Add(3 * time.Minute)},
+	}
+}
+
+// 测试游标分页可以完整、不重复地遍历全部服务
+func TestQueryServices_CursorPagination(t *testing.T) {
+	query := &dto.ServiceListQuery{Limit: 2}
+	var ids []string
+	for page := 0; page < 5; page++ {
+		result, err := queryServices(newSummaries(), query)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.Total)
+		for _, item := range result.Items {
+			ids = append(ids, item.ServiceId)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	// 默认按创建时间倒序，创建时间相同按 serviceId 倒序
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, ids)
+}
+
+// 测试阶段过滤与升序排序
+func TestQueryServices_FilterAndSort(t *testing.T) {
+	result, err := queryServices(newSummaries(), &dto.ServiceListQuery{
+		Phase:  dto.PhaseRunning,
+		SortBy: SortByServiceID,
+		Order:  OrderAsc,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 3)
+	assert.Equal(t, "a", result.Items[0].ServiceId)
+	assert.Equal(t, "d", result.Items[2].ServiceId)
+	assert.Empty(t, result.NextCursor)
+}
+
+// 测试非法参数
+func TestQueryServices_InvalidQuery(t *testing.T) {
+	_, err := queryServices(newSummaries(), &dto.ServiceListQuery{SortBy: "size"})
+	assert.Error(t, err)
+
+	first, err := queryServices(newSummaries(), &dto.ServiceListQuery{Limit: 1})
+	require.NoError(t, err)
+	_, err = queryServices(newSummaries(), &dto.ServiceListQuery{Limit: 1, Order: OrderAsc, Cursor: first.NextCursor})
+	assert.Error(t, err)
+}
+
+// 测试先按存储数据分页，只查询当前页服务的运行时状态
+func TestListServices_StatusOnlyForPage(t *testing.T) {
+	fake := &countingShimlet{statuses: map[string]int{}}
+	o, tracker := newListOrchestrator(t, fake, "a", "b", "c", "d", "e")
+	tracker.SetPhase("a", "", dto.PhaseFailed)
+
+	result, err := o.ListServices(&dto.ServiceListQuery{Limit: 2, SortBy: SortByServiceID, Order: OrderAsc})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "http://a/v1/chat/completions", result.Items[0].Endpoint)
+	assert.Equal(t, dto.PhaseUnknown, result.Items[1].Phase)
+	assert.Equal(t, dto.PhaseRunning, result.Items[1].RuntimePhase)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, fake.statusCalls())
+
+	// 阶段过滤使用 reconciler 观测到的阶段，未观测的服务为 unknown；返回的 Phase 与过滤条件一致
+	result, err = o.ListServices(&dto.ServiceListQuery{Phase: dto.PhaseFailed})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "a", result.Items[0].ServiceId)
+	assert.Equal(t, dto.PhaseFailed, result.Items[0].Phase)
+	assert.Equal(t, dto.PhaseRunning, result.Items[0].RuntimePhase)
+	assert.Equal(t, 2, fake.statusCalls()["a"])
+}
+
+// 测试状态查询超时后不再等待，未返回的服务没有实时阶段；非法查询返回 ErrInvalidListQuery
+func TestListServices_StatusTimeout(t *testing.T) {
+	saved := listStatusTimeout
+	listStatusTimeout = 50 * time.Millisecond
+	defer func() { listStatusTimeout = saved }()
+
+	fake := &countingShimlet{statuses: map[string]int{}, blocked: map[string]bool{"slow": true}, release: make(chan struct{})}
+	defer close(fake.release)
+	o, tracker := newListOrchestrator(t, fake, "fast", "slow")
+	tracker.SetPhase("slow", "", dto.PhasePending)
+
+	start := time.Now()
+	result, err := o.ListServices(&dto.ServiceListQuery{SortBy: SortByServiceID, Order: OrderAsc})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, dto.PhaseRunning, result.Items[0].RuntimePhase)
+	assert.Equal(t, dto.PhasePending, result.Items[1].Phase)
+	assert.Empty(t, result.Items[1].RuntimePhase)
+	assert.Empty(t, result.Items[1].Endpoint)
+
+	_, err = o.ListServices(&dto.ServiceListQuery{SortBy: "size"})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+}
+
+// 测试回调地址必须是带主机的 http(s) 绝对地址
+func TestProvision_CallbackURL(t *testing.T) {
+	orch, _ := newListOrchestrator(t, &countingShimlet{statuses: map[string]int{}})
+	provision := func(callbackURL string) error {
+		return orch.Provision(&dto.RequirementSpec{
+			ServiceId:            "svc",
+			ModelName:            "qwen",
+			ShimletName:          "counting",
+			ResourceRequirements: &dto.ResourceRequirements{},
+			CallbackURL:          callbackURL,
+		})
+	}
+
+	for _, callbackURL := range []string{"/hook", "ftp://hooks.local/x", "http://", "hooks.local/x", "http://%zz"} {
+		assert.ErrorIs(t, provision(callbackURL), ErrInvalidCallbackURL, callbackURL)
+	}
+	require.NoError(t, provision("https://hooks.local/xmod?token=1"))
+	assert.Equal(t, "https://hooks.local/xmod?token=1", orch.specStore.Get("svc").CallbackURL)
+}
+
+// 测试部署与更新请求不能指定下线目标集合，只能经删除提交
+func TestProvision_RejectTeardownGoalSet(t *testing.T) {
+	orch, _ := newListOrchestrator(t, &countingShimlet{statuses: map[string]int{}}, "svc")
+	err := orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "svc",
+		ModelName:            "qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          defaultDeleteGoalSet,
+	})
+	assert.ErrorIs(t, err, ErrTeardownGoalSet)
+	assert.Empty(t, orch.specStore.Get("svc").GoalSetName)
+
+	require.NoError(t, orch.SubmitDelete("svc"))
+	assert.Equal(t, defaultDeleteGoalSet, orch.specStore.Get("svc").GoalSetName)
+
+	// 删除提交后再次部署，未指定目标集合时使用默认部署目标集合
+	require.NoError(t, orch.Provision(&dto.RequirementSpec{ServiceId: "svc", ModelName: "qwen", ResourceRequirements: &dto.ResourceRequirements{}}))
+	assert.Equal(t, defaultDeployGoalSet, orch.specStore.Get("svc").GoalSetName)
+}
@@ -0,0 +1,276 @@
+package orchestrator
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+
+	SortByCreateTime = "createTime"
+	SortByUpdateTime = "updateTime"
+	SortByServiceID  = "serviceId"
+	SortByModelName  = "modelName"
+	SortByPhase      = "phase"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	// listStatusConcurrency 查询当前页服务状态的并发数
+	listStatusConcurrency = 10
+)
+
+// listStatusTimeout 查询当前页服务状态的总超时，测试中会缩短
+var listStatusTimeout = 5 * time.Second
+
+// listCursor 游标内容：上一页最后一项的排序键，以及生成游标时的排序方式
+type listCursor struct {
+	SortBy    string `json:"s"`
+	Order     string `json:"o"`
+	Key       string `json:"k"`
+	ServiceID string `json:"id"`
+}
+
+// ErrInvalidListQuery 服务列表查询条件不合法（排序字段、排序方向或游标）
+var ErrInvalidListQuery = errors.New("invalid service list query")
+
+// ListServices 基于 spec store 查询服务列表：先按部署期望与 reconciler 最近观测到的阶段过滤、排序、分页，
+// 再只对当前页的服务并发查询 shimlet 状态。观测阶段作为 Phase 原样返回，实时状态单独放在 RuntimePhase，
+// 保证返回的 Phase 与过滤、排序一致
+func (o *Orchestrator) ListServices(query *dto.ServiceListQuery) (*dto.ServiceList, error) {
+	summaries := make([]dto.ServiceSummary, 0)
+	for _, deploySpec := range o.specStore.List() {
+		if !matchSpec(deploySpec, query) {
+			continue
+		}
+
+		summary := dto.ServiceSummary{
+			ServiceId:    deploySpec.ServiceId,
+			ModelName:    deploySpec.ModelName,
+			Phase:        o.observedPhase(deploySpec.ServiceId),
+			ReplicaCount: deploySpec.ReplicaCount,
+			ShimletName:  deploySpec.ShimletName,
+			GoalSetName:  deploySpec.GoalSetName,
+			Labels:       deploySpec.Labels,
+			CreateTime:   deploySpec.CreateTime,
+			UpdateTime:   deploySpec.UpdateTime,
+		}
+		if deploySpec.ResourceRequirements != nil {
+			summary.AcceleratorType = deploySpec.ResourceRequirements.AcceleratorType
+			summary.AcceleratorCount = deploySpec.ResourceRequirements.AcceleratorCount
+		}
+		summaries = append(summaries, summary)
+	}
+
+	result, err := queryServices(summaries, query)
+	if err != nil {
+		return nil, err
+	}
+	o.fillStatus(result.Items)
+	return result, nil
+}
+
+// observedPhase 返回 reconciler 最近观测到的部署阶段，尚未观测时为 unknown
+func (o *Orchestrator) observedPhase(serviceID string) dto.DeployPhase {
+	if o.tracker.IsFailed(serviceID) {
+		return dto.PhaseFailed
+	}
+	if serviceProgress, ok := o.tracker.Get(serviceID); ok && serviceProgress.Phase != "" {
+		return serviceProgress.Phase
+	}
+	return dto.PhaseUnknown
+}
+
+// fillStatus 并发查询当前页服务的运行时状态与访问地址，整体超过 listStatusTimeout 后不再等待
+func (o *Orchestrator) fillStatus(items []dto.ServiceSummary) {
+	type statusResult struct {
+		index  int
+		status *dto.RuntimeStatus
+	}
+	// 缓冲足够容纳全部结果，超时后仍在查询的协程返回时不会阻塞
+	results := make(chan statusResult, len(items))
+	sem := make(chan struct{}, listStatusConcurrency)
+	for i := range items {
+		serviceID := items[i].ServiceId
+		go func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			status, err := o.GetServiceStatus(serviceID)
+			if err != nil {
+				log.Warn("get status of service %s failed: %v", serviceID, err)
+			}
+			results <- statusResult{index: i, status: status}
+		}()
+	}
+
+	timeout := time.NewTimer(listStatusTimeout)
+	defer timeout.Stop()
+	for range items {
+		select {
+		case result := <-results:
+			if result.status != nil {
+				items[result.index].RuntimePhase = result.status.Status
+				items[result.index].Endpoint = result.status.EndPoint
+			}
+		case <-timeout.C:
+			log.Warn("get status of listed services timed out after %s", listStatusTimeout)
+			return
+		}
+	}
+}
+
+// matchSpec 判断部署期望是否满足过滤条件（不含运行阶段）
+func matchSpec(deploySpec *dto.RequirementSpec, query *dto.ServiceListQuery) bool {
+	if query.ModelName != "" && deploySpec.ModelName != query.ModelName {
+		return false
+	}
+	if query.ShimletName != "" && deploySpec.ShimletName != query.ShimletName {
+		return false
+	}
+	for k, v := range query.Labels {
+		if actual, ok := deploySpec.Labels[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// queryServices 对服务列表做阶段过滤、排序和游标分页
+func queryServices(summaries []dto.ServiceSummary, query *dto.ServiceListQuery) (*dto.ServiceList, error) {
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = SortByCreateTime
+	}
+	if _, err := sortKey(dto.ServiceSummary{}, sortBy); err != nil {
+		return nil, err
+	}
+	order := query.Order
+	if order == "" {
+		order = OrderDesc
+	}
+	if order != OrderAsc && order != OrderDesc {
+		return nil, fmt.Errorf("%w: unsupported order: %s", ErrInvalidListQuery, order)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	filtered := make([]dto.ServiceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if query.Phase != "" && summary.Phase != query.Phase {
+			continue
+		}
+		filtered = append(filtered, summary)
+	}
+
+	// 排序键相同时以 serviceId 兜底，保证顺序稳定，游标才能准确续读
+	less := func(a, b dto.ServiceSummary) bool {
+		ka, _ := sortKey(a, sortBy)
+		kb, _ := sortKey(b, sortBy)
+		if ka != kb {
+			return ka < kb
+		}
+		return a.ServiceId < b.ServiceId
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if order == OrderAsc {
+			return less(filtered[i], filtered[j])
+		}
+		return less(filtered[j], filtered[i])
+	})
+
+	start := 0
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != sortBy || cursor.Order != order {
+			return nil, fmt.Errorf("%w: cursor does not match sortBy/order of the query", ErrInvalidListQuery)
+		}
+		// 找到第一个排在游标之后的元素
+		start = sort.Search(len(filtered), func(i int) bool {
+			ki, _ := sortKey(filtered[i], sortBy)
+			if ki == cursor.Key {
+				if order == OrderAsc {
+					return filtered[i].ServiceId > cursor.ServiceID
+				}
+				return filtered[i].ServiceId < cursor.ServiceID
+			}
+			if order == OrderAsc {
+				return ki > cursor.Key
+			}
+			return ki < cursor.Key
+		})
+	}
+
+	end := start + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	result := &dto.ServiceList{
+		Items: filtered[start:end],
+		Total: len(filtered),
+	}
+	if end < len(filtered) {
+		lastItem := filtered[end-1]
+		key, _ := sortKey(lastItem, sortBy)
+		result.NextCursor = encodeCursor(listCursor{SortBy: sortBy, Order: order, Key: key, ServiceID: lastItem.ServiceId})
+	}
+	return result, nil
+}
+
+// sortKey 返回可按字典序比较的排序键
+func sortKey(summary dto.ServiceSummary, sortBy string) (string, error) {
+	switch sortBy {
+	case SortByCreateTime:
+		return timeKey(summary.CreateTime), nil
+	case SortByUpdateTime:
+		return timeKey(summary.UpdateTime), nil
+	case SortByServiceID:
+		return summary.ServiceId, nil
+	case SortByModelName:
+		return summary.ModelName, nil
+	case SortByPhase:
+		return string(summary.Phase), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported sortBy: %s", ErrInvalidListQuery, sortBy)
+	}
+}
+
+// timeKey 将时间转换为定长数字串，零值排在最前
+func timeKey(t time.Time) string {
+	if t.IsZero() {
+		return fmt.Sprintf("%020d", 0)
+	}
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+func encodeCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor: %v", ErrInvalidListQuery, err)
+	}
+	cursor := &listCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor: %v", ErrInvalidListQuery, err)
+	}
+	return cursor, nil
+}
@@ -0,0 +1,50 @@
+package orchestrator
+
+import (
+	"astron-xmod-shim/internal/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"sort"
+)
+
+// ListShimlets 汇总已配置的 shimlet 实例，以及各实例下的部署期望与已部署服务
+// 未初始化的实例不会在这里被初始化，避免查询触发集群连接
+func (o *Orchestrator) ListShimlets() []dto.ShimletInfo {
+	globalCfg := config.Get()
+	names := make([]string, 0, len(globalCfg.Shimlets)+1)
+	for name := range globalCfg.Shimlets {
+		names = append(names, name)
+	}
+	if _, ok := globalCfg.Shimlets[globalCfg.CurrentShimlet]; !ok && globalCfg.CurrentShimlet != "" {
+		names = append(names, globalCfg.CurrentShimlet)
+	}
+	sort.Strings(names)
+
+	services := make(map[string]int)
+	for _, deploySpec := range o.specStore.List() {
+		services[deploySpec.ShimletName]++
+	}
+
+	infos := make([]dto.ShimletInfo, 0, len(names))
+	for _, name := range names {
+		info := dto.ShimletInfo{
+			Name:             name,
+			Type:             o.shimReg.TypeOf(name),
+			Current:          name == globalCfg.CurrentShimlet,
+			Initialized:      o.shimReg.Initialized(name),
+			Services:         services[name],
+			DeployedServices: []string{},
+		}
+		if info.Initialized {
+			if runtimeShimlet, err := o.shimReg.GetSingleton(name); err == nil {
+				info.Description = runtimeShimlet.Description()
+				if deployed, err := runtimeShimlet.ListDeployedServices(); err != nil {
+					info.Error = err.Error()
+				} else {
+					info.DeployedServices = deployed
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
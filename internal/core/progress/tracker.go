@@ -0,0 +1,281 @@
+package progress
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"sync"
+	"time"
+)
+
+// Tracker 记录每个服务的收敛进度，供 reconciler 判断重试/超时，供 API 查询
+type Tracker struct {
+	mu        sync.RWMutex
+	items     map[string]*dto.ServiceProgress
+	rollbacks map[string]string // 等待 reconciler 执行的回滚请求及原因
+}
+
+// NewTracker 创建进度记录器
+func NewTracker() *Tracker {
+	return &Tracker{
+		items:     make(map[string]*dto.ServiceProgress),
+		rollbacks: make(map[string]string),
+	}
+}
+
+// Reset 开始新一轮收敛（新提交、更新或手动重试），清除失败终态并重新计时
+func (t *Tracker) Reset(serviceID, goalSetName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item := &dto.ServiceProgress{
+		ServiceID:      serviceID,
+		GoalSetName:    goalSetName,
+		FirstSubmitted: time.Now(),
+	}
+	// 部署阶段反映运行时状态，不随新一轮收敛清除
+	if prev, ok := t.items[serviceID]; ok {
+		item.Phase = prev.Phase
+	}
+	t.items[serviceID] = item
+}
+
+// Resume 进程重启后按部署期望中持久化的起始时间与重试次数恢复本轮收敛，
+// 已有该目标集合的进度记录或未持久化时不做处理
+func (t *Tracker) Resume(serviceID, goalSetName string, firstSubmitted time.Time, retries int) {
+	if firstSubmitted.IsZero() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item, ok := t.items[serviceID]
+	if ok && item.GoalSetName == goalSetName {
+		return
+	}
+	next := &dto.ServiceProgress{
+		ServiceID:      serviceID,
+		GoalSetName:    goalSetName,
+		FirstSubmitted: firstSubmitted,
+		Retries:        retries,
+	}
+	if ok {
+		next.Phase = item.Phase
+	}
+	t.items[serviceID] = next
+}
+
+// getOrInitLocked 获取进度记录，不存在时（如重启后重新投递）以当前时间作为起点
+func (t *Tracker) getOrInitLocked(serviceID, goalSetName string) *dto.ServiceProgress {
+	item, ok := t.items[serviceID]
+	if !ok || item.GoalSetName != goalSetName {
+		next := &dto.ServiceProgress{
+			ServiceID:      serviceID,
+			GoalSetName:    goalSetName,
+			FirstSubmitted: time.Now(),
+		}
+		if ok {
+			next.Phase = item.Phase
+		}
+		item = next
+		t.items[serviceID] = item
+	}
+	return item
+}
+
+// RecordAttempt 记录一次未收敛的 reconcile
+// countRetry 为 true 表示 Ensure 执行失败，计入重试次数；目标仍在推进中则只记录错误
+func (t *Tracker) RecordAttempt(serviceID, goalSetName string, err error, countRetry bool) dto.ServiceProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item := t.getOrInitLocked(serviceID, goalSetName)
+	item.LastAttempt = time.Now()
+	if err != nil {
+		item.LastError = err.Error()
+	}
+	if countRetry {
+		item.Retries++
+	}
+	return *item
+}
+
+// RecordSuccess 记录收敛成功，下一次失败将开启新一轮重试与超时计算
+func (t *Tracker) RecordSuccess(serviceID, goalSetName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	item := t.getOrInitLocked(serviceID, goalSetName)
+	item.Retries = 0
+	item.FirstSubmitted = now
+	item.LastAttempt = now
+	item.LastError = ""
+}
+
+// BeginRound 在一次 reconcile 开始时登记目标及其依赖，保留已有目标的执行记录
+func (t *Tracker) BeginRound(serviceID, goalSetName string, graph []dto.GoalNode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item := t.getOrInitLocked(serviceID, goalSetName)
+
+	existing := make(map[string]dto.GoalProgress, len(item.Goals))
+	for _, g := range item.Goals {
+		existing[g.Name] = g
+	}
+	goals := make([]dto.GoalProgress, 0, len(graph))
+	for _, node := range graph {
+		g, ok := existing[node.Name]
+		if !ok {
+			g = dto.GoalProgress{Name: node.Name}
+		}
+		g.DependsOn = node.DependsOn
+		g.Running = false
+		goals = append(goals, g)
+	}
+	item.Goals = goals
+}
+
+// RecordGoalCheck 记录目标 IsAchieved 的结果，返回目标是否由未达成变为达成
+func (t *Tracker) RecordGoalCheck(serviceID, goalSetName, goalName string, achieved bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	g := t.goalLocked(serviceID, goalSetName, goalName)
+	now := time.Now()
+	if g.FirstChecked.IsZero() {
+		g.FirstChecked = now
+	}
+	g.LastChecked = now
+	completed := achieved && !g.Achieved
+	if completed {
+		g.AchievedAt = now
+	}
+	if !achieved {
+		g.AchievedAt = time.Time{}
+	}
+	g.Achieved = achieved
+	return completed
+}
+
+// SetPhase 记录观测到的部署阶段，返回之前的阶段以及是否发生变化
+func (t *Tracker) SetPhase(serviceID, goalSetName string, phase dto.DeployPhase) (dto.DeployPhase, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item := t.getOrInitLocked(serviceID, goalSetName)
+	prev := item.Phase
+	item.Phase = phase
+	return prev, prev != phase
+}
+
+// RecordGoalRunning 记录目标开始执行 Ensure
+func (t *Tracker) RecordGoalRunning(serviceID, goalSetName, goalName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.goalLocked(serviceID, goalSetName, goalName).Running = true
+}
+
+// RecordGoalEnsure 记录目标 Ensure 的执行结果
+func (t *Tracker) RecordGoalEnsure(serviceID, goalSetName, goalName string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	g := t.goalLocked(serviceID, goalSetName, goalName)
+	g.Running = false
+	g.Attempts++
+	g.LastEnsured = time.Now()
+	g.LastError = ""
+	if err != nil {
+		g.LastError = err.Error()
+	}
+}
+
+// RecordGoalDetail 记录目标 Ensure 产生的执行结果
+func (t *Tracker) RecordGoalDetail(serviceID, goalSetName, goalName string, detail any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.goalLocked(serviceID, goalSetName, goalName).Detail = detail
+}
+
+// goalLocked 返回目标的执行记录，不存在时追加到末尾
+func (t *Tracker) goalLocked(serviceID, goalSetName, goalName string) *dto.GoalProgress {
+	item := t.getOrInitLocked(serviceID, goalSetName)
+	for i := range item.Goals {
+		if item.Goals[i].Name == goalName {
+			return &item.Goals[i]
+		}
+	}
+	item.Goals = append(item.Goals, dto.GoalProgress{Name: goalName})
+	return &item.Goals[len(item.Goals)-1]
+}
+
+// MarkFailed 标记服务进入失败终态
+func (t *Tracker) MarkFailed(serviceID, goalSetName, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	item := t.getOrInitLocked(serviceID, goalSetName)
+	item.Failed = true
+	item.FailedAt = time.Now()
+	if reason != "" {
+		item.LastError = reason
+	}
+}
+
+// RequestRollback 登记回滚请求，由 reconciler 处理该服务时执行
+func (t *Tracker) RequestRollback(serviceID, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollbacks[serviceID] = reason
+}
+
+// TakeRollback 取出服务待执行的回滚请求
+func (t *Tracker) TakeRollback(serviceID string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reason, ok := t.rollbacks[serviceID]
+	delete(t.rollbacks, serviceID)
+	return reason, ok
+}
+
+// RecordRollback 记录本轮收敛由回滚发起，需在 Reset 之后调用
+func (t *Tracker) RecordRollback(serviceID, goalSetName, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.getOrInitLocked(serviceID, goalSetName).RollbackReason = reason
+}
+
+// IsFailed 判断服务是否处于失败终态
+func (t *Tracker) IsFailed(serviceID string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	item, ok := t.items[serviceID]
+	return ok && item.Failed
+}
+
+// Get 返回服务进度的快照
+func (t *Tracker) Get(serviceID string) (dto.ServiceProgress, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	item, ok := t.items[serviceID]
+	if !ok {
+		return dto.ServiceProgress{}, false
+	}
+	snapshot := *item
+	snapshot.Goals = append([]dto.GoalProgress(nil), item.Goals...)
+	return snapshot, true
+}
+
+// PhaseCounts 按部署阶段统计服务数量，尚未观测到阶段的服务计入 unknown
+func (t *Tracker) PhaseCounts() map[dto.DeployPhase]int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	counts := make(map[dto.DeployPhase]int)
+	for _, item := range t.items {
+		phase := item.Phase
+		if phase == "" {
+			phase = dto.PhaseUnknown
+		}
+		counts[phase]++
+	}
+	return counts
+}
+
+// Delete 删除服务进度
+func (t *Tracker) Delete(serviceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.items, serviceID)
+	delete(t.rollbacks, serviceID)
+}
@@ -0,0 +1,112 @@
+package progress
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试按目标记录检查/执行结果，并推导当前目标与已完成目标
+func TestTracker_GoalProgress(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Reset("svc", "deploy")
+	graph := []dto.GoalNode{
+		{Name: "map-model-path"},
+		{Name: "apply", DependsOn: []string{"map-model-path"}},
+		{Name: "ready", DependsOn: []string{"apply"}},
+	}
+	tracker.BeginRound("svc", "deploy", graph)
+
+	tracker.RecordGoalCheck("svc", "deploy", "map-model-path", true)
+	tracker.RecordGoalCheck("svc", "deploy", "apply", false)
+	tracker.RecordGoalEnsure("svc", "deploy", "apply", errors.New("quota exceeded"))
+
+	item, ok := tracker.Get("svc")
+	require.True(t, ok)
+	require.Len(t, item.Goals, 3)
+	assert.Equal(t, "apply", item.CurrentGoal())
+	assert.Equal(t, []string{"map-model-path"}, item.CompletedGoals())
+	assert.Equal(t, 1, item.Goals[1].Attempts)
+	assert.Equal(t, "quota exceeded", item.Goals[1].LastError)
+	assert.False(t, item.Goals[0].AchievedAt.IsZero())
+
+	// 新一轮保留已有记录，收敛成功后记录仍可查询
+	tracker.BeginRound("svc", "deploy", graph)
+	tracker.RecordGoalEnsure("svc", "deploy", "apply", nil)
+	tracker.RecordGoalCheck("svc", "deploy", "apply", true)
+	tracker.RecordGoalCheck("svc", "deploy", "ready", true)
+	tracker.RecordSuccess("svc", "deploy")
+
+	item, _ = tracker.Get("svc")
+	assert.Empty(t, item.CurrentGoal())
+	assert.Len(t, item.CompletedGoals(), 3)
+	assert.Equal(t, 2, item.Goals[1].Attempts)
+	assert.Empty(t, item.Goals[1].LastError)
+
+	// 目标执行结果随进度一起返回
+	tracker.RecordGoalDetail("svc", "deploy", "ready", map[string]int{"latencyMs": 12})
+	item, _ = tracker.Get("svc")
+	assert.Equal(t, map[string]int{"latencyMs": 12}, item.Goals[2].Detail)
+
+	// 重新提交清空目标记录
+	tracker.Reset("svc", "deploy")
+	item, _ = tracker.Get("svc")
+	assert.Empty(t, item.Goals)
+}
+
+// 测试依赖图中的当前目标：依赖已达成而自身未达成的目标，记录正在执行的目标
+func TestTracker_ActiveGoals(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Reset("svc", "dag")
+	tracker.BeginRound("svc", "dag", []dto.GoalNode{
+		{Name: "map-model-path"},
+		{Name: "pull-image"},
+		{Name: "validate-model", DependsOn: []string{"map-model-path"}},
+		{Name: "apply", DependsOn: []string{"pull-image", "validate-model"}},
+	})
+	item, _ := tracker.Get("svc")
+	assert.Equal(t, []string{"map-model-path", "pull-image"}, item.ActiveGoals())
+
+	tracker.RecordGoalCheck("svc", "dag", "map-model-path", true)
+	tracker.RecordGoalCheck("svc", "dag", "pull-image", false)
+	tracker.RecordGoalRunning("svc", "dag", "pull-image")
+	// apply 上一轮已达成，但依赖未全部达成，不视为完成
+	tracker.RecordGoalCheck("svc", "dag", "apply", true)
+	item, _ = tracker.Get("svc")
+	assert.Equal(t, []string{"pull-image", "validate-model"}, item.ActiveGoals())
+	assert.Equal(t, "pull-image", item.CurrentGoal())
+	assert.True(t, item.Goals[1].Running)
+	assert.Equal(t, []string{"pull-image", "validate-model"}, item.Goals[3].DependsOn)
+
+	tracker.RecordGoalEnsure("svc", "dag", "pull-image", nil)
+	tracker.RecordGoalCheck("svc", "dag", "pull-image", true)
+	tracker.RecordGoalCheck("svc", "dag", "validate-model", true)
+	item, _ = tracker.Get("svc")
+	assert.False(t, item.Goals[1].Running)
+	assert.Empty(t, item.ActiveGoals())
+}
+
+// 测试回滚请求只被取出一次，回滚原因在重新提交后清除
+func TestTracker_Rollback(t *testing.T) {
+	tracker := NewTracker()
+	_, ok := tracker.TakeRollback("svc")
+	assert.False(t, ok)
+
+	tracker.RequestRollback("svc", "requested")
+	reason, ok := tracker.TakeRollback("svc")
+	assert.True(t, ok)
+	assert.Equal(t, "requested", reason)
+	_, ok = tracker.TakeRollback("svc")
+	assert.False(t, ok)
+
+	tracker.Reset("svc", "deploy")
+	tracker.RecordRollback("svc", "deploy", "exceeded timeout (1h0m0s)")
+	item, _ := tracker.Get("svc")
+	assert.Equal(t, "exceeded timeout (1h0m0s)", item.RollbackReason)
+	tracker.Reset("svc", "deploy")
+	item, _ = tracker.Get("svc")
+	assert.Empty(t, item.RollbackReason)
+}
@@ -0,0 +1,612 @@
+package reconciler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/eventbus"
+	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/goal/goalset"
+	"astron-xmod-shim/internal/core/orchestrator"
+	"astron-xmod-shim/internal/core/progress"
+	"astron-xmod-shim/internal/core/shimlet"
+	"astron-xmod-shim/internal/core/shimlet/shimlets"
+	"astron-xmod-shim/internal/core/spec"
+	"astron-xmod-shim/internal/core/workqueue"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 端到端测试使用的全局配置：sim shimlet 及其具名实例 sim-b + 内存 spec store；
+// sim 的 endpoint 无法访问，关闭冒烟测试
+const e2eConfig = `
+current-shimlet: "sim"
+shimlets:
+  sim:
+    config-path: ""
+  sim-b:
+    type: "sim"
+    config-path: ""
+smoke-test:
+  enabled: false
+`
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+
+	dir, err := os.MkdirTemp("", "xmod-e2e")
+	if err != nil {
+		panic(err)
+	}
+	confPath := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(confPath, []byte(e2eConfig), 0o644); err != nil {
+		panic(err)
+	}
+	config.SetConfigPath(confPath)
+	quota, err := loadDeclarativeGoalSet(dir)
+	if err != nil {
+		panic(err)
+	}
+	registerRollbackGoalSet("e2e-rollback", 300*time.Millisecond)
+	registerRollbackGoalSet("e2e-rollback-manual", time.Minute)
+
+	// 缩短重新投递间隔，使重试与漂移检测在测试时长内完成
+	retryInterval = 20 * time.Millisecond
+	resyncInterval = 50 * time.Millisecond
+
+	code := m.Run()
+	quota.Close()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// e2eEnv 组装 orchestrator、reconciler 与 sim shimlet
+type e2eEnv struct {
+	orch    *orchestrator.Orchestrator
+	store   spec.Store
+	tracker *progress.Tracker
+	queue   *workqueue.Queue
+	sim     *shimlets.SimShimlet
+
+	mu     sync.Mutex
+	events []event.ServiceEvent
+}
+
+func newE2EEnv(t *testing.T) *e2eEnv {
+	infraShim, err := shimlet.Registry.GetSingleton("sim")
+	require.NoError(t, err)
+	sim, ok := shimlet.Unwrap(infraShim).(*shimlets.SimShimlet)
+	require.True(t, ok)
+
+	env := &e2eEnv{tracker: progress.NewTracker(), sim: sim}
+	bus := eventbus.New()
+	t.Cleanup(bus.Close)
+	_, err = bus.Subscribe("e2e", func(ev event.ServiceEvent) {
+		env.mu.Lock()
+		defer env.mu.Unlock()
+		env.events = append(env.events, ev)
+	}, eventbus.SubscribeOptions{BufferSize: 1024})
+	require.NoError(t, err)
+
+	env.store = spec.NewMemoryStore()
+	env.queue = workqueue.New()
+	env.orch = orchestrator.NewOrchestrator(shimlet.Registry, goal.Registry, env.queue, env.store, env.tracker, bus)
+	NewReconciler(env.store, 2, env.queue, env.tracker, bus).Start()
+	return env
+}
+
+// provision 按 API 层的方式提交部署
+func (e *e2eEnv) provision(t *testing.T, serviceID, goalSetName string) {
+	require.NoError(t, e.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            serviceID,
+		ModelName:            "qwen",
+		ModelFileDir:         "/models/qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          goalSetName,
+	}))
+}
+
+func (e *e2eEnv) waitPhase(t *testing.T, serviceID string, phase dto.DeployPhase) {
+	require.Eventually(t, func() bool {
+		item, ok := e.tracker.Get(serviceID)
+		return ok && item.Phase == phase
+	}, 5*time.Second, 10*time.Millisecond, "service %s never reached phase %s", serviceID, phase)
+}
+
+func (e *e2eEnv) phaseChanges(serviceID string) []dto.DeployPhase {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var phases []dto.DeployPhase
+	for _, ev := range e.events {
+		if ev.ServiceID == serviceID && ev.Type == event.EventPhaseChanged {
+			phases = append(phases, ev.To)
+		}
+	}
+	return phases
+}
+
+// 测试部署、重试与删除的完整流程
+func TestE2E_DeployAndDelete(t *testing.T) {
+	env := newE2EEnv(t)
+
+	// 前两次 Apply 失败，reconciler 重试后收敛
+	env.sim.FailNext(shimlets.SimOpApply, 2)
+	env.provision(t, "e2e-deploy", "opensource-llm-deploy")
+	env.waitPhase(t, "e2e-deploy", dto.PhaseRunning)
+
+	item, _ := env.tracker.Get("e2e-deploy")
+	assert.Empty(t, item.CurrentGoal())
+	assert.Equal(t, 1, env.sim.ApplyCount("e2e-deploy"))
+	status, err := env.orch.GetServiceStatus("e2e-deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "http://e2e-deploy.sim.local:8000/v1/chat/completions", status.EndPoint)
+
+	require.NoError(t, env.orch.SubmitDelete("e2e-deploy"))
+	// 下线收敛后部署期望与进度被删除
+	require.Eventually(t, func() bool {
+		_, tracked := env.tracker.Get("e2e-deploy")
+		return env.store.Get("e2e-deploy") == nil && !tracked
+	}, 5*time.Second, 10*time.Millisecond, "deleted service was not released")
+	ids, err := env.sim.ListDeployedServices()
+	require.NoError(t, err)
+	assert.NotContains(t, ids, "e2e-deploy")
+
+	// Apply 失败期间服务尚不存在，阶段在 pending/unknown 间变化，最终经 running 下线；事件异步投递
+	var phases []dto.DeployPhase
+	require.Eventually(t, func() bool {
+		phases = env.phaseChanges("e2e-deploy")
+		return len(phases) > 0 && phases[len(phases)-1] == dto.PhaseUnknown
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, dto.PhasePending, phases[0])
+	assert.Contains(t, phases, dto.PhaseRunning)
+}
+
+// 测试下线事件携带请求级回调地址：部署期望释放后通知仍能投递
+func TestE2E_DeleteEventsCarryCallback(t *testing.T) {
+	env := newE2EEnv(t)
+	require.NoError(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-callback",
+		ModelName:            "qwen",
+		ModelFileDir:         "/models/qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		CallbackURL:          "http://callback.local/hook",
+	}))
+	env.waitPhase(t, "e2e-callback", dto.PhaseRunning)
+	// 删除沿用部署时的回调地址
+	require.NoError(t, env.orch.SubmitDelete("e2e-callback"))
+
+	require.Eventually(t, func() bool {
+		env.mu.Lock()
+		defer env.mu.Unlock()
+		for _, ev := range env.events {
+			if ev.ServiceID == "e2e-callback" && ev.Type == event.EventPhaseChanged && ev.To == dto.PhaseUnknown {
+				return ev.CallbackURL == "http://callback.local/hook"
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// 测试收敛进度持久化在部署期望中：失败的重试次数写回存储，重启后接续计算而不是重新获得完整的重试次数
+func TestE2E_ConvergenceSurvivesRestart(t *testing.T) {
+	env := newE2EEnv(t)
+	env.sim.FailNext(shimlets.SimOpApply, 1)
+	env.provision(t, "e2e-persist", "opensource-llm-deploy")
+	require.Eventually(t, func() bool {
+		deploySpec := env.store.Get("e2e-persist")
+		return deploySpec != nil && deploySpec.ConvergeRetries == 1 && !deploySpec.ConvergeStartTime.IsZero()
+	}, 5*time.Second, 10*time.Millisecond, "convergence progress was not persisted")
+	env.waitPhase(t, "e2e-persist", dto.PhaseRunning)
+	// 收敛成功后清除
+	require.Eventually(t, func() bool {
+		deploySpec := env.store.Get("e2e-persist")
+		return deploySpec.ConvergeRetries == 0 && deploySpec.ConvergeStartTime.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 模拟重启：新的进程只有持久化的部署期望，重试次数已用完
+	restarted := newE2EEnv(t)
+	maxRetries := goal.Registry["opensource-llm-deploy"].MaxRetries
+	restarted.sim.FailNext(shimlets.SimOpApply, 1)
+	require.NoError(t, restarted.store.Set("e2e-restart", &dto.RequirementSpec{
+		ServiceId:            "e2e-restart",
+		ModelName:            "qwen",
+		ModelFileDir:         "/models/qwen",
+		ReplicaCount:         1,
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          "opensource-llm-deploy",
+		ShimletName:          "sim",
+		UpdateTime:           time.Now(),
+		ConvergeStartTime:    time.Now().Add(-time.Minute),
+		ConvergeRetries:      maxRetries,
+	}))
+	restarted.queue.Add("e2e-restart")
+	restarted.waitPhase(t, "e2e-restart", dto.PhaseFailed)
+	item, _ := restarted.tracker.Get("e2e-restart")
+	assert.Contains(t, item.LastError, "exceeded max retries")
+	assert.Equal(t, maxRetries+1, restarted.store.Get("e2e-restart").ConvergeRetries)
+}
+
+// 测试运行中的服务发生漂移后被 reconciler 重新拉起
+func TestE2E_DriftRepaired(t *testing.T) {
+	env := newE2EEnv(t)
+	env.provision(t, "e2e-drift", "opensource-llm-deploy")
+	env.waitPhase(t, "e2e-drift", dto.PhaseRunning)
+	require.Equal(t, 1, env.sim.ApplyCount("e2e-drift"))
+
+	// 副本数漂移，spec 一致性检查触发重新 Apply
+	require.NoError(t, env.sim.InjectDrift("e2e-drift", shimlets.SimDriftSpec))
+	require.Eventually(t, func() bool { return env.sim.ApplyCount("e2e-drift") == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// 服务消失，部署目标重新 Apply
+	require.NoError(t, env.sim.InjectDrift("e2e-drift", shimlets.SimDriftMissing))
+	require.Eventually(t, func() bool { return env.sim.ApplyCount("e2e-drift") == 1 }, 5*time.Second, 10*time.Millisecond)
+	env.waitPhase(t, "e2e-drift", dto.PhaseRunning)
+}
+
+// 测试目标修改的是本轮的部署期望拷贝，修改在本轮结束后写回存储
+func TestE2E_GoalSpecChangesWrittenBack(t *testing.T) {
+	env := newE2EEnv(t)
+	submitted := &dto.RequirementSpec{
+		ServiceId:            "e2e-write-back",
+		ModelName:            "qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          "opensource-llm-deploy",
+	}
+	require.NoError(t, env.orch.Provision(submitted))
+	env.waitPhase(t, "e2e-write-back", dto.PhaseRunning)
+
+	// 模型路径由 map-model-path 目标填充，提交的部署期望对象不被修改
+	assert.Empty(t, submitted.ModelFileDir)
+	stored := env.store.Get("e2e-write-back")
+	require.NotNil(t, stored)
+	assert.Equal(t, "/models/qwen", stored.ModelFileDir)
+	assert.True(t, stored.UpdateTime.Equal(submitted.UpdateTime))
+}
+
+// 测试只修改 K8s 覆盖项的更新同样会被重新应用
+func TestE2E_K8sOverrideUpdateReapplied(t *testing.T) {
+	env := newE2EEnv(t)
+	deploySpec := func(namespace string) *dto.RequirementSpec {
+		return &dto.RequirementSpec{
+			ServiceId:            "e2e-k8s-update",
+			ModelName:            "qwen",
+			ModelFileDir:         "/models/qwen",
+			ResourceRequirements: &dto.ResourceRequirements{},
+			GoalSetName:          "opensource-llm-deploy",
+			K8s:                  &dto.K8sOptions{Namespace: namespace, NodeSelector: map[string]string{"gpu": "a100"}},
+		}
+	}
+	require.NoError(t, env.orch.Provision(deploySpec("team-a")))
+	env.waitPhase(t, "e2e-k8s-update", dto.PhaseRunning)
+	require.Equal(t, 1, env.sim.ApplyCount("e2e-k8s-update"))
+
+	require.NoError(t, env.orch.Provision(deploySpec("team-b")))
+	require.Eventually(t, func() bool { return env.sim.ApplyCount("e2e-k8s-update") == 2 }, 5*time.Second, 10*time.Millisecond)
+	status, err := env.sim.Status("e2e-k8s-update")
+	require.NoError(t, err)
+	assert.Equal(t, "team-b", status.DeploySpec.K8s.Namespace)
+
+	// 一致后不再重复应用
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 2, env.sim.ApplyCount("e2e-k8s-update"))
+}
+
+// 测试服务按 ShimletName 路由到各自的 shimlet
+func TestE2E_RouteByShimletName(t *testing.T) {
+	infraShim, err := shimlet.Registry.GetSingleton("sim-b")
+	require.NoError(t, err)
+	simB := shimlet.Unwrap(infraShim).(*shimlets.SimShimlet)
+
+	env := newE2EEnv(t)
+	require.NoError(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-route",
+		ModelName:            "qwen",
+		ModelFileDir:         "/models/qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          "opensource-llm-deploy",
+		ShimletName:          "sim-b",
+	}))
+	env.waitPhase(t, "e2e-route", dto.PhaseRunning)
+	assert.Equal(t, 1, simB.ApplyCount("e2e-route"))
+	assert.Equal(t, 0, env.sim.ApplyCount("e2e-route"))
+	assert.Equal(t, "sim-b", env.orch.ShimletOf("e2e-route"))
+
+	// 未指定时沿用原 shimlet，不能迁移到其他 shimlet
+	update := &dto.RequirementSpec{ServiceId: "e2e-route", ModelName: "qwen", ResourceRequirements: &dto.ResourceRequirements{}, GoalSetName: "opensource-llm-deploy"}
+	require.NoError(t, env.orch.Provision(update))
+	assert.Equal(t, "sim-b", update.ShimletName)
+	assert.ErrorIs(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-route",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		ShimletName:          "sim",
+	}), orchestrator.ErrShimletChanged)
+
+	// 状态查询与删除都经由所属的 shimlet；直接删除同时删除部署期望，不会被重新部署
+	status, err := env.orch.GetServiceStatus("e2e-route")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	require.NoError(t, env.orch.DeleteService("e2e-route"))
+	assert.Equal(t, 0, simB.ApplyCount("e2e-route"))
+	assert.Nil(t, env.store.Get("e2e-route"))
+
+	assert.ErrorIs(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-unknown",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		ShimletName:          "no-such-shimlet",
+	}), orchestrator.ErrShimletNotRegistered)
+
+	assert.ErrorIs(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-unknown",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		Engine:               "no-such-engine",
+	}), orchestrator.ErrEngineNotRegistered)
+
+	assert.ErrorIs(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-unknown",
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorCount: 1},
+		Parallelism:          &dto.ParallelismSpec{TensorParallelSize: 2},
+	}), orchestrator.ErrInvalidParallelism)
+
+	assert.ErrorIs(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-unknown",
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          "no-such-goal-set",
+	}), orchestrator.ErrGoalSetNotRegistered)
+
+	assert.Equal(t, []string{"sim", "sim-b"}, env.orch.ShimletsInUse("sim"))
+}
+
+// 测试同一类型的多个具名实例互相独立，并可汇总查询
+func TestE2E_NamedInstances(t *testing.T) {
+	env := newE2EEnv(t)
+	defaultShim, err := shimlet.Registry.GetSingleton("sim")
+	require.NoError(t, err)
+	namedShim, err := shimlet.Registry.GetSingleton("sim-b")
+	require.NoError(t, err)
+
+	assert.Equal(t, "sim-b", namedShim.ID())
+	assert.NotSame(t, shimlet.Unwrap(defaultShim), shimlet.Unwrap(namedShim))
+	assert.True(t, shimlet.Registry.Has("sim-b"))
+	assert.False(t, shimlet.Registry.Has("sim-c"))
+	assert.Equal(t, "sim", shimlet.Registry.TypeOf("sim-b"))
+
+	require.NoError(t, namedShim.Apply(&dto.RequirementSpec{ServiceId: "e2e-named"}))
+	infos := env.orch.ListShimlets()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "sim", infos[0].Name)
+	assert.True(t, infos[0].Current)
+	assert.Equal(t, "sim-b", infos[1].Name)
+	assert.Equal(t, "sim", infos[1].Type)
+	assert.True(t, infos[1].Initialized)
+	assert.Contains(t, infos[1].DeployedServices, "e2e-named")
+	assert.NotContains(t, infos[0].DeployedServices, "e2e-named")
+}
+
+// 声明式目标集合的端到端测试依赖：配额检查服务与通知钩子的输出文件。
+// 其他测试启动的 reconciler 会持续读取 goal.Registry，目标集合需在 TestMain 中注册
+var (
+	quotaChecks  = make(chan string, 16)
+	notifiedPath string
+)
+
+const declarativeGoalSet = `
+name: e2e-declarative
+goals:
+  - type: builtin
+    params:
+      goal: map-model-path
+  - name: quota-check
+    type: http-check
+    params:
+      url: "%s/check?model={{model_name}}"
+  - name: apply
+    type: shimlet-apply
+  - name: wait-running
+    type: wait-for-phase
+  - name: notify
+    type: exec-hook
+    params:
+      command: ["/bin/sh", "-c", "echo $XMOD_SERVICE_ID > %s"]
+`
+
+// loadDeclarativeGoalSet 启动配额检查服务并注册声明式目标集合
+func loadDeclarativeGoalSet(dir string) (*httptest.Server, error) {
+	quota := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quotaChecks <- r.URL.Query().Get("model")
+	}))
+	notifiedPath = filepath.Join(dir, "notified")
+	goalSetDir := filepath.Join(dir, "goalsets")
+	if err := os.Mkdir(goalSetDir, 0o755); err != nil {
+		return quota, err
+	}
+	definition := fmt.Sprintf(declarativeGoalSet, quota.URL, notifiedPath)
+	if err := os.WriteFile(filepath.Join(goalSetDir, "e2e.yaml"), []byte(definition), 0o644); err != nil {
+		return quota, err
+	}
+	return quota, goalset.LoadDeclarative(goalSetDir)
+}
+
+// 测试声明式目标集合：配额检查通过后部署，等待 running 后执行通知钩子
+func TestE2E_DeclarativeGoalSet(t *testing.T) {
+	env := newE2EEnv(t)
+	infos := env.orch.ListGoalSets()
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	assert.Contains(t, names, "e2e-declarative")
+	assert.Contains(t, names, "opensource-llm-deploy")
+
+	env.provision(t, "e2e-declarative", "e2e-declarative")
+	env.waitPhase(t, "e2e-declarative", dto.PhaseRunning)
+	require.Eventually(t, func() bool {
+		item, _ := env.tracker.Get("e2e-declarative")
+		return len(item.Goals) == 5 && item.CurrentGoal() == ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "qwen", <-quotaChecks)
+	content, err := os.ReadFile(notifiedPath)
+	require.NoError(t, err)
+	assert.Equal(t, "e2e-declarative\n", string(content))
+	assert.Equal(t, 1, env.sim.ApplyCount("e2e-declarative"))
+
+	// 更新时未指定目标集合，沿用原目标集合
+	require.NoError(t, env.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            "e2e-declarative",
+		ModelName:            "qwen",
+		ResourceRequirements: &dto.ResourceRequirements{},
+	}))
+	item, _ := env.tracker.Get("e2e-declarative")
+	assert.Equal(t, "e2e-declarative", item.GoalSetName)
+}
+
+// compensated 回滚测试中被补偿的配额预占，值为 服务 ID/预占时的模型名
+var compensated = make(chan string, 10)
+
+// registerRollbackGoalSet 注册启用回滚的目标集合：模型 broken 的服务永远无法通过健康检查，
+// 预占配额的目标声明了补偿动作
+func registerRollbackGoalSet(name string, timeout time.Duration) {
+	var reserved sync.Map
+	reserveKey := func(ctx *goal.Context) string {
+		return ctx.DeploySpec.ServiceId + "/" + ctx.DeploySpec.UpdateTime.String()
+	}
+	goal.NewGoalSetBuilder(name).
+		AddGoal(goal.Goal{
+			Name: "reserve-quota",
+			IsAchieved: func(ctx *goal.Context) bool {
+				_, ok := reserved.Load(reserveKey(ctx))
+				return ok
+			},
+			Ensure: func(ctx *goal.Context) error {
+				reserved.Store(reserveKey(ctx), true)
+				return nil
+			},
+			Compensate: func(ctx *goal.Context) error {
+				reserved.Delete(reserveKey(ctx))
+				compensated <- ctx.DeploySpec.ServiceId + "/" + ctx.DeploySpec.ModelName
+				return nil
+			},
+		}).
+		AddGoal(goal.Goal{
+			Name: "apply",
+			IsAchieved: func(ctx *goal.Context) bool {
+				status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+				return err == nil && status.Status != dto.PhaseUnknown && status.DeploySpec.ModelName == ctx.DeploySpec.ModelName
+			},
+			Ensure: func(ctx *goal.Context) error { return ctx.Shimlet.Apply(ctx.DeploySpec) },
+		}).
+		AddGoal(goal.Goal{
+			Name: "healthy",
+			IsAchieved: func(ctx *goal.Context) bool {
+				status, err := ctx.Shimlet.Status(ctx.DeploySpec.ServiceId)
+				return err == nil && status.Status == dto.PhaseRunning && ctx.DeploySpec.ModelName != "broken"
+			},
+			Ensure: func(*goal.Context) error { return nil },
+		}).
+		WithMaxRetries(3).
+		WithTimeout(timeout).
+		WithRollback().
+		BuildAndRegister()
+}
+
+// provisionModel 以指定模型提交回滚测试，服务使用与服务 ID 同名的目标集合
+func (e *e2eEnv) provisionModel(t *testing.T, serviceID, modelName string) {
+	require.NoError(t, e.orch.Provision(&dto.RequirementSpec{
+		ServiceId:            serviceID,
+		ModelName:            modelName,
+		ModelFileDir:         "/models/" + modelName,
+		ResourceRequirements: &dto.ResourceRequirements{},
+		GoalSetName:          serviceID,
+	}))
+}
+
+// waitKnownGood 等待服务的部署期望被记录为最近一次正常版本
+func (e *e2eEnv) waitKnownGood(t *testing.T, serviceID, modelName string) *dto.RequirementSpec {
+	var current *dto.RequirementSpec
+	require.Eventually(t, func() bool {
+		current = e.store.Get(serviceID)
+		return current != nil && current.ModelName == modelName && current.LastKnownGood != nil &&
+			current.RollbackTarget() == nil
+	}, 5*time.Second, 10*time.Millisecond, "service %s never recorded %s as known-good", serviceID, modelName)
+	return current
+}
+
+// 测试更新超时后补偿并自动回滚到最近一次正常的部署期望
+func TestE2E_RollbackOnTimeout(t *testing.T) {
+	env := newE2EEnv(t)
+	env.provisionModel(t, "e2e-rollback", "qwen")
+	env.waitKnownGood(t, "e2e-rollback", "qwen")
+
+	env.provisionModel(t, "e2e-rollback", "broken")
+	updated := env.store.Get("e2e-rollback")
+	require.NotNil(t, updated.RollbackTarget())
+	assert.Equal(t, "qwen", updated.RollbackTarget().ModelName)
+
+	// 超时后补偿更新中预占的配额，回滚到 qwen 并重新收敛
+	select {
+	case model := <-compensated:
+		assert.Equal(t, "e2e-rollback/broken", model)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reserve-quota was never compensated")
+	}
+	current := env.waitKnownGood(t, "e2e-rollback", "qwen")
+	assert.True(t, current.RolledBack)
+	status, err := env.sim.Status("e2e-rollback")
+	require.NoError(t, err)
+	assert.Equal(t, "qwen", status.DeploySpec.ModelName)
+	item, _ := env.tracker.Get("e2e-rollback")
+	assert.Contains(t, item.RollbackReason, "exceeded timeout")
+	assert.False(t, item.Failed)
+
+	// 事件异步投递
+	assert.Eventually(t, func() bool {
+		env.mu.Lock()
+		defer env.mu.Unlock()
+		for _, ev := range env.events {
+			if ev.ServiceID == "e2e-rollback" && ev.Type == event.EventRolledBack {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// 测试通过 API 手动回滚，以及没有正常版本时拒绝回滚
+func TestE2E_RollbackOnDemand(t *testing.T) {
+	env := newE2EEnv(t)
+	assert.ErrorIs(t, env.orch.RollbackService("e2e-missing"), orchestrator.ErrServiceNotFound)
+
+	env.provisionModel(t, "e2e-rollback-manual", "qwen")
+	env.waitKnownGood(t, "e2e-rollback-manual", "qwen")
+	// 当前即为最近一次正常版本
+	assert.ErrorIs(t, env.orch.RollbackService("e2e-rollback-manual"), orchestrator.ErrNoRollbackTarget)
+
+	env.provisionModel(t, "e2e-rollback-manual", "broken")
+	require.Eventually(t, func() bool {
+		item, _ := env.tracker.Get("e2e-rollback-manual")
+		return len(item.CompletedGoals()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, env.orch.RollbackService("e2e-rollback-manual"))
+	assert.Equal(t, "e2e-rollback-manual/broken", <-compensated)
+	current := env.waitKnownGood(t, "e2e-rollback-manual", "qwen")
+	assert.True(t, current.RolledBack)
+	item, _ := env.tracker.Get("e2e-rollback-manual")
+	assert.Equal(t, "rollback requested via API", item.RollbackReason)
+
+	// 下线后不再保留正常版本
+	require.NoError(t, env.orch.SubmitDelete("e2e-rollback-manual"))
+	assert.Nil(t, env.store.Get("e2e-rollback-manual").LastKnownGood)
+	assert.ErrorIs(t, env.orch.RollbackService("e2e-rollback-manual"), orchestrator.ErrNoRollbackTarget)
+}
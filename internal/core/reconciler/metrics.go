@@ -0,0 +1,53 @@
+package reconciler
+
+import (
+	"astron-xmod-shim/pkg/metrics"
+	"errors"
+	"time"
+)
+
+// reconcile 结果
+const (
+	resultSuccess = "success" // 全部目标达成
+	resultPending = "pending" // 目标仍在推进中
+	resultError   = "error"   // Ensure 失败，将重试
+	resultFailed  = "failed"  // 超过重试次数或超时，进入失败终态
+)
+
+var reconcileBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+var (
+	reconcileDuration = metrics.Default.NewHistogramVec("xmod_reconcile_duration_seconds",
+		"Duration of a single reconcile round per goal set and result.", reconcileBuckets, "goal_set", "result")
+	reconcileTotal = metrics.Default.NewCounterVec("xmod_reconcile_total",
+		"Total number of reconcile rounds per goal set and result.", "goal_set", "result")
+	goalEnsureDuration = metrics.Default.NewHistogramVec("xmod_goal_ensure_duration_seconds",
+		"Duration of goal Ensure calls per goal set, goal and result.", reconcileBuckets, "goal_set", "goal", "result")
+)
+
+// observeReconcile 记录一次 reconcile 的耗时与结果
+func observeReconcile(goalSetName, result string, start time.Time) {
+	reconcileDuration.WithLabelValues(goalSetName, result).Observe(time.Since(start).Seconds())
+	reconcileTotal.WithLabelValues(goalSetName, result).Inc()
+}
+
+// observeEnsure 记录一次 Ensure 的耗时与结果
+func observeEnsure(goalSetName, goalName string, err error, start time.Time) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	goalEnsureDuration.WithLabelValues(goalSetName, goalName, result).Observe(time.Since(start).Seconds())
+}
+
+// resultOf 根据 reconcile 返回的错误得到结果标签
+func resultOf(err error) string {
+	switch {
+	case err == nil:
+		return resultSuccess
+	case errors.Is(err, errGoalPending):
+		return resultPending
+	default:
+		return resultError
+	}
+}
@@ -1,20 +1,40 @@
 package reconciler
 
 import (
+	"astron-xmod-shim/internal/core/eventbus"
 	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/progress"
 	"astron-xmod-shim/internal/core/shimlet"
 	"astron-xmod-shim/internal/core/spec"
 	"astron-xmod-shim/internal/core/workqueue"
 	dto "astron-xmod-shim/internal/dto/deploy"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 )
 
+// 重新投递间隔，端到端测试中会缩短
+var (
+	// retryInterval 未收敛时的重新投递间隔
+	retryInterval = 10 * time.Second
+	// resyncInterval 收敛后的周期性检查间隔（漂移检测）
+	resyncInterval = 300 * time.Second
+)
+
+// errGoalPending 目标的 Ensure 执行成功但尚未达成（如等待资源就绪），不计入重试次数
+var errGoalPending = errors.New("goal not yet achieved")
+
 type Reconciler struct {
 	queue     *workqueue.Queue
 	specStore spec.Store // 见下文说明
+	tracker   *progress.Tracker
+	bus       *eventbus.Bus
 	workers   int
 	ctx       context.Context
 	cancel    context.CancelFunc
@@ -22,18 +42,20 @@ type Reconciler struct {
 }
 
 // NewReconciler 创建一个可运行的 reconciler
-func NewReconciler(store spec.Store, workers int, queue *workqueue.Queue) *Reconciler {
+func NewReconciler(store spec.Store, workers int, queue *workqueue.Queue, tracker *progress.Tracker, bus *eventbus.Bus) *Reconciler {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Reconciler{
 		queue:     queue,
 		specStore: store,
+		tracker:   tracker,
+		bus:       bus,
 		workers:   workers,
 		ctx:       ctx,
 		cancel:    cancel,
 	}
 }
 
-func (r *Reconciler) reconcile(spec *dto.RequirementSpec) error {
+func (r *Reconciler) reconcile(spec *dto.RequirementSpec, goalSet *goal.GoalSet) error {
 
 	// 组装 ctx
 	infraShim, err := shimlet.Registry.GetSingleton(spec.ShimletName)
@@ -41,32 +63,192 @@ func (r *Reconciler) reconcile(spec *dto.RequirementSpec) error {
 		return err
 	}
 
+	// 每轮使用部署期望的深拷贝，目标对其的修改（如模型路径）在本轮结束后写回存储，
+	// 不直接修改存储返回的共享对象
 	goalSetCtx := &goal.Context{
 		Data:       make(map[string]any),
-		DeploySpec: spec,
+		DeploySpec: spec.DeepCopy(),
 		Shimlet:    infraShim,
 	}
+	defer r.writeBack(spec, goalSetCtx.DeploySpec)
 
-	// 获取 goals
-	goalSet := goal.Registry[spec.GoalSetName]
+	serviceID := spec.ServiceId
+	r.tracker.BeginRound(serviceID, goalSet.Name, goalSet.Graph())
 
-	// 这里遍历全部goal 严格按先后顺序遍历
-	for _, singleGoal := range goalSet.Goals {
-		// 如果有goal 没有达成 则调用 ensure
-		if !singleGoal.IsAchieved(goalSetCtx) {
-			err := singleGoal.Ensure(goalSetCtx)
-			if err != nil {
-				return err
-			}
+	// 依赖在本轮全部达成的目标即可执行，互不依赖的目标并发执行；
+	// 出现失败后不再启动新的目标，等待已启动的目标结束
+	index := make(map[string]int, len(goalSet.Goals))
+	for i, g := range goalSet.Goals {
+		index[g.Name] = i
+	}
+	waiting := make([]int, len(goalSet.Goals))      // 各目标尚未达成的依赖数
+	dependents := make([][]int, len(goalSet.Goals)) // 依赖各目标的目标
+	for i := range goalSet.Goals {
+		deps := goalSet.Dependencies(i)
+		waiting[i] = len(deps)
+		for _, dep := range deps {
+			dependents[index[dep]] = append(dependents[index[dep]], i)
 		}
-		if !singleGoal.IsAchieved(goalSetCtx) {
-			return errors.New("some goal not yet serviceId:" + goalSetCtx.DeploySpec.ServiceId)
+	}
+
+	results := make(chan goalResult, len(goalSet.Goals))
+	running := 0
+	start := func(i int) {
+		running++
+		go func() {
+			achieved, err := r.runGoal(serviceID, goalSet.Name, goalSet.Goals[i], goalSetCtx)
+			results <- goalResult{index: i, achieved: achieved, err: err}
+		}()
+	}
+	for i := range goalSet.Goals {
+		if waiting[i] == 0 {
+			start(i)
+		}
+	}
+
+	var errs []error
+	var pending []string
+	for running > 0 {
+		result := <-results
+		running--
+		switch {
+		case result.err != nil:
+			errs = append(errs, result.err)
+		case !result.achieved:
+			pending = append(pending, goalSet.Goals[result.index].Name)
+		case len(errs) == 0:
+			for _, next := range dependents[result.index] {
+				if waiting[next]--; waiting[next] == 0 {
+					start(next)
+				}
+			}
 		}
 	}
 
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s, serviceId: %s", errGoalPending, strings.Join(pending, ", "), serviceID)
+	}
 	return nil
 }
 
+// writeBack 将目标在本轮对部署期望的修改写回存储；
+// 本轮期间提交了新的部署期望时丢弃修改，由新的部署期望重新收敛
+func (r *Reconciler) writeBack(original, working *dto.RequirementSpec) {
+	if reflect.DeepEqual(original, working) {
+		return
+	}
+	current := r.specStore.Get(original.ServiceId)
+	if current == nil || !current.UpdateTime.Equal(original.UpdateTime) {
+		return
+	}
+	updated := working.DeepCopy()
+	// 回滚信息与收敛进度由 reconciler 另行维护，以存储中的为准
+	updated.LastKnownGood = current.LastKnownGood
+	updated.RolledBack = current.RolledBack
+	updated.ConvergeStartTime, updated.ConvergeRetries = current.ConvergeStartTime, current.ConvergeRetries
+	updated.ResourceVersion = current.ResourceVersion
+	if err := r.specStore.Set(original.ServiceId, updated); err != nil {
+		log.Warn("service %s write back spec changes failed: %v", original.ServiceId, err)
+	}
+}
+
+// goalResult 一个目标在本轮 reconcile 中的执行结果
+type goalResult struct {
+	index    int
+	achieved bool
+	err      error
+}
+
+// runGoal 检查目标，未达成时调用 Ensure 并再次检查
+func (r *Reconciler) runGoal(serviceID, goalSetName string, singleGoal goal.Goal, goalSetCtx *goal.Context) (bool, error) {
+	// 如果有goal 没有达成 则调用 ensure
+	if r.checkGoal(serviceID, goalSetName, singleGoal, goalSetCtx) {
+		return true, nil
+	}
+
+	r.tracker.RecordGoalRunning(serviceID, goalSetName, singleGoal.Name)
+	ensureStart := time.Now()
+	err := singleGoal.Ensure(goalSetCtx)
+	observeEnsure(goalSetName, singleGoal.Name, err, ensureStart)
+	r.tracker.RecordGoalEnsure(serviceID, goalSetName, singleGoal.Name, err)
+	if detail, ok := goalSetCtx.Detail(singleGoal.Name); ok {
+		r.tracker.RecordGoalDetail(serviceID, goalSetName, singleGoal.Name, detail)
+	}
+	if err != nil {
+		r.bus.Publish(event.ServiceEvent{
+			Type:        event.EventError,
+			ServiceID:   serviceID,
+			GoalSetName: goalSetName,
+			Goal:        singleGoal.Name,
+			Error:       err.Error(),
+			CallbackURL: goalSetCtx.DeploySpec.CallbackURL,
+		})
+		return false, fmt.Errorf("goal %s ensure failed: %w", singleGoal.Name, err)
+	}
+
+	return r.checkGoal(serviceID, goalSetName, singleGoal, goalSetCtx), nil
+}
+
+// checkGoal 检查目标是否达成，目标新达成时发布事件
+func (r *Reconciler) checkGoal(serviceID, goalSetName string, singleGoal goal.Goal, goalSetCtx *goal.Context) bool {
+	achieved := singleGoal.IsAchieved(goalSetCtx)
+	if r.tracker.RecordGoalCheck(serviceID, goalSetName, singleGoal.Name, achieved) {
+		r.bus.Publish(event.ServiceEvent{
+			Type:        event.EventGoalCompleted,
+			ServiceID:   serviceID,
+			GoalSetName: goalSetName,
+			Goal:        singleGoal.Name,
+			CallbackURL: goalSetCtx.DeploySpec.CallbackURL,
+		})
+	}
+	return achieved
+}
+
+// observePhase 读取运行时状态，部署阶段变化时发布事件
+func (r *Reconciler) observePhase(deploySpec *dto.RequirementSpec, goalSetName string) {
+	phase := dto.PhaseFailed
+	endpoint := ""
+	if !r.tracker.IsFailed(deploySpec.ServiceId) {
+		infraShim, err := shimlet.Registry.GetSingleton(deploySpec.ShimletName)
+		if err != nil {
+			return
+		}
+		status, err := infraShim.Status(deploySpec.ServiceId)
+		if err != nil {
+			log.Warn("observe phase of service %s failed: %v", deploySpec.ServiceId, err)
+			return
+		}
+		phase = status.Status
+		endpoint = status.EndPoint
+		// 部署后验证未通过前，运行中的服务上报验证阶段
+		if goalSet, ok := goal.Registry[goalSetName]; ok {
+			if serviceProgress, ok := r.tracker.Get(deploySpec.ServiceId); ok {
+				phase = goalSet.ObservedPhase(phase, serviceProgress)
+			}
+		}
+	}
+
+	if prev, changed := r.tracker.SetPhase(deploySpec.ServiceId, goalSetName, phase); changed {
+		lastError := ""
+		if serviceProgress, ok := r.tracker.Get(deploySpec.ServiceId); ok {
+			lastError = serviceProgress.LastError
+		}
+		r.bus.Publish(event.ServiceEvent{
+			Type:        event.EventPhaseChanged,
+			ServiceID:   deploySpec.ServiceId,
+			GoalSetName: goalSetName,
+			From:        prev,
+			To:          phase,
+			EndPoint:    endpoint,
+			Error:       lastError,
+			CallbackURL: deploySpec.CallbackURL,
+		})
+	}
+}
+
 // Start 启动消费者协程
 func (r *Reconciler) Start() {
 	for i := 0; i < r.workers; i++ {
@@ -88,18 +270,247 @@ func (r *Reconciler) runWorker() {
 				return // 优雅退出
 			default:
 			}
-			deploySpec := r.specStore.Get(key)
-			err := r.reconcile(deploySpec)
-			if err != nil {
-				r.queue.Forget(key) // 清除重试计数
-				r.queue.AddAfter(key, time.Second*10)
-				// 注意：workqueue 会自动重试（因为没调用 Forget）
-			} else {
-				r.queue.Forget(key) // 清除重试计数
-				r.queue.AddAfter(key, time.Second*300)
-			}
-
+			r.processKey(key)
 		}()
 
 	}
 }
+
+// processKey 执行一次 reconcile，并根据目标集合的 MaxRetries/Timeout 决定重新投递或进入失败终态
+func (r *Reconciler) processKey(key string) {
+	deploySpec := r.specStore.Get(key)
+	if deploySpec == nil {
+		// 部署期望已被删除
+		r.queue.Forget(key)
+		return
+	}
+	if reason, ok := r.tracker.TakeRollback(key); ok {
+		// 回滚请求在失败终态下同样执行
+		r.rollback(deploySpec, reason)
+		return
+	}
+	if r.tracker.IsFailed(key) {
+		// 失败终态，等待通过 API 手动重试
+		r.queue.Forget(key)
+		return
+	}
+
+	goalSet, ok := goal.Registry[deploySpec.GoalSetName]
+	if !ok {
+		reason := "goal set not registered: " + deploySpec.GoalSetName
+		r.tracker.MarkFailed(key, deploySpec.GoalSetName, reason)
+		r.bus.Publish(event.ServiceEvent{
+			Type:        event.EventError,
+			ServiceID:   key,
+			GoalSetName: deploySpec.GoalSetName,
+			Error:       reason,
+			CallbackURL: deploySpec.CallbackURL,
+		})
+		r.observePhase(deploySpec, deploySpec.GoalSetName)
+		r.queue.Forget(key)
+		return
+	}
+
+	r.tracker.Resume(key, goalSet.Name, deploySpec.ConvergeStartTime, deploySpec.ConvergeRetries)
+	start := time.Now()
+	err := r.reconcile(deploySpec, goalSet)
+	r.queue.Forget(key) // 清除重试计数，重试节奏由下方 AddAfter 控制
+	if err == nil {
+		observeReconcile(goalSet.Name, resultSuccess, start)
+		r.tracker.RecordSuccess(key, goalSet.Name)
+		if goalSet.Teardown {
+			// 下线完成：发布最终阶段后释放服务，不再周期性检查
+			r.observePhase(deploySpec, goalSet.Name)
+			r.release(deploySpec)
+			return
+		}
+		r.persistConvergence(deploySpec, time.Time{}, 0)
+		r.queue.AddAfter(key, resyncInterval)
+		r.observePhase(deploySpec, goalSet.Name)
+		if goalSet.Rollback {
+			r.recordKnownGood(deploySpec)
+		}
+		return
+	}
+
+	item := r.tracker.RecordAttempt(key, goalSet.Name, err, !errors.Is(err, errGoalPending))
+	r.persistConvergence(deploySpec, item.FirstSubmitted, item.Retries)
+	if reason, timedOut := exceededReason(goalSet, item); reason != "" {
+		observeReconcile(goalSet.Name, resultFailed, start)
+		failure := fmt.Sprintf("%s: %v", reason, err)
+		// 更新超时后回滚到最近一次正常的版本；回滚生成的部署期望不再自动回滚，避免往复
+		if timedOut && goalSet.Rollback && !deploySpec.RolledBack && deploySpec.RollbackTarget() != nil {
+			log.Warn("service %s update %s, rolling back to the last known-good spec", key, reason)
+			r.rollback(deploySpec, failure)
+			return
+		}
+		log.Error("service %s reconcile failed permanently: %s, last error: %v", key, reason, err)
+		r.tracker.MarkFailed(key, goalSet.Name, failure)
+		r.observePhase(deploySpec, goalSet.Name)
+		return
+	}
+	observeReconcile(goalSet.Name, resultOf(err), start)
+	r.queue.AddAfter(key, retryInterval)
+	r.observePhase(deploySpec, goalSet.Name)
+}
+
+// persistConvergence 将本轮收敛的起始时间与重试次数写回部署期望，重启后接续计算超时与重试，
+// 避免持续失败的服务在每次重启后重新获得完整的超时与重试次数；期间提交了新的部署期望时跳过
+func (r *Reconciler) persistConvergence(deploySpec *dto.RequirementSpec, start time.Time, retries int) {
+	current := r.specStore.Get(deploySpec.ServiceId)
+	if current == nil || !current.UpdateTime.Equal(deploySpec.UpdateTime) {
+		return
+	}
+	if current.ConvergeStartTime.Equal(start) && current.ConvergeRetries == retries {
+		return
+	}
+	updated := current.DeepCopy()
+	updated.ConvergeStartTime, updated.ConvergeRetries = start, retries
+	if err := r.specStore.Set(deploySpec.ServiceId, updated); err != nil {
+		log.Warn("service %s persist convergence progress failed: %v", deploySpec.ServiceId, err)
+	}
+}
+
+// exceededReason 判断是否超过目标集合的重试次数或超时，返回失败原因以及是否为超时
+func exceededReason(goalSet *goal.GoalSet, item dto.ServiceProgress) (string, bool) {
+	if item.Retries > goalSet.MaxRetries {
+		return fmt.Sprintf("exceeded max retries (%d)", goalSet.MaxRetries), false
+	}
+	if goalSet.Timeout > 0 && time.Since(item.FirstSubmitted) > goalSet.Timeout {
+		return fmt.Sprintf("exceeded timeout (%s)", goalSet.Timeout), true
+	}
+	return "", false
+}
+
+// release 下线收敛后删除服务的部署期望、进度与各模块按服务保存的状态；
+// 收敛期间已提交新的部署期望时保留，由新的部署期望继续收敛
+func (r *Reconciler) release(deploySpec *dto.RequirementSpec) {
+	key := deploySpec.ServiceId
+	current := r.specStore.Get(key)
+	if current == nil || !current.UpdateTime.Equal(deploySpec.UpdateTime) {
+		return
+	}
+	r.specStore.Delete(key)
+	r.tracker.Delete(key)
+	goal.RunTeardownHooks(key)
+	log.Info("service %s deleted, released its spec and progress", key)
+}
+
+// recordKnownGood 收敛成功后将部署期望记录为最近一次正常的版本，供回滚使用。
+// 只有运行时上报 Running（新版本已全部滚动完成且可用）时才记录，
+// 否则旧版本仍在提供服务，留待下一次 resync 再记录
+func (r *Reconciler) recordKnownGood(deploySpec *dto.RequirementSpec) {
+	if deploySpec.LastKnownGood != nil && deploySpec.LastKnownGood.UpdateTime.Equal(deploySpec.UpdateTime) {
+		return
+	}
+	if item, ok := r.tracker.Get(deploySpec.ServiceId); !ok || item.Phase != dto.PhaseRunning {
+		return
+	}
+	// 收敛期间部署期望已被更新时不记录
+	current := r.specStore.Get(deploySpec.ServiceId)
+	if current == nil || !current.UpdateTime.Equal(deploySpec.UpdateTime) {
+		return
+	}
+	updated := *current
+	updated.LastKnownGood = current.Snapshot()
+	if err := r.specStore.Set(deploySpec.ServiceId, &updated); err != nil {
+		// 冲突表示部署期望已被更新，新版本收敛后再记录
+		log.Warn("service %s record known-good spec failed: %v", deploySpec.ServiceId, err)
+		return
+	}
+	log.Info("service %s recorded known-good spec updated at %s", deploySpec.ServiceId, deploySpec.UpdateTime.Format(time.RFC3339))
+}
+
+// rollback 对本轮执行过 Ensure 的目标执行补偿动作，再将部署期望恢复为最近一次正常的版本并重新收敛
+func (r *Reconciler) rollback(deploySpec *dto.RequirementSpec, reason string) {
+	key := deploySpec.ServiceId
+	target := deploySpec.RollbackTarget()
+	if target == nil {
+		log.Warn("service %s has no known-good spec to roll back to", key)
+		return
+	}
+	r.compensate(deploySpec)
+
+	// 保留快照与最新的回调地址，更新时间变化使只对每个版本执行一次的目标重新执行
+	rolledBack := target.Snapshot()
+	rolledBack.LastKnownGood = deploySpec.LastKnownGood
+	rolledBack.RolledBack = true
+	rolledBack.CallbackURL = deploySpec.CallbackURL
+	rolledBack.UpdateTime = time.Now()
+	rolledBack.ResourceVersion = deploySpec.ResourceVersion
+	if err := r.specStore.Set(key, rolledBack); err != nil {
+		// 冲突表示回滚期间提交了新的部署期望，由新的部署期望继续收敛
+		log.Warn("service %s rollback not persisted: %v", key, err)
+		return
+	}
+	log.Warn("service %s rolled back to spec updated at %s: %s", key, target.UpdateTime.Format(time.RFC3339), reason)
+
+	r.tracker.Reset(key, rolledBack.GoalSetName)
+	r.tracker.RecordRollback(key, rolledBack.GoalSetName, reason)
+	r.bus.Publish(event.ServiceEvent{
+		Type:        event.EventRolledBack,
+		ServiceID:   key,
+		GoalSetName: rolledBack.GoalSetName,
+		Error:       reason,
+		CallbackURL: rolledBack.CallbackURL,
+	})
+	if prev, changed := r.tracker.SetPhase(key, rolledBack.GoalSetName, dto.PhasePending); changed {
+		r.bus.Publish(event.ServiceEvent{
+			Type:        event.EventPhaseChanged,
+			ServiceID:   key,
+			GoalSetName: rolledBack.GoalSetName,
+			From:        prev,
+			To:          dto.PhasePending,
+			Error:       reason,
+			CallbackURL: rolledBack.CallbackURL,
+		})
+	}
+	r.queue.Add(key)
+}
+
+// compensate 按依赖的逆序对本轮调用过 Ensure 的目标执行补偿动作；
+// 补偿失败只发布错误事件，不影响回滚。进程重启后本轮的执行记录丢失，不再补偿
+func (r *Reconciler) compensate(deploySpec *dto.RequirementSpec) {
+	goalSet, ok := goal.Registry[deploySpec.GoalSetName]
+	if !ok {
+		return
+	}
+	serviceProgress, ok := r.tracker.Get(deploySpec.ServiceId)
+	if !ok || serviceProgress.GoalSetName != goalSet.Name {
+		return
+	}
+	ensured := make(map[string]bool, len(serviceProgress.Goals))
+	for _, g := range serviceProgress.Goals {
+		ensured[g.Name] = g.Attempts > 0
+	}
+
+	infraShim, err := shimlet.Registry.GetSingleton(deploySpec.ShimletName)
+	if err != nil {
+		log.Warn("skip compensation of service %s: %v", deploySpec.ServiceId, err)
+		return
+	}
+	goalSetCtx := &goal.Context{
+		Data:       make(map[string]any),
+		DeploySpec: deploySpec.DeepCopy(),
+		Shimlet:    infraShim,
+	}
+	order := goalSet.TopologicalOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		singleGoal := goalSet.Goals[order[i]]
+		if singleGoal.Compensate == nil || !ensured[singleGoal.Name] {
+			continue
+		}
+		if err := singleGoal.Compensate(goalSetCtx); err != nil {
+			r.bus.Publish(event.ServiceEvent{
+				Type:        event.EventError,
+				ServiceID:   deploySpec.ServiceId,
+				GoalSetName: goalSet.Name,
+				Goal:        singleGoal.Name,
+				Error:       fmt.Sprintf("compensate failed: %v", err),
+				CallbackURL: deploySpec.CallbackURL,
+			})
+			continue
+		}
+		log.Info("goal %s of service %s compensated", singleGoal.Name, deploySpec.ServiceId)
+	}
+}
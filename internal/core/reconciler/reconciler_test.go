@@ -0,0 +1,148 @@
+package reconciler
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/core/eventbus"
+	"astron-xmod-shim/internal/core/goal"
+	"astron-xmod-shim/internal/core/progress"
+	"astron-xmod-shim/internal/core/spec"
+	"astron-xmod-shim/internal/core/workqueue"
+	dto "astron-xmod-shim/internal/dto/deploy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试目标集合的重试次数与超时判定
+func TestExceededReason(t *testing.T) {
+	goalSet := &goal.GoalSet{Name: "test", MaxRetries: 2, Timeout: time.Minute}
+
+	reason, _ := exceededReason(goalSet, dto.ServiceProgress{Retries: 2, FirstSubmitted: time.Now()})
+	assert.Empty(t, reason)
+	reason, timedOut := exceededReason(goalSet, dto.ServiceProgress{Retries: 3, FirstSubmitted: time.Now()})
+	assert.Contains(t, reason, "max retries")
+	assert.False(t, timedOut)
+	reason, timedOut = exceededReason(goalSet, dto.ServiceProgress{FirstSubmitted: time.Now().Add(-2 * time.Minute)})
+	assert.Contains(t, reason, "timeout")
+	assert.True(t, timedOut)
+
+	// Timeout 为 0 表示不限制时长
+	goalSet.Timeout = 0
+	reason, _ = exceededReason(goalSet, dto.ServiceProgress{FirstSubmitted: time.Now().Add(-time.Hour)})
+	assert.Empty(t, reason)
+}
+
+// flagGoal 调用 Ensure 后达成的目标，ensure 为空时直接达成
+func flagGoal(name string, ensure func() error, dependsOn ...string) goal.Goal {
+	var achieved atomic.Bool
+	return goal.Goal{
+		Name:       name,
+		DependsOn:  dependsOn,
+		IsAchieved: func(*goal.Context) bool { return achieved.Load() },
+		Ensure: func(*goal.Context) error {
+			if ensure != nil {
+				if err := ensure(); err != nil {
+					return err
+				}
+			}
+			achieved.Store(true)
+			return nil
+		},
+	}
+}
+
+func newTestReconciler(t *testing.T) (*Reconciler, *progress.Tracker) {
+	bus := eventbus.New()
+	t.Cleanup(bus.Close)
+	tracker := progress.NewTracker()
+	return NewReconciler(spec.NewMemoryStore(), 1, workqueue.New(), tracker, bus), tracker
+}
+
+// 测试互不依赖的目标并发执行，依赖全部达成后才执行后续目标
+func TestReconcile_ConcurrentGoals(t *testing.T) {
+	r, tracker := newTestReconciler(t)
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	// pull-image 与 validate-model 互相等待对方开始，串行执行时会超时
+	pullStarted, validateStarted := make(chan struct{}), make(chan struct{})
+	rendezvous := func(name string, self, other chan struct{}) func() error {
+		return func() error {
+			close(self)
+			select {
+			case <-other:
+				record(name)
+				return nil
+			case <-time.After(2 * time.Second):
+				return errors.New(name + " did not run concurrently")
+			}
+		}
+	}
+	goalSet, err := goal.NewGoalSetBuilder("dag").
+		AddGoal(flagGoal("map-model-path", func() error { record("map-model-path"); return nil })).
+		AddGoal(flagGoal("pull-image", rendezvous("pull-image", pullStarted, validateStarted))).
+		AddGoal(flagGoal("validate-model", rendezvous("validate-model", validateStarted, pullStarted), "map-model-path")).
+		AddGoal(flagGoal("apply", func() error { record("apply"); return nil }, "pull-image", "validate-model")).
+		Build()
+	require.NoError(t, err)
+
+	deploySpec := &dto.RequirementSpec{ServiceId: "dag-svc", ShimletName: "sim"}
+	require.NoError(t, r.reconcile(deploySpec, goalSet))
+	assert.Equal(t, "apply", order[len(order)-1])
+	assert.Len(t, order, 4)
+
+	item, ok := tracker.Get("dag-svc")
+	require.True(t, ok)
+	assert.Len(t, item.CompletedGoals(), 4)
+	assert.Equal(t, []string{"pull-image", "validate-model"}, item.Goals[3].DependsOn)
+	for _, g := range item.Goals {
+		assert.False(t, g.Running, g.Name)
+	}
+}
+
+// 测试失败后不再启动依赖它的目标，未达成的目标不阻塞其他分支
+func TestReconcile_FailedAndPendingGoals(t *testing.T) {
+	r, tracker := newTestReconciler(t)
+	var applied atomic.Bool
+	pending := goal.Goal{
+		Name:       "wait-quota",
+		IsAchieved: func(*goal.Context) bool { return false },
+		Ensure:     func(*goal.Context) error { return nil },
+	}
+	goalSet, err := goal.NewGoalSetBuilder("dag").
+		AddGoal(pending).
+		AddGoal(flagGoal("warmup", nil)).
+		AddGoal(flagGoal("apply", func() error { applied.Store(true); return nil }, "wait-quota")).
+		AddGoal(flagGoal("notify", nil, "warmup")).
+		Build()
+	require.NoError(t, err)
+
+	deploySpec := &dto.RequirementSpec{ServiceId: "dag-pending", ShimletName: "sim"}
+	err = r.reconcile(deploySpec, goalSet)
+	assert.ErrorIs(t, err, errGoalPending)
+	assert.ErrorContains(t, err, "wait-quota")
+	assert.False(t, applied.Load())
+	item, _ := tracker.Get("dag-pending")
+	assert.Equal(t, []string{"warmup", "notify"}, item.CompletedGoals())
+	assert.Equal(t, []string{"wait-quota"}, item.ActiveGoals())
+
+	goalSet, err = goal.NewGoalSetBuilder("dag-failed").
+		AddGoal(flagGoal("pull-image", func() error { return errors.New("registry unreachable") })).
+		AddGoal(flagGoal("apply", func() error { applied.Store(true); return nil }, "pull-image")).
+		Build()
+	require.NoError(t, err)
+	err = r.reconcile(&dto.RequirementSpec{ServiceId: "dag-failed", ShimletName: "sim"}, goalSet)
+	assert.ErrorContains(t, err, "goal pull-image ensure failed: registry unreachable")
+	assert.NotErrorIs(t, err, errGoalPending)
+	assert.False(t, applied.Load())
+}
@@ -0,0 +1,89 @@
+package shimlet
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/metrics"
+	"time"
+)
+
+var (
+	callDuration = metrics.Default.NewHistogramVec("xmod_shimlet_call_duration_seconds",
+		"Duration of shimlet calls per shimlet and method.", nil, "shimlet", "method")
+	callErrors = metrics.Default.NewCounterVec("xmod_shimlet_call_errors_total",
+		"Total number of failed shimlet calls per shimlet and method.", "shimlet", "method")
+)
+
+func init() {
+	Registry.Decorate(Instrument)
+}
+
+// Instrument 包装 shimlet，记录各方法的调用耗时与错误数，指标与 ID 使用实例名
+func Instrument(name string, inner Shimlet) Shimlet {
+	if _, ok := inner.(*instrumentedShimlet); ok {
+		return inner
+	}
+	return &instrumentedShimlet{name: name, inner: inner}
+}
+
+type instrumentedShimlet struct {
+	name  string
+	inner Shimlet
+}
+
+// Unwrap 返回被包装的 shimlet，未被包装时原样返回（如测试中获取 SimShimlet 以注入故障）
+func Unwrap(s Shimlet) Shimlet {
+	if instrumented, ok := s.(*instrumentedShimlet); ok {
+		return instrumented.inner
+	}
+	return s
+}
+
+func (s *instrumentedShimlet) observe(method string, start time.Time, err error) {
+	callDuration.WithLabelValues(s.name, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		callErrors.WithLabelValues(s.name, method).Inc()
+	}
+}
+
+func (s *instrumentedShimlet) InitWithConfig(confPath string) error {
+	start := time.Now()
+	err := s.inner.InitWithConfig(confPath)
+	s.observe("InitWithConfig", start, err)
+	return err
+}
+
+func (s *instrumentedShimlet) Apply(spec *dto.RequirementSpec) error {
+	start := time.Now()
+	err := s.inner.Apply(spec)
+	s.observe("Apply", start, err)
+	return err
+}
+
+func (s *instrumentedShimlet) Delete(resourceId string) error {
+	start := time.Now()
+	err := s.inner.Delete(resourceId)
+	s.observe("Delete", start, err)
+	return err
+}
+
+func (s *instrumentedShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
+	start := time.Now()
+	status, err := s.inner.Status(resourceId)
+	s.observe("Status", start, err)
+	return status, err
+}
+
+func (s *instrumentedShimlet) ListDeployedServices() ([]string, error) {
+	start := time.Now()
+	serviceIDs, err := s.inner.ListDeployedServices()
+	s.observe("ListDeployedServices", start, err)
+	return serviceIDs, err
+}
+
+func (s *instrumentedShimlet) ID() string {
+	return s.name
+}
+
+func (s *instrumentedShimlet) Description() string {
+	return s.inner.Description()
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConnClosed is returned for calls on a connection that has been closed or broke.
+var ErrConnClosed = errors.New("plugin connection closed")
+
+// ErrCallTimeout is returned when a call gets no response in time. The connection stays
+// usable: a late response is discarded by the read loop.
+var ErrCallTimeout = errors.New("plugin call timed out")
+
+// conn multiplexes requests over one plugin connection. Responses are matched by id.
+type conn struct {
+	w       io.Writer
+	closeFn func() error
+
+	writeMu sync.Mutex
+	nextID  atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan *Response
+	err     error
+	done    chan struct{}
+}
+
+// newConn starts reading responses from r. closeFn releases the underlying transport.
+func newConn(r io.Reader, w io.Writer, closeFn func() error, onNoise func(line string)) *conn {
+	c := &conn{
+		w:       w,
+		closeFn: closeFn,
+		pending: make(map[uint64]chan *Response),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(r, onNoise)
+	return c
+}
+
+func (c *conn) readLoop(r io.Reader, onNoise func(line string)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil || resp.ID == 0 {
+			// not a protocol message, e.g. a log line the plugin wrote to stdout
+			if onNoise != nil {
+				onNoise(scanner.Text())
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- &resp
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	c.fail(fmt.Errorf("%w: %v", ErrConnClosed, err))
+}
+
+// fail marks the connection broken and wakes up all pending calls.
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// lastErr returns why the connection broke, nil while it is usable.
+func (c *conn) lastErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// close shuts the transport down.
+func (c *conn) close() {
+	c.fail(ErrConnClosed)
+	if c.closeFn != nil {
+		_ = c.closeFn()
+	}
+}
+
+// call sends a request and decodes the result into result (if non-nil).
+// Plugin errors are returned as *Error; anything else is a transport failure.
+func (c *conn) call(method string, params, result any, timeout time.Duration) error {
+	req := Request{ID: c.nextID.Add(1), Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("encode %s params: %w", method, err)
+		}
+		req.Params = data
+	}
+
+	ch := make(chan *Response, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", method, err)
+	}
+	c.writeMu.Lock()
+	_, err = c.w.Write(append(line, '\n'))
+	c.writeMu.Unlock()
+	if err != nil {
+		c.fail(fmt.Errorf("%w: %v", ErrConnClosed, err))
+		return c.lastErr()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return c.lastErr()
+	case <-timer.C:
+		return fmt.Errorf("%w: %s after %s", ErrCallTimeout, method, timeout)
+	}
+}
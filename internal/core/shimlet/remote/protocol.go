@@ -0,0 +1,87 @@
+// Package remote lets shimlets run out of process. The shim talks to a plugin with
+// newline-delimited JSON requests/responses that mirror the shimlet.Shimlet interface,
+// either over the plugin's stdin/stdout (the shim launches the binary) or over a
+// unix/tcp socket (the plugin is already running).
+//
+// A session starts with a handshake that negotiates the protocol version, followed by
+// init with the shimlet's config path. Requests carry an id and may be answered out of
+// order; lines on the plugin's stdout that are not responses are ignored, so plugins
+// may log to stdout, although stderr is preferred.
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the newest protocol version this shim speaks.
+const ProtocolVersion = 1
+
+// supportedVersions are offered during the handshake, newest first.
+var supportedVersions = []int{ProtocolVersion}
+
+// Methods of the plugin protocol.
+const (
+	MethodHandshake = "handshake" // HandshakeParams → HandshakeResult
+	MethodInit      = "init"      // InitParams → {}
+	MethodApply     = "apply"     // RequirementSpec → {}
+	MethodDelete    = "delete"    // ResourceParams → {}
+	MethodStatus    = "status"    // ResourceParams → RuntimeStatus
+	MethodList      = "list"      // {} → []string
+	MethodHealth    = "health"    // {} → {}
+)
+
+// Error codes returned by plugins.
+const (
+	CodeUnsupportedVersion = "unsupported_version"
+	CodeUnknownMethod      = "unknown_method"
+	CodeInvalidParams      = "invalid_params"
+	CodeNotInitialized     = "not_initialized"
+	CodeInternal           = "internal"
+)
+
+// Request is one call from the shim to the plugin.
+type Request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response answers the request with the same id. Exactly one of Result and Error is set.
+type Response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is an error reported by the plugin itself (as opposed to a transport failure).
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error (%s): %s", e.Code, e.Message)
+}
+
+// HandshakeParams lists the protocol versions the shim supports.
+type HandshakeParams struct {
+	ProtocolVersions []int `json:"protocolVersions"`
+}
+
+// HandshakeResult is the version chosen by the plugin and its self-description.
+type HandshakeResult struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+}
+
+// InitParams carries the shimlet's config path.
+type InitParams struct {
+	ConfigPath string `json:"configPath"`
+}
+
+// ResourceParams identifies the service for delete and status.
+type ResourceParams struct {
+	ResourceID string `json:"resourceId"`
+}
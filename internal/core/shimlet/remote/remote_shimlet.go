@@ -0,0 +1,410 @@
+package remote
+
+import (
+	"astron-xmod-shim/internal/core/shimlet"
+	"astron-xmod-shim/internal/core/typereg"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ensure RemoteShimlet implements the Shimlet interface at compile time
+var _ shimlet.Shimlet = (*RemoteShimlet)(nil)
+
+const (
+	defaultCallTimeout      = 30 * time.Second
+	defaultHealthInterval   = 10 * time.Second
+	defaultReconnectBackoff = time.Second
+	defaultMaxBackoff       = 30 * time.Second
+	// pluginStopTimeout is how long a launched plugin gets to exit after its stdin is closed
+	pluginStopTimeout = 5 * time.Second
+)
+
+// ErrPluginUnavailable is returned while the plugin is down and the reconnect backoff has not elapsed.
+var ErrPluginUnavailable = errors.New("plugin unavailable")
+
+// RegisterPlugins registers a RemoteShimlet for every shimlet configured with a plugin block,
+// so that GetSingleton launches or dials the plugin like any compiled-in shimlet.
+func RegisterPlugins(reg *typereg.TypeReg[shimlet.Shimlet], shimlets map[string]cfg.ShimletConfig) {
+	for name, shimletCfg := range shimlets {
+		if shimletCfg.Plugin == nil {
+			continue
+		}
+		name, pluginCfg := name, *shimletCfg.Plugin
+		reg.Register(name, func() shimlet.Shimlet { return NewRemoteShimlet(name, pluginCfg) })
+		log.Info("shimlet %s registered as plugin (path: %s, address: %s)", name, pluginCfg.Path, pluginCfg.Address)
+	}
+}
+
+// RemoteShimlet forwards the Shimlet interface to an external plugin process.
+// It health-checks the plugin periodically and reconnects (relaunching the binary
+// when it owns it) with exponential backoff after the connection breaks.
+type RemoteShimlet struct {
+	name           string
+	conf           cfg.PluginConfig
+	callTimeout    time.Duration
+	healthInterval time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mu          sync.Mutex
+	confPath    string
+	conn        *conn
+	description string
+	version     int
+	backoff     time.Duration
+	nextAttempt time.Time
+	lastErr     error
+	stopCh      chan struct{}
+	// connecting is closed when the in-flight connection attempt finishes; concurrent
+	// callers wait on it instead of dialing (or launching the plugin) again
+	connecting chan struct{}
+	closed     bool
+}
+
+// NewRemoteShimlet creates the adapter; the plugin is started by InitWithConfig.
+func NewRemoteShimlet(name string, pluginCfg cfg.PluginConfig) *RemoteShimlet {
+	r := &RemoteShimlet{
+		name:           name,
+		conf:           pluginCfg,
+		callTimeout:    time.Duration(pluginCfg.CallTimeoutMs) * time.Millisecond,
+		healthInterval: time.Duration(pluginCfg.HealthIntervalMs) * time.Millisecond,
+		initialBackoff: time.Duration(pluginCfg.ReconnectBackoffMs) * time.Millisecond,
+		maxBackoff:     time.Duration(pluginCfg.MaxBackoffMs) * time.Millisecond,
+	}
+	if r.callTimeout <= 0 {
+		r.callTimeout = defaultCallTimeout
+	}
+	if r.healthInterval <= 0 {
+		r.healthInterval = defaultHealthInterval
+	}
+	if r.initialBackoff <= 0 {
+		r.initialBackoff = defaultReconnectBackoff
+	}
+	if r.maxBackoff < r.initialBackoff {
+		r.maxBackoff = defaultMaxBackoff
+	}
+	r.backoff = r.initialBackoff
+	return r
+}
+
+// ID returns the name the plugin is configured under.
+func (r *RemoteShimlet) ID() string { return r.name }
+
+// Description returns the description reported by the plugin during the handshake.
+func (r *RemoteShimlet) Description() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.description == "" {
+		return "remote shimlet plugin"
+	}
+	return r.description
+}
+
+// ProtocolVersion returns the negotiated protocol version, 0 before the first handshake.
+func (r *RemoteShimlet) ProtocolVersion() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version
+}
+
+// InitWithConfig connects to the plugin, negotiates the protocol version, forwards the
+// config path and starts health checking.
+func (r *RemoteShimlet) InitWithConfig(confPath string) error {
+	if r.conf.Path == "" && r.conf.Address == "" {
+		return fmt.Errorf("plugin %s: either path or address must be configured", r.name)
+	}
+	r.mu.Lock()
+	r.confPath = confPath
+	r.closed = false
+	r.mu.Unlock()
+
+	if _, err := r.getConn(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.stopCh = make(chan struct{})
+	stopCh := r.stopCh
+	r.mu.Unlock()
+	go r.healthLoop(stopCh)
+	return nil
+}
+
+// Close stops health checking and shuts the plugin connection down.
+func (r *RemoteShimlet) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	if r.stopCh != nil {
+		close(r.stopCh)
+		r.stopCh = nil
+	}
+	if r.conn != nil {
+		r.conn.close()
+		r.conn = nil
+	}
+}
+
+// Apply forwards to the plugin.
+func (r *RemoteShimlet) Apply(spec *dto.RequirementSpec) error {
+	return r.call(MethodApply, spec, nil)
+}
+
+// Delete forwards to the plugin.
+func (r *RemoteShimlet) Delete(resourceId string) error {
+	return r.call(MethodDelete, &ResourceParams{ResourceID: resourceId}, nil)
+}
+
+// Status forwards to the plugin.
+func (r *RemoteShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
+	status := &dto.RuntimeStatus{}
+	if err := r.call(MethodStatus, &ResourceParams{ResourceID: resourceId}, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ListDeployedServices 获取插件管理的已部署服务列表
+func (r *RemoteShimlet) ListDeployedServices() ([]string, error) {
+	serviceIDs := []string{}
+	if err := r.call(MethodList, nil, &serviceIDs); err != nil {
+		return []string{}, err
+	}
+	return serviceIDs, nil
+}
+
+// call runs one request, dropping the connection only when it broke so the next
+// call (or the health loop) reconnects. A timeout keeps the connection: reconnecting
+// would restart a launched plugin and abort the work it is still doing.
+func (r *RemoteShimlet) call(method string, params, result any) error {
+	c, err := r.getConn()
+	if err != nil {
+		return err
+	}
+	err = c.call(method, params, result, r.callTimeout)
+	var pluginErr *Error
+	if err == nil || errors.As(err, &pluginErr) {
+		return err
+	}
+	if errors.Is(err, ErrConnClosed) {
+		r.dropConn(c, err)
+	}
+	return fmt.Errorf("plugin %s %s: %w", r.name, method, err)
+}
+
+// getConn returns the live connection, reconnecting when the backoff allows it.
+// The connection attempt runs outside r.mu so a slow or hanging plugin does not block
+// other callers; only one attempt is in flight at a time and its result is published under r.mu.
+func (r *RemoteShimlet) getConn() (*conn, error) {
+	for {
+		r.mu.Lock()
+		if r.conn != nil && r.conn.lastErr() == nil {
+			c := r.conn
+			r.mu.Unlock()
+			return c, nil
+		}
+		broken := r.conn
+		r.conn = nil
+		if r.connecting != nil {
+			wait := r.connecting
+			r.mu.Unlock()
+			<-wait
+			continue
+		}
+		if now := time.Now(); now.Before(r.nextAttempt) {
+			err := fmt.Errorf("%w: %s, retry in %s, last error: %v",
+				ErrPluginUnavailable, r.name, r.nextAttempt.Sub(now).Round(time.Millisecond), r.lastErr)
+			r.mu.Unlock()
+			closeConn(broken)
+			return nil, err
+		}
+		done := make(chan struct{})
+		r.connecting = done
+		confPath := r.confPath
+		r.mu.Unlock()
+
+		closeConn(broken)
+		c, hs, err := r.connect(confPath)
+
+		r.mu.Lock()
+		r.connecting = nil
+		close(done)
+		if err == nil && r.closed {
+			err = fmt.Errorf("plugin %s is closed", r.name)
+			defer c.close()
+		}
+		if err != nil {
+			r.lastErr = err
+			r.nextAttempt = time.Now().Add(r.backoff)
+			r.backoff = min(r.backoff*2, r.maxBackoff)
+			r.mu.Unlock()
+			log.Warn("connect to plugin %s failed: %v", r.name, err)
+			return nil, fmt.Errorf("%w: %s: %v", ErrPluginUnavailable, r.name, err)
+		}
+		r.conn = c
+		r.version = hs.ProtocolVersion
+		r.description = hs.Description
+		r.lastErr = nil
+		r.backoff = r.initialBackoff
+		r.nextAttempt = time.Time{}
+		r.mu.Unlock()
+		return c, nil
+	}
+}
+
+// closeConn closes a connection taken out of r.conn; closing a launched plugin may wait for it to exit.
+func closeConn(c *conn) {
+	if c != nil {
+		c.close()
+	}
+}
+
+// dropConn discards a broken connection unless it was already replaced.
+func (r *RemoteShimlet) dropConn(c *conn, cause error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn != c {
+		return
+	}
+	log.Warn("plugin %s connection lost: %v", r.name, cause)
+	c.close()
+	r.conn = nil
+	r.lastErr = cause
+}
+
+// connect opens the transport, negotiates the version and initializes the plugin.
+// It does not touch the shared state, callers publish the result under r.mu.
+func (r *RemoteShimlet) connect(confPath string) (*conn, *HandshakeResult, error) {
+	var c *conn
+	var err error
+	if r.conf.Address != "" {
+		c, err = r.dial()
+	} else {
+		c, err = r.launch()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var hs HandshakeResult
+	if err := c.call(MethodHandshake, &HandshakeParams{ProtocolVersions: supportedVersions}, &hs, r.callTimeout); err != nil {
+		c.close()
+		return nil, nil, fmt.Errorf("handshake: %w", err)
+	}
+	if !slices.Contains(supportedVersions, hs.ProtocolVersion) {
+		c.close()
+		return nil, nil, fmt.Errorf("handshake: plugin chose unsupported protocol version %d", hs.ProtocolVersion)
+	}
+	if err := c.call(MethodInit, &InitParams{ConfigPath: confPath}, nil, r.callTimeout); err != nil {
+		c.close()
+		return nil, nil, fmt.Errorf("init: %w", err)
+	}
+	log.Info("plugin %s connected (name: %s, protocol version: %d)", r.name, hs.Name, hs.ProtocolVersion)
+	return c, &hs, nil
+}
+
+// dial connects to an already running plugin.
+func (r *RemoteShimlet) dial() (*conn, error) {
+	network, address := "tcp", r.conf.Address
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	netConn, err := net.DialTimeout(network, address, r.callTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", r.conf.Address, err)
+	}
+	return newConn(netConn, netConn, netConn.Close, r.logNoise), nil
+}
+
+// launch starts the plugin binary and talks to it over stdin/stdout; stderr goes to the shim log.
+func (r *RemoteShimlet) launch() (*conn, error) {
+	cmd := exec.Command(r.conf.Path, r.conf.Args...)
+	cmd.Env = append(os.Environ(), r.conf.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", r.conf.Path, err)
+	}
+	log.Info("plugin %s launched: %s (pid %d)", r.name, r.conf.Path, cmd.Process.Pid)
+
+	go r.forwardStderr(stderr)
+	exited := make(chan struct{})
+	c := newConn(stdout, stdin, func() error {
+		// closing stdin asks the plugin to exit; kill it if it does not
+		_ = stdin.Close()
+		select {
+		case <-exited:
+		case <-time.After(pluginStopTimeout):
+			_ = cmd.Process.Kill()
+			<-exited
+		}
+		return nil
+	}, r.logNoise)
+	go func() {
+		err := cmd.Wait()
+		close(exited)
+		c.fail(fmt.Errorf("%w: plugin process exited: %v", ErrConnClosed, err))
+	}()
+	return c, nil
+}
+
+func (r *RemoteShimlet) forwardStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		log.Info("[plugin %s] %s", r.name, scanner.Text())
+	}
+}
+
+func (r *RemoteShimlet) logNoise(line string) {
+	log.Debug("[plugin %s] %s", r.name, line)
+}
+
+// healthLoop pings the plugin and reconnects when it stops answering. Unlike other
+// calls a health timeout drops the connection: requests are served concurrently, so a
+// plugin that cannot answer a ping is hung rather than busy.
+func (r *RemoteShimlet) healthLoop(stopCh chan struct{}) {
+	ticker := time.NewTicker(r.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			c, err := r.getConn()
+			if err == nil {
+				err = c.call(MethodHealth, nil, nil, r.callTimeout)
+				var pluginErr *Error
+				if err != nil && !errors.As(err, &pluginErr) {
+					r.dropConn(c, err)
+				}
+			}
+			if err != nil {
+				log.Warn("plugin %s health check failed: %v", r.name, err)
+			}
+		}
+	}
+}
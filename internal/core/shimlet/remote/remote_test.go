@@ -0,0 +1,255 @@
+package remote
+
+import (
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/core/shimlet/shimlets"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pluginHelperEnv = "XMOD_PLUGIN_HELPER"
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&cfg.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// TestPluginHelper is not a real test: TestRemoteShimlet_Launch re-execs the test binary
+// with pluginHelperEnv set to act as a stdio plugin.
+func TestPluginHelper(t *testing.T) {
+	if os.Getenv(pluginHelperEnv) == "" {
+		return
+	}
+	_ = NewServer(&shimlets.SimShimlet{}).ServeConn(os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+// startSocketPlugin serves a SimShimlet on a local tcp port.
+func startSocketPlugin(t *testing.T) (net.Listener, *shimlets.SimShimlet) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sim := &shimlets.SimShimlet{}
+	go func() { _ = NewServer(sim).Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+	return listener, sim
+}
+
+func TestRemoteShimlet_Dial(t *testing.T) {
+	listener, sim := startSocketPlugin(t)
+	r := NewRemoteShimlet("sim-remote", cfg.PluginConfig{Address: "tcp://" + listener.Addr().String()})
+	require.NoError(t, r.InitWithConfig(""))
+	t.Cleanup(r.Close)
+
+	assert.Equal(t, "sim-remote", r.ID())
+	assert.Equal(t, "simulated in-memory shimlet", r.Description())
+	assert.Equal(t, ProtocolVersion, r.ProtocolVersion())
+
+	require.NoError(t, r.Apply(&dto.RequirementSpec{ServiceId: "svc", ModelName: "qwen"}))
+	status, err := r.Status("svc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	assert.Equal(t, "qwen", status.DeploySpec.ModelName)
+
+	ids, err := r.ListDeployedServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, ids)
+
+	// 插件返回的错误原样透传，不触发重连
+	sim.FailNext(shimlets.SimOpApply, 1)
+	err = r.Apply(&dto.RequirementSpec{ServiceId: "svc"})
+	var pluginErr *Error
+	require.ErrorAs(t, err, &pluginErr)
+	assert.Contains(t, pluginErr.Message, "injected failure")
+
+	require.NoError(t, r.Delete("svc"))
+	status, err = r.Status("svc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseUnknown, status.Status)
+}
+
+func TestRemoteShimlet_ReconnectAfterDisconnect(t *testing.T) {
+	listener, _ := startSocketPlugin(t)
+	r := NewRemoteShimlet("sim-remote", cfg.PluginConfig{
+		Address:            listener.Addr().String(),
+		HealthIntervalMs:   20,
+		ReconnectBackoffMs: 10,
+	})
+	require.NoError(t, r.InitWithConfig(""))
+	t.Cleanup(r.Close)
+	require.NoError(t, r.Apply(&dto.RequirementSpec{ServiceId: "svc"}))
+
+	// 断开当前连接，健康检查发现后重连；插件已初始化，状态保留
+	r.mu.Lock()
+	broken := r.conn
+	r.mu.Unlock()
+	broken.close()
+
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.conn != nil && r.conn != broken
+	}, 5*time.Second, 10*time.Millisecond)
+	status, err := r.Status("svc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+}
+
+func TestRemoteShimlet_Unavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	r := NewRemoteShimlet("gone", cfg.PluginConfig{Address: address, ReconnectBackoffMs: 60000})
+	assert.ErrorIs(t, r.InitWithConfig(""), ErrPluginUnavailable)
+	// 退避期内不重复连接
+	_, err = r.Status("svc")
+	assert.ErrorIs(t, err, ErrPluginUnavailable)
+	assert.Contains(t, err.Error(), "retry in")
+
+	assert.Error(t, NewRemoteShimlet("empty", cfg.PluginConfig{}).InitWithConfig(""))
+}
+
+// 测试连接在锁外建立：并发调用只建立一次连接，连接期间其他方法不被阻塞
+func TestRemoteShimlet_ConnectOutsideLock(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	var accepted atomic.Int32
+	release := make(chan struct{})
+	sim := &shimlets.SimShimlet{}
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() {
+				// 握手前挂起，模拟响应缓慢的插件
+				<-release
+				_ = NewServer(sim).ServeConn(netConn, netConn)
+			}()
+		}
+	}()
+
+	r := NewRemoteShimlet("slow", cfg.PluginConfig{Address: listener.Addr().String(), CallTimeoutMs: 5000})
+	t.Cleanup(r.Close)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Status("svc")
+			assert.NoError(t, err)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return accepted.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	described := make(chan string, 1)
+	go func() { described <- r.Description() }()
+	select {
+	case desc := <-described:
+		assert.Equal(t, "remote shimlet plugin", desc)
+	case <-time.After(time.Second):
+		t.Fatal("Description blocked by the in-flight connection attempt")
+	}
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted.Load())
+	assert.Equal(t, "simulated in-memory shimlet", r.Description())
+}
+
+func TestRemoteShimlet_VersionNegotiation(t *testing.T) {
+	listener, _ := startSocketPlugin(t)
+	saved := supportedVersions
+	supportedVersions = []int{99}
+	defer func() { supportedVersions = saved }()
+
+	r := NewRemoteShimlet("sim-remote", cfg.PluginConfig{Address: listener.Addr().String()})
+	err := r.InitWithConfig("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), CodeUnsupportedVersion)
+}
+
+func TestRemoteShimlet_Launch(t *testing.T) {
+	r := NewRemoteShimlet("sim-stdio", cfg.PluginConfig{
+		Path:               os.Args[0],
+		Args:               []string{"-test.run=^TestPluginHelper$"},
+		Env:                []string{pluginHelperEnv + "=1"},
+		ReconnectBackoffMs: 10,
+	})
+	require.NoError(t, r.InitWithConfig(""))
+	t.Cleanup(r.Close)
+
+	require.NoError(t, r.Apply(&dto.RequirementSpec{ServiceId: "svc"}))
+	status, err := r.Status("svc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+
+	// 插件进程退出后重新拉起，新进程中没有该服务
+	r.mu.Lock()
+	r.conn.close()
+	r.mu.Unlock()
+	require.Eventually(t, func() bool {
+		status, err = r.Status("svc")
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, dto.PhaseUnknown, status.Status)
+}
+
+// blockingApplyShimlet 的 Apply 阻塞到 release 关闭，模拟耗时较长的插件操作
+type blockingApplyShimlet struct {
+	*shimlets.SimShimlet
+	release chan struct{}
+}
+
+func (s *blockingApplyShimlet) Apply(spec *dto.RequirementSpec) error {
+	<-s.release
+	return s.SimShimlet.Apply(spec)
+}
+
+// 测试调用超时不断开连接：插件不会被重连（对拉起的插件即重启），之后的调用复用原连接
+func TestRemoteShimlet_TimeoutKeepsConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	impl := &blockingApplyShimlet{SimShimlet: &shimlets.SimShimlet{}, release: make(chan struct{})}
+	var accepted atomic.Int32
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() { _ = NewServer(impl).ServeConn(netConn, netConn) }()
+		}
+	}()
+
+	r := NewRemoteShimlet("slow-apply", cfg.PluginConfig{Address: listener.Addr().String(), CallTimeoutMs: 200})
+	require.NoError(t, r.InitWithConfig(""))
+	t.Cleanup(r.Close)
+
+	err = r.Apply(&dto.RequirementSpec{ServiceId: "svc", ModelName: "qwen"})
+	require.ErrorIs(t, err, ErrCallTimeout)
+
+	// 迟到的响应被丢弃，连接仍可用
+	close(impl.release)
+	require.Eventually(t, func() bool { return impl.ApplyCount("svc") == 1 }, 5*time.Second, 10*time.Millisecond)
+	status, err := r.Status("svc")
+	require.NoError(t, err)
+	assert.NotEmpty(t, status.Status)
+	assert.Equal(t, int32(1), accepted.Load())
+}
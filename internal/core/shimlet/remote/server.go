@@ -0,0 +1,182 @@
+package remote
+
+import (
+	"astron-xmod-shim/internal/core/shimlet"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+)
+
+// maxMessageSize bounds a single protocol line.
+const maxMessageSize = 16 * 1024 * 1024
+
+// Server exposes a Go shimlet over the plugin protocol. Plugins written in Go call
+// ServeConn with os.Stdin/os.Stdout, or Serve with a listener when they are dialed.
+// The shimlet is initialized once; repeated init calls with the same config path
+// (e.g. after the shim reconnects) are no-ops.
+type Server struct {
+	impl shimlet.Shimlet
+
+	mu         sync.Mutex
+	configPath string
+	inited     bool
+}
+
+// NewServer creates a server for the shimlet.
+func NewServer(impl shimlet.Shimlet) *Server {
+	return &Server{impl: impl}
+}
+
+// Serve accepts connections until the listener is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			_ = s.ServeConn(conn, conn)
+		}()
+	}
+}
+
+// ServeConn handles requests from r until EOF. Requests are handled concurrently.
+func (s *Server) ServeConn(r io.Reader, w io.Writer) error {
+	var writeMu sync.Mutex
+	encoder := json.NewEncoder(w)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := s.handle(&req)
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = encoder.Encode(resp)
+		}()
+	}
+	return scanner.Err()
+}
+
+// handle dispatches one request to the shimlet.
+func (s *Server) handle(req *Request) *Response {
+	result, err := s.dispatch(req)
+	resp := &Response{ID: req.ID}
+	if err != nil {
+		var pluginErr *Error
+		if !errors.As(err, &pluginErr) {
+			pluginErr = &Error{Code: CodeInternal, Message: err.Error()}
+		}
+		resp.Error = pluginErr
+		return resp
+	}
+	if result == nil {
+		result = struct{}{}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeInternal, Message: err.Error()}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+func (s *Server) dispatch(req *Request) (any, error) {
+	switch req.Method {
+	case MethodHandshake:
+		var params HandshakeParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(params.ProtocolVersions, ProtocolVersion) {
+			return nil, &Error{Code: CodeUnsupportedVersion,
+				Message: fmt.Sprintf("plugin speaks protocol version %d, shim offered %v", ProtocolVersion, params.ProtocolVersions)}
+		}
+		return &HandshakeResult{ProtocolVersion: ProtocolVersion, Name: s.impl.ID(), Description: s.impl.Description()}, nil
+	case MethodInit:
+		var params InitParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.init(params.ConfigPath)
+	case MethodHealth:
+		return nil, nil
+	}
+
+	if !s.initialized() {
+		return nil, &Error{Code: CodeNotInitialized, Message: "init has not been called"}
+	}
+	switch req.Method {
+	case MethodApply:
+		var spec dto.RequirementSpec
+		if err := decodeParams(req.Params, &spec); err != nil {
+			return nil, err
+		}
+		return nil, s.impl.Apply(&spec)
+	case MethodDelete:
+		var params ResourceParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.impl.Delete(params.ResourceID)
+	case MethodStatus:
+		var params ResourceParams
+		if err := decodeParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.impl.Status(params.ResourceID)
+	case MethodList:
+		return s.impl.ListDeployedServices()
+	default:
+		return nil, &Error{Code: CodeUnknownMethod, Message: "unknown method: " + req.Method}
+	}
+}
+
+func (s *Server) init(configPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inited && s.configPath == configPath {
+		return nil
+	}
+	if err := s.impl.InitWithConfig(configPath); err != nil {
+		return err
+	}
+	s.inited = true
+	s.configPath = configPath
+	return nil
+}
+
+func (s *Server) initialized() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inited
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
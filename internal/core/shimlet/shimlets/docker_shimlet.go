@@ -0,0 +1,548 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/engine"
+	"astron-xmod-shim/internal/core/shimlet"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/docker"
+	"astron-xmod-shim/pkg/log"
+	"astron-xmod-shim/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ensure DockerShimlet implements the Shimlet interface at compile time
+var _ shimlet.Shimlet = (*DockerShimlet)(nil)
+
+func init() {
+	shimlet.Registry.AutoRegister(&DockerShimlet{})
+}
+
+const (
+	defaultDockerHostIP    = "127.0.0.1"
+	defaultPortRangeStart  = 30000
+	defaultPortRangeEnd    = 32767
+	defaultGPUDriver       = "nvidia"
+	dockerContainerPort    = 8000
+	dockerStopTimeout      = 30 * time.Second
+	dockerNetworkModeHost  = "host"
+	dockerManagedByLabel   = "managed-by"
+	dockerManagedByValue   = "astron-xmod-shim"
+	dockerServiceIDLabel   = "astron-xmod-shim/service-id"
+	dockerModelNameLabel   = "astron-xmod-shim/model-name"
+	dockerModelPathLabel   = "astron-xmod-shim/model-path"
+	dockerGoalSetLabel     = "astron-xmod-shim/goal-set-name"
+	dockerShimletNameLabel = "astron-xmod-shim/shimlet-name"
+	dockerAcceleratorLabel = "astron-xmod-shim/accelerator-type"
+	dockerHostPortLabel    = "astron-xmod-shim/host-port"
+	dockerEngineLabel      = "astron-xmod-shim/engine"
+	dockerEngineOptsLabel  = "astron-xmod-shim/engine-options"
+	dockerParallelismLabel = "astron-xmod-shim/parallelism"
+	dockerK8sOptionsLabel  = "astron-xmod-shim/k8s-options"
+	dockerEnvKeysLabel     = "astron-xmod-shim/env-keys"
+)
+
+// DockerShimlet deploys model servers as containers on a single host through the Docker Engine API.
+// Each service maps to one container labeled with its serviceId; the labels also record
+// the spec fields Status needs to rebuild the RequirementSpec for adoption.
+type DockerShimlet struct {
+	client *docker.DockerClient
+	conf   *cfg.DockerConfig
+
+	// portsMu serializes host port allocation with container creation
+	portsMu sync.Mutex
+}
+
+// ID returns the unique identifier for this shimlet.
+func (d *DockerShimlet) ID() string { return "docker" }
+
+// Description returns a brief description of the shimlet.
+func (d *DockerShimlet) Description() string { return "docker shimlet" }
+
+// InitWithConfig reads the Docker-specific config and creates the Engine API client.
+func (d *DockerShimlet) InitWithConfig(confPath string) error {
+	dockerCfg, err := config.GetConfFromFileDir[cfg.DockerConfig](confPath)
+	if err != nil {
+		return err
+	}
+	return d.initWithDockerConfig(dockerCfg)
+}
+
+// initWithDockerConfig applies defaults and creates the client.
+func (d *DockerShimlet) initWithDockerConfig(dockerCfg *cfg.DockerConfig) error {
+	if dockerCfg.HostIP == "" {
+		dockerCfg.HostIP = defaultDockerHostIP
+	}
+	if dockerCfg.PortRangeStart <= 0 || dockerCfg.PortRangeEnd < dockerCfg.PortRangeStart {
+		dockerCfg.PortRangeStart, dockerCfg.PortRangeEnd = defaultPortRangeStart, defaultPortRangeEnd
+	}
+	if dockerCfg.GPUDriver == "" {
+		dockerCfg.GPUDriver = defaultGPUDriver
+	}
+
+	client, err := docker.NewDockerClient(dockerCfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Docker client: %w", err)
+	}
+	d.client = client
+	d.conf = dockerCfg
+	return nil
+}
+
+// Apply (re)creates the container for the service.
+// Updates are rolled out blue/green: the new container is created under a temporary name on a
+// fresh host port and started before the old containers are removed, then renamed to the
+// regular name. If the new container cannot be created or started, the old one keeps serving.
+func (d *DockerShimlet) Apply(deploySpec *dto.RequirementSpec) error {
+	if d.client == nil {
+		return errors.New("docker client is not initialized")
+	}
+	modelDirPath, err := resolveModelDir(deploySpec.ModelFileDir)
+	if err != nil {
+		return err
+	}
+
+	existing, err := d.listServiceContainers(deploySpec.ServiceId)
+	if err != nil {
+		return err
+	}
+	containerName := "xmod-" + utils.ModelNameToDeploymentName(deploySpec.ModelName) + "-" + deploySpec.ServiceId
+	tempName := containerName
+	if len(existing) > 0 {
+		tempName = containerName + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	containerID, hostPort, err := d.createContainer(deploySpec, modelDirPath, tempName)
+	if err != nil {
+		return err
+	}
+	if err := d.client.StartContainer(containerID); err != nil {
+		if rmErr := d.client.RemoveContainer(containerID, true); rmErr != nil && !errors.Is(rmErr, docker.ErrNotFound) {
+			log.Warn("Failed to remove container %s that did not start: %v", containerID, rmErr)
+		}
+		return fmt.Errorf("failed to start container %s: %w", tempName, err)
+	}
+
+	for _, c := range existing {
+		if err := d.client.RemoveContainer(c.ID, true); err != nil && !errors.Is(err, docker.ErrNotFound) {
+			return fmt.Errorf("failed to remove old container %s: %w", c.ID, err)
+		}
+	}
+	if tempName != containerName {
+		// The container keeps serving under the temporary name if the rename fails;
+		// the next Apply replaces it like any other old container
+		if err := d.client.RenameContainer(containerID, containerName); err != nil {
+			log.Warn("Failed to rename container %s to %s: %v", tempName, containerName, err)
+		}
+	}
+
+	log.Info("Container %s (%s) started on host port %d", containerName, containerID, hostPort)
+	return nil
+}
+
+// createContainer allocates a host port and creates the container under portsMu, so that
+// concurrent Applies never pick the same port before either container carries its label.
+func (d *DockerShimlet) createContainer(deploySpec *dto.RequirementSpec, modelDirPath, name string) (string, int, error) {
+	d.portsMu.Lock()
+	defer d.portsMu.Unlock()
+
+	hostPort, err := d.allocatePort(deploySpec.ServiceId)
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := d.buildCreateRequest(deploySpec, modelDirPath, hostPort)
+	if err != nil {
+		return "", 0, err
+	}
+	containerID, err := d.client.CreateContainer(name, req)
+	if errors.Is(err, docker.ErrNotFound) {
+		// Image is not present locally: pull it and retry once
+		log.Info("Image %s not found locally, pulling", req.Image)
+		if pullErr := d.client.PullImage(req.Image); pullErr != nil {
+			return "", 0, pullErr
+		}
+		containerID, err = d.client.CreateContainer(name, req)
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create container %s: %w", name, err)
+	}
+	return containerID, hostPort, nil
+}
+
+// buildCreateRequest builds the container config for the inference engine named in the spec.
+func (d *DockerShimlet) buildCreateRequest(deploySpec *dto.RequirementSpec, modelDirPath string, hostPort int) (*docker.CreateContainerRequest, error) {
+	// With host networking the server listens on the allocated host port directly
+	containerPort := dockerContainerPort
+	if d.conf.Network == dockerNetworkModeHost {
+		containerPort = hostPort
+	}
+	portStr := strconv.Itoa(containerPort)
+
+	// An empty configured image falls back to the engine's image
+	rendered, err := engine.Registry.Render(deploySpec, engine.Target{
+		ModelDir: modelDirPath,
+		Port:     containerPort,
+		Image:    d.conf.Image,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rendered.Parallelism.MultiNode() {
+		return nil, fmt.Errorf("%w: multi-node deployments need the k8s shimlet", engine.ErrInvalidParallelism)
+	}
+
+	env := []string{
+		"MODEL=" + deploySpec.ModelName,
+		"SERVING_ENGINE=openai",
+		"PORT=" + portStr,
+		"SERVICE_ID=" + deploySpec.ServiceId,
+	}
+	if deploySpec.ContextLength > 0 {
+		env = append(env, "CONTEXT_LENGTH="+strconv.Itoa(deploySpec.ContextLength))
+	}
+	for _, e := range append(rendered.Env, deploySpec.Env...) {
+		env = append(env, e.Key+"="+e.Value)
+	}
+
+	labels := map[string]string{
+		dockerManagedByLabel:   dockerManagedByValue,
+		dockerServiceIDLabel:   deploySpec.ServiceId,
+		dockerModelNameLabel:   deploySpec.ModelName,
+		dockerModelPathLabel:   deploySpec.ModelFileDir,
+		dockerGoalSetLabel:     deploySpec.GoalSetName,
+		dockerShimletNameLabel: deploySpec.ShimletName,
+		dockerHostPortLabel:    strconv.Itoa(hostPort),
+		dockerEngineLabel:      rendered.Engine,
+	}
+	// Request options are recorded so Status reports the spec the container was created from;
+	// K8s overrides do not apply here but are kept so an unchanged spec is not re-applied
+	if err := encodeContainerLabel(labels, dockerEngineOptsLabel, deploySpec.EngineOptions); err != nil {
+		return nil, err
+	}
+	if err := encodeContainerLabel(labels, dockerParallelismLabel, deploySpec.Parallelism); err != nil {
+		return nil, err
+	}
+	if err := encodeContainerLabel(labels, dockerK8sOptionsLabel, deploySpec.K8s); err != nil {
+		return nil, err
+	}
+	// The daemon merges the image's ENV into the container env, so the user's keys are
+	// recorded to tell them apart in Status
+	envKeys := make([]string, 0, len(deploySpec.Env))
+	for _, e := range deploySpec.Env {
+		envKeys = append(envKeys, e.Key)
+	}
+	if err := encodeContainerLabel(labels, dockerEnvKeysLabel, &envKeys); err != nil {
+		return nil, err
+	}
+
+	hostConfig := docker.HostConfig{
+		Binds:         []string{modelDirPath + ":" + modelDirPath + ":ro"}, // Mount at the same path used in --model
+		RestartPolicy: docker.RestartPolicy{Name: "unless-stopped"},
+		NetworkMode:   d.conf.Network,
+	}
+	req := &docker.CreateContainerRequest{
+		ContainerConfig: docker.ContainerConfig{
+			Image:      rendered.Image,
+			Entrypoint: rendered.Command,
+			Cmd:        rendered.Args,
+			Env:        env,
+			Labels:     labels,
+		},
+	}
+	if d.conf.Network != dockerNetworkModeHost {
+		containerPortKey := portStr + "/tcp"
+		req.ExposedPorts = map[string]struct{}{containerPortKey: {}}
+		hostConfig.PortBindings = map[string][]docker.PortBinding{
+			containerPortKey: {{HostPort: strconv.Itoa(hostPort)}},
+		}
+	}
+
+	if rr := deploySpec.ResourceRequirements; rr != nil && rr.AcceleratorCount > 0 {
+		labels[dockerAcceleratorLabel] = rr.AcceleratorType
+		hostConfig.DeviceRequests = []docker.DeviceRequest{{
+			Driver:       d.conf.GPUDriver,
+			Count:        rr.AcceleratorCount,
+			Capabilities: [][]string{{"gpu"}},
+		}}
+	}
+	req.HostConfig = hostConfig
+	return req, nil
+}
+
+// allocatePort picks a host port derived from the serviceId, probing forward past ports
+// already used by managed containers (including the service's own old container during a
+// replacement). The same serviceId gets the same port whenever it is free. Callers hold portsMu.
+func (d *DockerShimlet) allocatePort(serviceID string) (int, error) {
+	containers, err := d.client.ListContainers([]string{dockerManagedByLabel + "=" + dockerManagedByValue}, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+	used := make(map[int]struct{}, len(containers))
+	for _, c := range containers {
+		if port, err := strconv.Atoi(c.Labels[dockerHostPortLabel]); err == nil {
+			used[port] = struct{}{}
+		}
+		for _, p := range c.Ports {
+			if p.PublicPort != 0 {
+				used[p.PublicPort] = struct{}{}
+			}
+		}
+	}
+
+	size := d.conf.PortRangeEnd - d.conf.PortRangeStart + 1
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(serviceID))
+	offset := int(h.Sum32() % uint32(size))
+	for i := 0; i < size; i++ {
+		port := d.conf.PortRangeStart + (offset+i)%size
+		if _, taken := used[port]; !taken {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free host port in range %d-%d", d.conf.PortRangeStart, d.conf.PortRangeEnd)
+}
+
+// listServiceContainers lists all containers (including stopped ones) of the service.
+func (d *DockerShimlet) listServiceContainers(serviceID string) ([]docker.ContainerSummary, error) {
+	containers, err := d.client.ListContainers([]string{
+		dockerManagedByLabel + "=" + dockerManagedByValue,
+		dockerServiceIDLabel + "=" + serviceID,
+	}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers for service %s: %w", serviceID, err)
+	}
+	return containers, nil
+}
+
+// Delete stops and removes the containers of the service. Missing containers count as deleted.
+func (d *DockerShimlet) Delete(resourceId string) error {
+	if d.client == nil {
+		return errors.New("docker client is not initialized")
+	}
+	containers, err := d.listServiceContainers(resourceId)
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		log.Info("No containers found for service %s", resourceId)
+		return nil
+	}
+
+	var errs []error
+	for _, c := range containers {
+		if err := d.client.StopContainer(c.ID, dockerStopTimeout); err != nil && !errors.Is(err, docker.ErrNotFound) {
+			log.Warn("Failed to stop container %s: %v", c.ID, err)
+		}
+		if err := d.client.RemoveContainer(c.ID, true); err != nil && !errors.Is(err, docker.ErrNotFound) {
+			log.Error("Failed to remove container %s: %v", c.ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("Successfully removed container %s of service %s", c.ID, resourceId)
+	}
+	return errors.Join(errs...)
+}
+
+// Status maps the container state to a DeployPhase and rebuilds the spec from labels and env.
+func (d *DockerShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
+	if d.client == nil {
+		return nil, errors.New("docker client is not initialized")
+	}
+	containers, err := d.listServiceContainers(resourceId)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return &dto.RuntimeStatus{
+			DeploySpec: dto.RequirementSpec{ServiceId: resourceId},
+			Status:     dto.PhaseUnknown,
+		}, nil
+	}
+
+	// During a replacement both the old and the new container exist; report the running one
+	current := containers[0]
+	for _, c := range containers {
+		if c.State == "running" {
+			current = c
+			break
+		}
+	}
+	inspect, err := d.client.InspectContainer(current.ID)
+	if errors.Is(err, docker.ErrNotFound) {
+		// Removed between list and inspect
+		return &dto.RuntimeStatus{
+			DeploySpec: dto.RequirementSpec{ServiceId: resourceId},
+			Status:     dto.PhaseUnknown,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", current.ID, err)
+	}
+
+	phase := dockerPhase(inspect.State)
+	labels := inspect.Config.Labels
+
+	var endpoint string
+	if phase == dto.PhaseRunning && labels[dockerHostPortLabel] != "" {
+		endpoint = fmt.Sprintf("http://%s:%s", d.conf.HostIP, labels[dockerHostPortLabel])
+	}
+
+	spec := dto.RequirementSpec{
+		ServiceId:    resourceId,
+		ModelName:    labelOrUnknown(labels, dockerModelNameLabel),
+		ModelFileDir: labelOrUnknown(labels, dockerModelPathLabel),
+		ReplicaCount: 1,
+		GoalSetName:  labels[dockerGoalSetLabel],
+		ShimletName:  labels[dockerShimletNameLabel],
+		Engine:       labels[dockerEngineLabel],
+	}
+	decodeContainerLabel(labels, dockerEngineOptsLabel, current.ID, &spec.EngineOptions)
+	decodeContainerLabel(labels, dockerParallelismLabel, current.ID, &spec.Parallelism)
+	decodeContainerLabel(labels, dockerK8sOptionsLabel, current.ID, &spec.K8s)
+	if spec.GoalSetName == "" {
+		spec.GoalSetName = "opensource-llm-deploy"
+	}
+	if spec.ShimletName == "" {
+		spec.ShimletName = d.ID()
+	}
+	for _, req := range inspect.HostConfig.DeviceRequests {
+		if req.Count > 0 {
+			spec.ResourceRequirements = &dto.ResourceRequirements{
+				AcceleratorType:  labels[dockerAcceleratorLabel],
+				AcceleratorCount: req.Count,
+			}
+			break
+		}
+	}
+	var envKeys *[]string
+	decodeContainerLabel(labels, dockerEnvKeysLabel, current.ID, &envKeys)
+	containerEnv := make(map[string]string, len(inspect.Config.Env))
+	for _, kv := range inspect.Config.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		containerEnv[key] = value
+	}
+	if val, err := strconv.Atoi(containerEnv["CONTEXT_LENGTH"]); err == nil {
+		spec.ContextLength = val
+	}
+	if envKeys != nil {
+		for _, key := range *envKeys {
+			if value, ok := containerEnv[key]; ok {
+				spec.Env = append(spec.Env, dto.Env{Key: key, Value: value})
+			}
+		}
+	} else {
+		spec.Env = legacyContainerEnv(inspect.Config.Env)
+	}
+
+	return &dto.RuntimeStatus{
+		DeploySpec: spec,
+		Status:     phase,
+		EndPoint:   endpoint,
+	}, nil
+}
+
+// dockerPhase maps a container state to a DeployPhase.
+func dockerPhase(state docker.ContainerState) dto.DeployPhase {
+	switch state.Status {
+	case "running":
+		return dto.PhaseRunning
+	case "created", "restarting", "paused":
+		return dto.PhasePending
+	case "removing":
+		return dto.PhaseTerminating
+	case "exited", "dead":
+		if state.ExitCode == 0 && state.Error == "" {
+			return dto.PhaseTerminated
+		}
+		return dto.PhaseFailed
+	default:
+		return dto.PhaseUnknown
+	}
+}
+
+// legacyContainerEnv rebuilds the user env of containers created before the env keys were
+// recorded: everything except what Apply injects. Image ENV cannot be told apart here.
+func legacyContainerEnv(containerEnv []string) []dto.Env {
+	var env []dto.Env
+	engineEnvNames := engine.Registry.BuiltinEnvNames()
+	for _, kv := range containerEnv {
+		key, value, _ := strings.Cut(kv, "=")
+		_, builtin := builtinEnvNames[key]
+		_, engineEnv := engineEnvNames[key]
+		if builtin || engineEnv || key == "CONTEXT_LENGTH" {
+			// Injected by Apply, not part of the user's spec
+			continue
+		}
+		env = append(env, dto.Env{Key: key, Value: value})
+	}
+	return env
+}
+
+// labelOrUnknown returns the label value, or "unknown" when it was not recorded.
+func labelOrUnknown(labels map[string]string, key string) string {
+	if val := labels[key]; val != "" {
+		return val
+	}
+	return "unknown"
+}
+
+// encodeContainerLabel records value as a JSON label; nil values are left out.
+func encodeContainerLabel[T any](labels map[string]string, key string, value *T) error {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	labels[key] = string(data)
+	return nil
+}
+
+// decodeContainerLabel decodes a JSON label into *target, leaving it nil when the label is
+// missing or malformed.
+func decodeContainerLabel[T any](labels map[string]string, key, containerID string, target **T) {
+	val := labels[key]
+	if val == "" {
+		return
+	}
+	decoded := new(T)
+	if err := json.Unmarshal([]byte(val), decoded); err != nil {
+		log.Warn("Failed to decode %s of container %s: %v", key, containerID, err)
+		return
+	}
+	*target = decoded
+}
+
+// ListDeployedServices 获取所有由 astron-xmod-shim 管理的容器对应的 serviceId
+func (d *DockerShimlet) ListDeployedServices() ([]string, error) {
+	if d.client == nil {
+		return []string{}, errors.New("docker client is not initialized")
+	}
+	containers, err := d.client.ListContainers([]string{dockerManagedByLabel + "=" + dockerManagedByValue}, true)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(containers))
+	serviceIDs := make([]string, 0, len(containers))
+	for _, c := range containers {
+		serviceID := c.Labels[dockerServiceIDLabel]
+		if serviceID == "" {
+			continue
+		}
+		if _, ok := seen[serviceID]; ok {
+			continue
+		}
+		seen[serviceID] = struct{}{}
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	return serviceIDs, nil
+}
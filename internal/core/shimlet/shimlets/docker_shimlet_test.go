@@ -0,0 +1,313 @@
+package shimlets
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/docker"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&cfg.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// fakeEngine is an in-memory Docker Engine API covering the endpoints DockerShimlet uses.
+type fakeEngine struct {
+	mu         sync.Mutex
+	containers map[string]*docker.ContainerInspect
+	images     map[string]bool
+	// imageEnv is the image's ENV, merged into every created container like the daemon does
+	imageEnv []string
+	nextID   int
+	// failStart makes container starts fail, e.g. when the host port is taken
+	failStart bool
+}
+
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{
+		containers: make(map[string]*docker.ContainerInspect),
+		images:     make(map[string]bool),
+		imageEnv:   []string{"PATH=/usr/local/bin:/usr/bin", "CUDA_VERSION=12.4.1", "HF_HOME=/root/.cache/huggingface"},
+	}
+}
+
+func (f *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Strip the API version prefix, e.g. /v1.43/containers/json
+	path := r.URL.Path
+	if parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2); len(parts) == 2 && strings.HasPrefix(parts[0], "v1.") {
+		path = "/" + parts[1]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "/containers/json":
+		var filters map[string][]string
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		result := []docker.ContainerSummary{}
+		for _, c := range f.containers {
+			if matchLabels(c.Config.Labels, filters["label"]) {
+				result = append(result, docker.ContainerSummary{ID: c.ID, Names: []string{c.Name}, Labels: c.Config.Labels, State: c.State.Status})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		var req docker.CreateContainerRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !f.images[req.Image] {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such image"}`))
+			return
+		}
+		req.ContainerConfig.Env = mergeEnv(f.imageEnv, req.ContainerConfig.Env)
+		f.nextID++
+		id := "c" + string(rune('0'+f.nextID))
+		f.containers[id] = &docker.ContainerInspect{
+			ID:         id,
+			Name:       "/" + r.URL.Query().Get("name"),
+			State:      docker.ContainerState{Status: "created"},
+			Config:     req.ContainerConfig,
+			HostConfig: req.HostConfig,
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"` + id + `"}`))
+	case r.Method == http.MethodPost && path == "/images/create":
+		f.images[r.URL.Query().Get("fromImage")+":"+r.URL.Query().Get("tag")] = true
+		_, _ = w.Write([]byte(`{"status":"Downloaded"}` + "\n"))
+	default:
+		segments := strings.Split(strings.TrimPrefix(path, "/containers/"), "/")
+		c, ok := f.containers[segments[0]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container"}`))
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && len(segments) == 2 && segments[1] == "json":
+			_ = json.NewEncoder(w).Encode(c)
+		case r.Method == http.MethodPost && len(segments) == 2 && segments[1] == "start" && f.failStart:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"port is already allocated"}`))
+		case r.Method == http.MethodPost && len(segments) == 2 && segments[1] == "rename":
+			c.Name = "/" + r.URL.Query().Get("name")
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && len(segments) == 2 && segments[1] == "start":
+			c.State = docker.ContainerState{Status: "running", Running: true}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && len(segments) == 2 && segments[1] == "stop":
+			c.State = docker.ContainerState{Status: "exited"}
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && len(segments) == 1:
+			delete(f.containers, c.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}
+}
+
+// mergeEnv appends the container env to the image env, replacing keys set by both.
+func mergeEnv(imageEnv, containerEnv []string) []string {
+	overridden := make(map[string]struct{}, len(containerEnv))
+	for _, kv := range containerEnv {
+		key, _, _ := strings.Cut(kv, "=")
+		overridden[key] = struct{}{}
+	}
+	var merged []string
+	for _, kv := range imageEnv {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := overridden[key]; !ok {
+			merged = append(merged, kv)
+		}
+	}
+	return append(merged, containerEnv...)
+}
+
+func matchLabels(labels map[string]string, selectors []string) bool {
+	for _, selector := range selectors {
+		key, value, _ := strings.Cut(selector, "=")
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func newTestDockerShimlet(t *testing.T, engine *fakeEngine) *DockerShimlet {
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	d := &DockerShimlet{}
+	require.NoError(t, d.initWithDockerConfig(&cfg.DockerConfig{
+		Host:           server.URL,
+		Image:          "vllm:test",
+		HostIP:         "10.0.0.1",
+		PortRangeStart: 31000,
+		PortRangeEnd:   31002,
+	}))
+	return d
+}
+
+// 测试部署、状态查询、重新部署保持端口与删除的完整流程
+func TestDockerShimlet_Lifecycle(t *testing.T) {
+	engine := newFakeEngine()
+	d := newTestDockerShimlet(t, engine)
+
+	spec := &dto.RequirementSpec{
+		ServiceId:            "svc1",
+		ModelName:            "qwen",
+		ModelFileDir:         "/models/qwen/model.safetensors",
+		ReplicaCount:         1,
+		GoalSetName:          "opensource-llm-deploy",
+		ShimletName:          "docker",
+		ContextLength:        4096,
+		Env:                  []dto.Env{{Key: "FOO", Value: "bar"}},
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorType: "nvidia.com/gpu", AcceleratorCount: 2},
+	}
+	// Image is pulled on first create
+	require.NoError(t, d.Apply(spec))
+	assert.True(t, engine.images["vllm:test"])
+	require.Len(t, engine.containers, 1)
+
+	var created *docker.ContainerInspect
+	for _, c := range engine.containers {
+		created = c
+	}
+	assert.Equal(t, []string{"/models/qwen:/models/qwen:ro"}, created.HostConfig.Binds)
+	require.Len(t, created.HostConfig.DeviceRequests, 1)
+	assert.Equal(t, 2, created.HostConfig.DeviceRequests[0].Count)
+	port := created.Config.Labels[dockerHostPortLabel]
+
+	status, err := d.Status("svc1")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	assert.Equal(t, "http://10.0.0.1:"+port, status.EndPoint)
+	assert.Equal(t, "qwen", status.DeploySpec.ModelName)
+	assert.Equal(t, 4096, status.DeploySpec.ContextLength)
+	assert.Equal(t, []dto.Env{{Key: "FOO", Value: "bar"}}, status.DeploySpec.Env)
+	assert.Equal(t, spec.ResourceRequirements, status.DeploySpec.ResourceRequirements)
+
+	// A second service gets another port in the range
+	other := *spec
+	other.ServiceId = "svc2"
+	require.NoError(t, d.Apply(&other))
+	otherStatus, err := d.Status("svc2")
+	require.NoError(t, err)
+	assert.NotEqual(t, status.EndPoint, otherStatus.EndPoint)
+
+	// Re-applying starts the replacement on a free port before removing the old container,
+	// then renames it to the regular name
+	require.NoError(t, d.Apply(spec))
+	assert.Len(t, engine.containers, 2)
+	status, err = d.Status("svc1")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	assert.NotEqual(t, "http://10.0.0.1:"+port, status.EndPoint)
+	assert.NotEqual(t, otherStatus.EndPoint, status.EndPoint)
+	containers, err := d.listServiceContainers("svc1")
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, []string{"/xmod-qwen-svc1"}, containers[0].Names)
+
+	ids, err := d.ListDeployedServices()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"svc1", "svc2"}, ids)
+
+	require.NoError(t, d.Delete("svc1"))
+	status, err = d.Status("svc1")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseUnknown, status.Status)
+	require.NoError(t, d.Delete("svc1"))
+}
+
+// 测试替换容器启动失败时删除新容器，旧容器继续提供服务
+// 测试镜像 ENV 被合并进容器 env 时，Status 只上报用户设置的 env
+func TestDockerShimlet_StatusIgnoresImageEnv(t *testing.T) {
+	engine := newFakeEngine()
+	d := newTestDockerShimlet(t, engine)
+
+	spec := &dto.RequirementSpec{
+		ServiceId:    "svc1",
+		ModelName:    "qwen",
+		ModelFileDir: "/models/qwen",
+		ReplicaCount: 1,
+		// HF_HOME is both set by the image and injected by the engine
+		Env: []dto.Env{{Key: "FOO", Value: "bar"}, {Key: "HF_HOME", Value: "/data/hf"}},
+	}
+	require.NoError(t, d.Apply(spec))
+
+	status, err := d.Status("svc1")
+	require.NoError(t, err)
+	assert.Equal(t, spec.Env, status.DeploySpec.Env)
+
+	// No user env: nothing from the image is reported
+	spec.Env = nil
+	require.NoError(t, d.Apply(spec))
+	status, err = d.Status("svc1")
+	require.NoError(t, err)
+	assert.Empty(t, status.DeploySpec.Env)
+}
+
+func TestDockerShimlet_ReplaceKeepsOldOnStartFailure(t *testing.T) {
+	engine := newFakeEngine()
+	engine.images["vllm:test"] = true
+	d := newTestDockerShimlet(t, engine)
+
+	spec := &dto.RequirementSpec{ServiceId: "svc1", ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1}
+	require.NoError(t, d.Apply(spec))
+	before, err := d.Status("svc1")
+	require.NoError(t, err)
+
+	engine.failStart = true
+	spec.ContextLength = 8192
+	require.Error(t, d.Apply(spec))
+	require.Len(t, engine.containers, 1)
+	after, err := d.Status("svc1")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, after.Status)
+	assert.Equal(t, before.EndPoint, after.EndPoint)
+	assert.Equal(t, 0, after.DeploySpec.ContextLength)
+}
+
+// 测试并发部署不同服务时分配到不同的主机端口
+func TestDockerShimlet_ConcurrentPortAllocation(t *testing.T) {
+	engine := newFakeEngine()
+	engine.images["vllm:test"] = true
+	d := newTestDockerShimlet(t, engine)
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"svc1", "svc2", "svc3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, d.Apply(&dto.RequirementSpec{ServiceId: id, ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1}))
+		}()
+	}
+	wg.Wait()
+
+	ports := map[string]struct{}{}
+	for _, c := range engine.containers {
+		ports[c.Config.Labels[dockerHostPortLabel]] = struct{}{}
+	}
+	assert.Len(t, ports, 3)
+}
+
+// 测试容器状态到部署阶段的映射
+func TestDockerPhase(t *testing.T) {
+	assert.Equal(t, dto.PhasePending, dockerPhase(docker.ContainerState{Status: "restarting"}))
+	assert.Equal(t, dto.PhaseFailed, dockerPhase(docker.ContainerState{Status: "exited", ExitCode: 1}))
+	assert.Equal(t, dto.PhaseTerminated, dockerPhase(docker.ContainerState{Status: "exited"}))
+}
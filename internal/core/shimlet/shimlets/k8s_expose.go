@@ -0,0 +1,725 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"astron-xmod-shim/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+	networkingv1apply "k8s.io/client-go/applyconfigurations/networking/v1"
+)
+
+// Exposure modes of a K8sShimlet service.
+const (
+	K8sExposeClusterIP   = "cluster-ip"   // ClusterIP Service, reachable inside the cluster
+	K8sExposeNodePort    = "node-port"    // NodePort Service on an allocated port
+	K8sExposeIngress     = "ingress"      // ClusterIP Service behind an Ingress
+	K8sExposeGateway     = "gateway"      // ClusterIP Service behind a Gateway API HTTPRoute
+	K8sExposeHostNetwork = "host-network" // Pods listen on an allocated port of their node
+)
+
+const (
+	defaultK8sExposeMode    = K8sExposeHostNetwork
+	defaultK8sServicePort   = 8000
+	defaultK8sPortStart     = 30000
+	defaultK8sPortEnd       = 32767
+	defaultK8sExposePath    = "/"
+	k8sExposeModeAnnotation = "astron-xmod-shim/expose-mode"
+	// k8sPortAnnotation records the allocated host port or node port
+	k8sPortAnnotation  = "astron-xmod-shim/port"
+	httpRouteAPIPrefix = "/apis/gateway.networking.k8s.io/v1"
+)
+
+// Kinds of the resources created to expose a service.
+const (
+	exposeKindService   = "Service"
+	exposeKindIngress   = "Ingress"
+	exposeKindHTTPRoute = "HTTPRoute"
+)
+
+// mergeK8sExpose overlays the non-empty fields of override on defaults.
+func mergeK8sExpose(defaults, override *dto.K8sExpose) *dto.K8sExpose {
+	expose := &dto.K8sExpose{}
+	if defaults != nil {
+		*expose = *defaults
+	}
+	if override == nil {
+		return expose
+	}
+	if override.Mode != "" {
+		expose.Mode = override.Mode
+	}
+	if override.Port != 0 {
+		expose.Port = override.Port
+	}
+	if override.PortRangeStart != 0 {
+		expose.PortRangeStart = override.PortRangeStart
+	}
+	if override.PortRangeEnd != 0 {
+		expose.PortRangeEnd = override.PortRangeEnd
+	}
+	if override.NodeAddress != "" {
+		expose.NodeAddress = override.NodeAddress
+	}
+	if override.Host != "" {
+		expose.Host = override.Host
+	}
+	if override.Path != "" {
+		expose.Path = override.Path
+	}
+	if override.IngressClassName != "" {
+		expose.IngressClassName = override.IngressClassName
+	}
+	if override.TLSSecretName != "" {
+		expose.TLSSecretName = override.TLSSecretName
+	}
+	if override.GatewayName != "" {
+		expose.GatewayName = override.GatewayName
+	}
+	if override.GatewayNamespace != "" {
+		expose.GatewayNamespace = override.GatewayNamespace
+	}
+	return expose
+}
+
+// completeK8sExpose fills in the built-in defaults and validates the exposure.
+func completeK8sExpose(expose *dto.K8sExpose) error {
+	switch expose.Mode {
+	case "":
+		expose.Mode = defaultK8sExposeMode
+	case K8sExposeClusterIP, K8sExposeNodePort, K8sExposeIngress, K8sExposeGateway, K8sExposeHostNetwork:
+	default:
+		return fmt.Errorf("unsupported expose mode: %s", expose.Mode)
+	}
+	if expose.Port == 0 {
+		expose.Port = defaultK8sServicePort
+	}
+	if expose.PortRangeStart == 0 {
+		expose.PortRangeStart = defaultK8sPortStart
+	}
+	if expose.PortRangeEnd == 0 {
+		expose.PortRangeEnd = defaultK8sPortEnd
+	}
+	if expose.Path == "" {
+		expose.Path = defaultK8sExposePath
+	}
+	if expose.Port < 1 || expose.Port > 65535 {
+		return fmt.Errorf("invalid expose port: %d", expose.Port)
+	}
+	if expose.PortRangeStart < 1 || expose.PortRangeEnd > 65535 || expose.PortRangeStart > expose.PortRangeEnd {
+		return fmt.Errorf("invalid port range: %d-%d", expose.PortRangeStart, expose.PortRangeEnd)
+	}
+	if !strings.HasPrefix(expose.Path, "/") {
+		return fmt.Errorf("expose path must start with /: %s", expose.Path)
+	}
+	if expose.Mode == K8sExposeGateway && expose.GatewayName == "" {
+		return fmt.Errorf("expose mode %s requires gateway-name", K8sExposeGateway)
+	}
+	return nil
+}
+
+// exposeName names the Service, Ingress and HTTPRoute of a service.
+// Service IDs may start with a digit, which DNS-1035 names do not allow.
+func exposeName(serviceID string) string {
+	return utils.ModelNameToDeploymentName("xmod-" + serviceID)
+}
+
+// exposeHost renders the ingress / gateway host for the service.
+func exposeHost(expose *dto.K8sExpose, serviceID, namespace string) string {
+	return strings.NewReplacer(
+		"{{service_id}}", serviceID,
+		"{{namespace}}", namespace,
+	).Replace(expose.Host)
+}
+
+// usesService reports whether the mode exposes the Pods through a Service.
+func usesService(mode string) bool { return mode != K8sExposeHostNetwork }
+
+// allocatesPort reports whether the mode needs a port from the allocation range.
+func allocatesPort(mode string) bool {
+	return mode == K8sExposeNodePort || mode == K8sExposeHostNetwork
+}
+
+// pickPort returns the first free port probing from a position derived from the service ID,
+// so the same service gets the same port as long as it is free.
+func pickPort(serviceID string, used map[int]struct{}, start, end int) (int, error) {
+	size := end - start + 1
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(serviceID))
+	offset := int(h.Sum32() % uint32(size))
+	for i := 0; i < size; i++ {
+		port := start + (offset+i)%size
+		if _, taken := used[port]; !taken {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port in range %d-%d", start, end)
+}
+
+// allocatePort returns the host port or node port of the service. A port recorded on the
+// service's deployment for the same mode is kept; otherwise a free port is picked, avoiding
+// ports recorded on other deployments, node ports of all Services and ports reserved by
+// applies the informer has not observed yet.
+func (k *K8sShimlet) allocatePort(serviceID, namespace string, expose *dto.K8sExpose) (int, error) {
+	k.portsMu.Lock()
+	defer k.portsMu.Unlock()
+
+	selector := labels.Set{"app": serviceID}.AsSelector().String()
+	workloads, err := k.listWorkloads(namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workloads for service %s: %w", serviceID, err)
+	}
+	for _, w := range workloads {
+		if w.annotations[k8sExposeModeAnnotation] != expose.Mode {
+			continue
+		}
+		port, err := strconv.Atoi(w.annotations[k8sPortAnnotation])
+		if err == nil && port >= expose.PortRangeStart && port <= expose.PortRangeEnd && k.reserveLocked(serviceID, port) {
+			return port, nil
+		}
+	}
+
+	used, err := k.usedPortsLocked(serviceID)
+	if err != nil {
+		return 0, err
+	}
+	port, err := pickPort(serviceID, used, expose.PortRangeStart, expose.PortRangeEnd)
+	if err != nil {
+		return 0, err
+	}
+	k.reserveLocked(serviceID, port)
+	log.Info("Allocated port %d for service %s (%s)", port, serviceID, expose.Mode)
+	return port, nil
+}
+
+// reserveLocked records port as owned by the service, dropping the service's previous port.
+// It fails if another service holds the port.
+func (k *K8sShimlet) reserveLocked(serviceID string, port int) bool {
+	if owner, ok := k.ports[port]; ok && owner != serviceID {
+		return false
+	}
+	k.releasePortsLocked(serviceID)
+	if k.ports == nil {
+		k.ports = make(map[int]string)
+	}
+	k.ports[port] = serviceID
+	return true
+}
+
+// releasePorts forgets the ports reserved by the service.
+func (k *K8sShimlet) releasePorts(serviceID string) {
+	k.portsMu.Lock()
+	defer k.portsMu.Unlock()
+	k.releasePortsLocked(serviceID)
+}
+
+func (k *K8sShimlet) releasePortsLocked(serviceID string) {
+	for port, owner := range k.ports {
+		if owner == serviceID {
+			delete(k.ports, port)
+		}
+	}
+}
+
+// usedPortsLocked collects the ports taken by other services.
+func (k *K8sShimlet) usedPortsLocked(serviceID string) (map[int]struct{}, error) {
+	used := make(map[int]struct{})
+	for port, owner := range k.ports {
+		if owner != serviceID {
+			used[port] = struct{}{}
+		}
+	}
+
+	managed := metav1.ListOptions{LabelSelector: labels.Set{"managed-by": "astron-xmod-shim"}.AsSelector().String()}
+	workloads, err := k.listWorkloads(metav1.NamespaceAll, managed)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workloads {
+		if w.labels["app"] == serviceID {
+			continue
+		}
+		if port, err := strconv.Atoi(w.annotations[k8sPortAnnotation]); err == nil {
+			used[port] = struct{}{}
+		}
+	}
+
+	// Node ports are opened on every node, so Services not managed by the shim count as well
+	services, err := k.client.GetClientSet().CoreV1().Services(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, svc := range services.Items {
+		if svc.Labels["app"] == serviceID && svc.Labels["managed-by"] == "astron-xmod-shim" {
+			continue
+		}
+		for _, p := range svc.Spec.Ports {
+			if p.NodePort != 0 {
+				used[int(p.NodePort)] = struct{}{}
+			}
+		}
+	}
+	return used, nil
+}
+
+// buildExposeService renders the Service selecting the service's Pods.
+func buildExposeService(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, workloadLabels map[string]string, nodePort int) *corev1apply.ServiceApplyConfiguration {
+	port := corev1apply.ServicePort().
+		WithName("http").
+		WithProtocol(corev1.ProtocolTCP).
+		WithPort(int32(opts.Expose.Port)).
+		WithTargetPort(intstr.FromString("http"))
+	serviceType := corev1.ServiceTypeClusterIP
+	if opts.Expose.Mode == K8sExposeNodePort {
+		serviceType = corev1.ServiceTypeNodePort
+		port.WithNodePort(int32(nodePort))
+	}
+
+	svc := corev1apply.Service(exposeName(deploySpec.ServiceId), opts.Namespace).
+		WithLabels(workloadLabels).
+		WithSpec(corev1apply.ServiceSpec().
+			WithType(serviceType).
+			WithSelector(exposeSelector(deploySpec)).
+			WithPorts(port))
+	if len(opts.Annotations) > 0 {
+		svc.WithAnnotations(opts.Annotations)
+	}
+	return svc
+}
+
+// buildExposeIngress renders the Ingress routing to the service's Service.
+func buildExposeIngress(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, workloadLabels map[string]string) *networkingv1apply.IngressApplyConfiguration {
+	name := exposeName(deploySpec.ServiceId)
+	host := exposeHost(opts.Expose, deploySpec.ServiceId, opts.Namespace)
+
+	rule := networkingv1apply.IngressRule().
+		WithHTTP(networkingv1apply.HTTPIngressRuleValue().
+			WithPaths(networkingv1apply.HTTPIngressPath().
+				WithPath(opts.Expose.Path).
+				WithPathType(networkingv1.PathTypePrefix).
+				WithBackend(networkingv1apply.IngressBackend().
+					WithService(networkingv1apply.IngressServiceBackend().
+						WithName(name).
+						WithPort(networkingv1apply.ServiceBackendPort().WithNumber(int32(opts.Expose.Port)))))))
+	if host != "" {
+		rule.WithHost(host)
+	}
+
+	spec := networkingv1apply.IngressSpec().WithRules(rule)
+	if opts.Expose.IngressClassName != "" {
+		spec.WithIngressClassName(opts.Expose.IngressClassName)
+	}
+	if opts.Expose.TLSSecretName != "" {
+		tls := networkingv1apply.IngressTLS().WithSecretName(opts.Expose.TLSSecretName)
+		if host != "" {
+			tls.WithHosts(host)
+		}
+		spec.WithTLS(tls)
+	}
+
+	ingress := networkingv1apply.Ingress(name, opts.Namespace).
+		WithLabels(workloadLabels).
+		WithSpec(spec)
+	if len(opts.Annotations) > 0 {
+		ingress.WithAnnotations(opts.Annotations)
+	}
+	return ingress
+}
+
+// buildHTTPRoute renders the Gateway API HTTPRoute routing to the service's Service.
+// The Gateway API types are not vendored, so the route is built as an unstructured object.
+func buildHTTPRoute(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, workloadLabels map[string]string) map[string]any {
+	name := exposeName(deploySpec.ServiceId)
+	parentRef := map[string]any{"name": opts.Expose.GatewayName}
+	if opts.Expose.GatewayNamespace != "" {
+		parentRef["namespace"] = opts.Expose.GatewayNamespace
+	}
+	spec := map[string]any{
+		"parentRefs": []any{parentRef},
+		"rules": []any{map[string]any{
+			"matches": []any{map[string]any{
+				"path": map[string]any{"type": "PathPrefix", "value": opts.Expose.Path},
+			}},
+			"backendRefs": []any{map[string]any{"name": name, "port": opts.Expose.Port}},
+		}},
+	}
+	if host := exposeHost(opts.Expose, deploySpec.ServiceId, opts.Namespace); host != "" {
+		spec["hostnames"] = []any{host}
+	}
+	metadata := map[string]any{
+		"name":      name,
+		"namespace": opts.Namespace,
+		"labels":    workloadLabels,
+	}
+	if len(opts.Annotations) > 0 {
+		metadata["annotations"] = opts.Annotations
+	}
+	return map[string]any{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       exposeKindHTTPRoute,
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+}
+
+func httpRoutePath(namespace, name string) string {
+	if name == "" {
+		return fmt.Sprintf("%s/namespaces/%s/httproutes", httpRouteAPIPrefix, namespace)
+	}
+	return fmt.Sprintf("%s/namespaces/%s/httproutes/%s", httpRouteAPIPrefix, namespace, name)
+}
+
+// applyExposure creates or updates the resources the exposure mode needs and removes the
+// ones a previous mode or namespace left behind. Multi-node deployments also get the Ray
+// headless Service.
+func (k *K8sShimlet) applyExposure(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, workloadLabels map[string]string, nodePort int) error {
+	ctx := context.Background()
+	applyOpts := metav1.ApplyOptions{FieldManager: "astron-xmod-shim", Force: true}
+	mode := opts.Expose.Mode
+	keep := map[string]bool{}
+
+	if parallelism, err := engine.ResolveParallelism(deploySpec); err == nil && parallelism.MultiNode() {
+		svc := buildRayService(deploySpec, opts, workloadLabels)
+		if _, err := k.client.GetClientSet().CoreV1().Services(opts.Namespace).Apply(ctx, svc, applyOpts); err != nil {
+			return fmt.Errorf("failed to apply ray service: %w", err)
+		}
+		keep[exposeKindRayService] = true
+	}
+
+	if usesService(mode) {
+		svc := buildExposeService(deploySpec, opts, workloadLabels, nodePort)
+		if _, err := k.client.GetClientSet().CoreV1().Services(opts.Namespace).Apply(ctx, svc, applyOpts); err != nil {
+			return fmt.Errorf("failed to apply service: %w", err)
+		}
+		keep[exposeKindService] = true
+	}
+	switch mode {
+	case K8sExposeIngress:
+		ingress := buildExposeIngress(deploySpec, opts, workloadLabels)
+		if _, err := k.client.GetClientSet().NetworkingV1().Ingresses(opts.Namespace).Apply(ctx, ingress, applyOpts); err != nil {
+			return fmt.Errorf("failed to apply ingress: %w", err)
+		}
+		keep[exposeKindIngress] = true
+	case K8sExposeGateway:
+		data, err := json.Marshal(buildHTTPRoute(deploySpec, opts, workloadLabels))
+		if err != nil {
+			return fmt.Errorf("failed to encode httproute: %w", err)
+		}
+		err = k.client.GetClientSet().CoreV1().RESTClient().
+			Patch(types.ApplyPatchType).
+			AbsPath(httpRoutePath(opts.Namespace, exposeName(deploySpec.ServiceId))).
+			Param("fieldManager", "astron-xmod-shim").
+			Param("force", "true").
+			Body(data).
+			Do(ctx).
+			Error()
+		if err != nil {
+			return fmt.Errorf("failed to apply httproute: %w", err)
+		}
+		keep[exposeKindHTTPRoute] = true
+	}
+
+	return k.deleteExposure(deploySpec.ServiceId, func(kind, namespace string) bool {
+		return namespace == opts.Namespace && keep[kind]
+	})
+}
+
+// deleteExposure deletes the service's exposure resources in any namespace, except those kept.
+func (k *K8sShimlet) deleteExposure(serviceID string, keep func(kind, namespace string) bool) error {
+	ctx := context.Background()
+	clientSet := k.client.GetClientSet()
+	selector := labels.Set{"app": serviceID, "managed-by": "astron-xmod-shim"}.AsSelector().String()
+	listOpts := metav1.ListOptions{LabelSelector: selector}
+	var errs []error
+
+	services, err := clientSet.CoreV1().Services(metav1.NamespaceAll).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list services for service %s: %w", serviceID, err)
+	}
+	for _, svc := range services.Items {
+		kind := exposeKindService
+		if svc.Labels[rayComponentLabel] == rayComponentValue {
+			kind = exposeKindRayService
+		}
+		if keep(kind, svc.Namespace) {
+			continue
+		}
+		err := clientSet.CoreV1().Services(svc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		errs = append(errs, logExposureDelete(kind, svc.Namespace, svc.Name, err))
+	}
+
+	ingresses, err := clientSet.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list ingresses for service %s: %w", serviceID, err)
+	}
+	for _, ingress := range ingresses.Items {
+		if keep(exposeKindIngress, ingress.Namespace) {
+			continue
+		}
+		err := clientSet.NetworkingV1().Ingresses(ingress.Namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
+		errs = append(errs, logExposureDelete(exposeKindIngress, ingress.Namespace, ingress.Name, err))
+	}
+
+	// HTTPRoutes only exist when the Gateway API CRDs are installed
+	raw, err := clientSet.CoreV1().RESTClient().Get().
+		AbsPath(httpRouteAPIPrefix+"/httproutes").
+		Param("labelSelector", selector).
+		Do(ctx).
+		Raw()
+	if err != nil {
+		if !k8s_errors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to list httproutes for service %s: %w", serviceID, err))
+		}
+	} else {
+		var routes metav1.PartialObjectMetadataList
+		if err := json.Unmarshal(raw, &routes); err != nil {
+			errs = append(errs, fmt.Errorf("failed to decode httproutes: %w", err))
+		}
+		for _, route := range routes.Items {
+			if keep(exposeKindHTTPRoute, route.Namespace) {
+				continue
+			}
+			err := clientSet.CoreV1().RESTClient().Delete().
+				AbsPath(httpRoutePath(route.Namespace, route.Name)).
+				Do(ctx).
+				Error()
+			errs = append(errs, logExposureDelete(exposeKindHTTPRoute, route.Namespace, route.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func logExposureDelete(kind, namespace, name string, err error) error {
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		log.Error("Failed to delete %s %s/%s: %v", kind, namespace, name, err)
+		return fmt.Errorf("failed to delete %s %s/%s: %w", kind, namespace, name, err)
+	}
+	log.Info("Successfully deleted %s %s/%s", kind, namespace, name)
+	return nil
+}
+
+// resolveEndpoint returns the address clients reach the service at, empty while it is not
+// reachable yet. Deployments without the expose-mode annotation predate exposure modes and
+// use host networking.
+func (k *K8sShimlet) resolveEndpoint(workload *k8sWorkload, resourceId string) string {
+	mode := workload.annotations[k8sExposeModeAnnotation]
+	if mode == "" || mode == K8sExposeHostNetwork {
+		return k.hostNetworkEndpoint(workload, resourceId)
+	}
+
+	ctx := context.Background()
+	name := exposeName(resourceId)
+	switch mode {
+	case K8sExposeClusterIP, K8sExposeNodePort:
+		svc, err := k.client.GetClientSet().CoreV1().Services(workload.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warn("Failed to get service %s/%s: %v", workload.namespace, name, err)
+			return ""
+		}
+		nodeAddress := ""
+		if mode == K8sExposeNodePort {
+			nodeAddress = k.nodeAddress(workload)
+		}
+		return serviceEndpoint(svc, nodeAddress)
+	case K8sExposeIngress:
+		ingress, err := k.client.GetClientSet().NetworkingV1().Ingresses(workload.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warn("Failed to get ingress %s/%s: %v", workload.namespace, name, err)
+			return ""
+		}
+		return ingressEndpoint(ingress)
+	case K8sExposeGateway:
+		raw, err := k.client.GetClientSet().CoreV1().RESTClient().Get().
+			AbsPath(httpRoutePath(workload.namespace, name)).
+			Do(ctx).
+			Raw()
+		if err != nil {
+			log.Warn("Failed to get httproute %s/%s: %v", workload.namespace, name, err)
+			return ""
+		}
+		return httpRouteEndpoint(raw)
+	}
+	return ""
+}
+
+// nodeAddress returns the configured node address, or the InternalIP of a ready node.
+func (k *K8sShimlet) nodeAddress(workload *k8sWorkload) string {
+	override := &dto.K8sOptions{}
+	if val := workload.annotations[k8sOptionsAnnotation]; val != "" {
+		_ = json.Unmarshal([]byte(val), override)
+	}
+	if opts, err := k.resolveOptions(override); err == nil && opts.Expose.NodeAddress != "" {
+		return opts.Expose.NodeAddress
+	}
+
+	nodes, err := k.client.ListNodesByLabelFromCache("")
+	if err != nil {
+		log.Warn("Failed to list nodes: %v", err)
+		return ""
+	}
+	for _, node := range nodes {
+		if !nodeReady(node) {
+			continue
+		}
+		if ip := nodeInternalIP(node); ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// serviceEndpoint resolves the endpoint from a Service: the node port on nodeAddress for
+// NodePort Services, the cluster DNS name otherwise.
+func serviceEndpoint(svc *corev1.Service, nodeAddress string) string {
+	if len(svc.Spec.Ports) == 0 {
+		return ""
+	}
+	port := svc.Spec.Ports[0]
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		if nodeAddress == "" || port.NodePort == 0 {
+			return ""
+		}
+		return fmt.Sprintf("http://%s:%d", nodeAddress, port.NodePort)
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, port.Port)
+}
+
+// ingressEndpoint resolves the endpoint from the Ingress rule host, or from the load balancer
+// address when the rule has no host.
+func ingressEndpoint(ingress *networkingv1.Ingress) string {
+	if len(ingress.Spec.Rules) == 0 {
+		return ""
+	}
+	rule := ingress.Spec.Rules[0]
+	host := rule.Host
+	if host == "" {
+		for _, lb := range ingress.Status.LoadBalancer.Ingress {
+			if lb.Hostname != "" {
+				host = lb.Hostname
+			} else {
+				host = lb.IP
+			}
+			if host != "" {
+				break
+			}
+		}
+	}
+	if host == "" {
+		return ""
+	}
+	scheme := "http"
+	if len(ingress.Spec.TLS) > 0 {
+		scheme = "https"
+	}
+	path := ""
+	if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+		path = strings.TrimSuffix(rule.HTTP.Paths[0].Path, "/")
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, path)
+}
+
+// httpRouteEndpoint resolves the endpoint from the first hostname and path of an HTTPRoute.
+func httpRouteEndpoint(raw []byte) string {
+	var route struct {
+		Spec struct {
+			Hostnames []string `json:"hostnames"`
+			Rules     []struct {
+				Matches []struct {
+					Path struct {
+						Value string `json:"value"`
+					} `json:"path"`
+				} `json:"matches"`
+			} `json:"rules"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &route); err != nil || len(route.Spec.Hostnames) == 0 {
+		return ""
+	}
+	path := ""
+	if len(route.Spec.Rules) > 0 && len(route.Spec.Rules[0].Matches) > 0 {
+		path = strings.TrimSuffix(route.Spec.Rules[0].Matches[0].Path.Value, "/")
+	}
+	return fmt.Sprintf("http://%s%s", route.Spec.Hostnames[0], path)
+}
+
+// hostNetworkEndpoint resolves the endpoint from the node of a running Pod (the head Pod for
+// multi-node deployments) and the container port.
+func (k *K8sShimlet) hostNetworkEndpoint(workload *k8sWorkload, resourceId string) string {
+	// 从 PodTemplate 中提取容器端口（即主机端口）
+	var hostPort int32 = 0
+	for _, c := range workload.template.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == "http" {
+				hostPort = p.ContainerPort
+				break
+			}
+		}
+		if hostPort != 0 {
+			break
+		}
+	}
+	if hostPort == 0 {
+		return ""
+	}
+
+	// 获取任一运行中的 Pod 的 Node IP
+	podListOptions := metav1.ListOptions{
+		LabelSelector: labels.Set{"app": resourceId}.AsSelector().String(),
+	}
+	pods, err := k.client.GetClientSet().CoreV1().Pods(workload.namespace).List(context.Background(), podListOptions)
+	if err != nil {
+		log.Warn("Failed to list pods for %s %s: %v", workload.kind, workload.name, err)
+		return ""
+	}
+	headPod := workload.headPodName()
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if headPod != "" && pod.Name != headPod {
+			continue
+		}
+		node, err := k.client.GetClientSet().CoreV1().Nodes().Get(context.Background(), pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		// 使用第一个运行中 Pod 的节点 IP
+		if nodeIP := nodeInternalIP(node); nodeIP != "" {
+			return fmt.Sprintf("http://%s:%d", nodeIP, hostPort)
+		}
+	}
+	return ""
+}
+
+func nodeInternalIP(node *corev1.Node) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package shimlets
+
+import (
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestK8sExpose_DefaultsAndOverride(t *testing.T) {
+	k := &K8sShimlet{conf: &cfg.K8sConfig{Defaults: dto.K8sOptions{Expose: &dto.K8sExpose{
+		Mode:             K8sExposeIngress,
+		Host:             "{{service_id}}.llm.example.com",
+		IngressClassName: "nginx",
+	}}}}
+
+	opts, err := k.resolveOptions(nil)
+	require.NoError(t, err)
+	assert.Equal(t, K8sExposeIngress, opts.Expose.Mode)
+	assert.Equal(t, defaultK8sServicePort, opts.Expose.Port)
+	assert.Equal(t, defaultK8sExposePath, opts.Expose.Path)
+	assert.Equal(t, defaultK8sPortStart, opts.Expose.PortRangeStart)
+	assert.Equal(t, defaultK8sPortEnd, opts.Expose.PortRangeEnd)
+
+	opts, err = k.resolveOptions(&dto.K8sOptions{Expose: &dto.K8sExpose{Mode: K8sExposeNodePort, Port: 9000}})
+	require.NoError(t, err)
+	assert.Equal(t, K8sExposeNodePort, opts.Expose.Mode)
+	assert.Equal(t, 9000, opts.Expose.Port)
+	assert.Equal(t, "nginx", opts.Expose.IngressClassName)
+	// the configured defaults are left untouched
+	assert.Equal(t, K8sExposeIngress, k.conf.Defaults.Expose.Mode)
+
+	opts, err = (&K8sShimlet{}).resolveOptions(nil)
+	require.NoError(t, err)
+	assert.Equal(t, K8sExposeHostNetwork, opts.Expose.Mode)
+}
+
+func TestK8sExpose_Invalid(t *testing.T) {
+	k := &K8sShimlet{}
+	cases := map[string]*dto.K8sExpose{
+		"unsupported expose mode": {Mode: "load-balancer"},
+		"invalid port range":      {PortRangeStart: 32000, PortRangeEnd: 31000},
+		"must start with /":       {Path: "v1"},
+		"requires gateway-name":   {Mode: K8sExposeGateway},
+	}
+	for msg, expose := range cases {
+		_, err := k.resolveOptions(&dto.K8sOptions{Expose: expose})
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+func TestPickPort(t *testing.T) {
+	first, err := pickPort("svc-1", nil, 30000, 30009)
+	require.NoError(t, err)
+	again, err := pickPort("svc-1", map[int]struct{}{}, 30000, 30009)
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "allocation is deterministic")
+
+	// a taken port moves the service to the next free one in the range
+	next, err := pickPort("svc-1", map[int]struct{}{first: {}}, 30000, 30009)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, next)
+	assert.GreaterOrEqual(t, next, 30000)
+	assert.LessOrEqual(t, next, 30009)
+
+	_, err = pickPort("svc-1", map[int]struct{}{30000: {}, 30001: {}}, 30000, 30001)
+	assert.ErrorContains(t, err, "no free port")
+}
+
+func TestK8sReservePort(t *testing.T) {
+	k := &K8sShimlet{}
+	assert.True(t, k.reserveLocked("svc-1", 30001))
+	assert.False(t, k.reserveLocked("svc-2", 30001), "held by another service")
+	assert.True(t, k.reserveLocked("svc-1", 30002))
+	assert.Equal(t, map[int]string{30002: "svc-1"}, k.ports, "previous port is released")
+
+	k.releasePorts("svc-1")
+	assert.Empty(t, k.ports)
+}
+
+func TestK8sBuildDeployment_ExposeModes(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1}
+
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Expose: &dto.K8sExpose{Mode: K8sExposeClusterIP}})
+	require.NoError(t, err)
+	deployment, err := buildDeployment(spec, opts, opts.Expose.Port, 0, 0)
+	require.NoError(t, err)
+	assert.Nil(t, deployment.Spec.Template.Spec.HostNetwork)
+	assert.Equal(t, int32(defaultK8sServicePort), *deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort)
+	assert.Equal(t, K8sExposeClusterIP, deployment.Annotations[k8sExposeModeAnnotation])
+	assert.NotContains(t, deployment.Annotations, k8sPortAnnotation)
+
+	opts, err = (&K8sShimlet{}).resolveOptions(nil)
+	require.NoError(t, err)
+	deployment, err = buildDeployment(spec, opts, 31234, 31234, 0)
+	require.NoError(t, err)
+	assert.True(t, *deployment.Spec.Template.Spec.HostNetwork)
+	assert.Equal(t, K8sExposeHostNetwork, deployment.Annotations[k8sExposeModeAnnotation])
+	assert.Equal(t, "31234", deployment.Annotations[k8sPortAnnotation])
+}
+
+func TestBuildExposeService(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "1a2b3c4d"}
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{
+		Namespace:   "llm",
+		Annotations: map[string]string{"team": "nlp"},
+		Expose:      &dto.K8sExpose{Mode: K8sExposeNodePort},
+	})
+	require.NoError(t, err)
+
+	svc := buildExposeService(spec, opts, workloadLabels(spec, opts), 31500)
+	assert.Equal(t, "xmod-1a2b3c4d", *svc.Name)
+	assert.Equal(t, "llm", *svc.Namespace)
+	assert.Equal(t, "1a2b3c4d", svc.Labels["app"])
+	assert.Equal(t, "nlp", svc.Annotations["team"])
+	assert.Equal(t, corev1.ServiceTypeNodePort, *svc.Spec.Type)
+	assert.Equal(t, map[string]string{"app": "1a2b3c4d"}, svc.Spec.Selector)
+	require.Len(t, svc.Spec.Ports, 1)
+	assert.Equal(t, int32(defaultK8sServicePort), *svc.Spec.Ports[0].Port)
+	assert.Equal(t, int32(31500), *svc.Spec.Ports[0].NodePort)
+	assert.Equal(t, "http", svc.Spec.Ports[0].TargetPort.StrVal)
+
+	opts.Expose.Mode = K8sExposeClusterIP
+	svc = buildExposeService(spec, opts, workloadLabels(spec, opts), 0)
+	assert.Equal(t, corev1.ServiceTypeClusterIP, *svc.Spec.Type)
+	assert.Nil(t, svc.Spec.Ports[0].NodePort)
+}
+
+func TestBuildExposeIngress(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "svc-1"}
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{
+		Namespace: "llm",
+		Expose: &dto.K8sExpose{
+			Mode:             K8sExposeIngress,
+			Host:             "{{service_id}}.{{namespace}}.example.com",
+			Path:             "/v1",
+			IngressClassName: "nginx",
+			TLSSecretName:    "llm-tls",
+		},
+	})
+	require.NoError(t, err)
+
+	ingress := buildExposeIngress(spec, opts, workloadLabels(spec, opts))
+	assert.Equal(t, "xmod-svc-1", *ingress.Name)
+	assert.Equal(t, "nginx", *ingress.Spec.IngressClassName)
+	require.Len(t, ingress.Spec.Rules, 1)
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "svc-1.llm.example.com", *rule.Host)
+	require.Len(t, rule.HTTP.Paths, 1)
+	assert.Equal(t, "/v1", *rule.HTTP.Paths[0].Path)
+	assert.Equal(t, networkingv1.PathTypePrefix, *rule.HTTP.Paths[0].PathType)
+	assert.Equal(t, "xmod-svc-1", *rule.HTTP.Paths[0].Backend.Service.Name)
+	assert.Equal(t, int32(defaultK8sServicePort), *rule.HTTP.Paths[0].Backend.Service.Port.Number)
+	require.Len(t, ingress.Spec.TLS, 1)
+	assert.Equal(t, "llm-tls", *ingress.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"svc-1.llm.example.com"}, ingress.Spec.TLS[0].Hosts)
+}
+
+func TestBuildHTTPRoute(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "svc-1"}
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{
+		Namespace: "llm",
+		Expose: &dto.K8sExpose{
+			Mode:             K8sExposeGateway,
+			Host:             "{{service_id}}.example.com",
+			GatewayName:      "public",
+			GatewayNamespace: "gateways",
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(buildHTTPRoute(spec, opts, workloadLabels(spec, opts)))
+	require.NoError(t, err)
+	var route struct {
+		Kind     string            `json:"kind"`
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			ParentRefs []map[string]string `json:"parentRefs"`
+			Hostnames  []string            `json:"hostnames"`
+			Rules      []struct {
+				BackendRefs []struct {
+					Name string `json:"name"`
+					Port int    `json:"port"`
+				} `json:"backendRefs"`
+			} `json:"rules"`
+		} `json:"spec"`
+	}
+	require.NoError(t, json.Unmarshal(data, &route))
+	assert.Equal(t, "HTTPRoute", route.Kind)
+	assert.Equal(t, "xmod-svc-1", route.Metadata.Name)
+	assert.Equal(t, "llm", route.Metadata.Namespace)
+	assert.Equal(t, "svc-1", route.Metadata.Labels["app"])
+	assert.Equal(t, []map[string]string{{"name": "public", "namespace": "gateways"}}, route.Spec.ParentRefs)
+	assert.Equal(t, []string{"svc-1.example.com"}, route.Spec.Hostnames)
+	require.Len(t, route.Spec.Rules, 1)
+	assert.Equal(t, "xmod-svc-1", route.Spec.Rules[0].BackendRefs[0].Name)
+	assert.Equal(t, defaultK8sServicePort, route.Spec.Rules[0].BackendRefs[0].Port)
+
+	// the endpoint is resolved from the route that was applied
+	assert.Equal(t, "http://svc-1.example.com", httpRouteEndpoint(data))
+}
+
+func TestServiceEndpoint(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "xmod-svc-1", Namespace: "llm"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{Port: 8000}},
+		},
+	}
+	assert.Equal(t, "http://xmod-svc-1.llm.svc.cluster.local:8000", serviceEndpoint(svc, ""))
+
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports[0].NodePort = 31500
+	assert.Equal(t, "http://10.0.0.5:31500", serviceEndpoint(svc, "10.0.0.5"))
+	assert.Empty(t, serviceEndpoint(svc, ""), "no node address known yet")
+}
+
+func TestIngressEndpoint(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: "svc-1.example.com",
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{Path: "/v1"}},
+				}},
+			}},
+			TLS: []networkingv1.IngressTLS{{SecretName: "llm-tls"}},
+		},
+	}
+	assert.Equal(t, "https://svc-1.example.com/v1", ingressEndpoint(ingress))
+
+	// without a host the load balancer address is used
+	ingress.Spec.Rules[0].Host = ""
+	ingress.Spec.TLS = nil
+	ingress.Spec.Rules[0].HTTP.Paths[0].Path = "/"
+	assert.Empty(t, ingressEndpoint(ingress))
+	ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.7"}}
+	assert.Equal(t, "http://203.0.113.7", ingressEndpoint(ingress))
+}
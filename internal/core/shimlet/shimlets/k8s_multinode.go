@@ -0,0 +1,148 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	appsv1apply "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1apply "k8s.io/client-go/applyconfigurations/meta/v1"
+)
+
+const (
+	rayHeadPort = 6379
+	// rayComponentLabel marks the headless Service of a multi-node deployment
+	rayComponentLabel = "astron-xmod-shim/component"
+	rayComponentValue = "ray"
+	// statefulSetPodNameLabel is set on every StatefulSet Pod by the controller
+	statefulSetPodNameLabel = "statefulset.kubernetes.io/pod-name"
+	// exposeKindRayService is the headless Service kind tracked by applyExposure
+	exposeKindRayService = "RayService"
+	// k8sGangScheduleTimeout is how long part of a multi-node group may stay unschedulable
+	// while other Pods of the group are bound before the service is reported failed
+	k8sGangScheduleTimeout = 10 * time.Minute
+)
+
+// statefulSetName returns the StatefulSet name of a multi-node deployment. It is kept short
+// because Pod names (and so DNS labels) append the ordinal to it.
+func statefulSetName(serviceID string) string { return exposeName(serviceID) }
+
+// rayServiceName returns the headless Service giving the Pods of a multi-node deployment stable DNS names.
+func rayServiceName(serviceID string) string {
+	return utils.ModelNameToDeploymentName("xmod-" + serviceID + "-ray")
+}
+
+// rayHeadAddress returns the DNS name of the Ray head (the Pod with ordinal 0).
+func rayHeadAddress(serviceID, namespace string) string {
+	return fmt.Sprintf("%s-0.%s.%s.svc.cluster.local", statefulSetName(serviceID), rayServiceName(serviceID), namespace)
+}
+
+// buildStatefulSet renders the StatefulSet of a multi-node deployment: one Pod per node,
+// started together (parallel Pod management) so the Ray cluster can form. Pod 0 is the
+// Ray head and runs the inference server; the other Pods join it as workers.
+func buildStatefulSet(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, parallelism engine.Parallelism, containerPort, allocatedPort int, modelSizeGiB float64) (*appsv1apply.StatefulSetApplyConfiguration, error) {
+	template, annotations, err := buildPodTemplate(deploySpec, opts, containerPort, allocatedPort, modelSizeGiB)
+	if err != nil {
+		return nil, err
+	}
+	return appsv1apply.StatefulSet(statefulSetName(deploySpec.ServiceId), opts.Namespace).
+		WithLabels(workloadLabels(deploySpec, opts)).
+		WithAnnotations(annotations).
+		WithSpec(appsv1apply.StatefulSetSpec().
+			WithReplicas(int32(parallelism.Nodes)).
+			WithServiceName(rayServiceName(deploySpec.ServiceId)).
+			WithPodManagementPolicy(appsv1.ParallelPodManagement).
+			WithSelector(metav1apply.LabelSelector().WithMatchLabels(map[string]string{"app": deploySpec.ServiceId})).
+			WithTemplate(template)), nil
+}
+
+// partialScheduleReason reports a multi-node group whose Pods have been only partly scheduled
+// for longer than k8sGangScheduleTimeout, empty otherwise. StatefulSets are not gang scheduled:
+// the bound Pods hold their GPUs while the others wait for capacity that may never come.
+func partialScheduleReason(pods []corev1.Pod, now time.Time) string {
+	bound := 0
+	var stuck *corev1.Pod
+	var since time.Time
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName != "" {
+			bound++
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type != corev1.PodScheduled || cond.Status != corev1.ConditionFalse || cond.Reason != corev1.PodReasonUnschedulable {
+				continue
+			}
+			if stuck == nil || cond.LastTransitionTime.Time.Before(since) {
+				stuck, since = pod, cond.LastTransitionTime.Time
+			}
+		}
+	}
+	if bound == 0 || stuck == nil || now.Sub(since) < k8sGangScheduleTimeout {
+		return ""
+	}
+	return fmt.Sprintf("only %d/%d pods of the group scheduled, pod %s unschedulable for %s",
+		bound, len(pods), stuck.Name, now.Sub(since).Round(time.Second))
+}
+
+// buildRayService renders the headless Service of a multi-node deployment. Addresses are
+// published before the Pods are ready: workers must reach the head while the model loads.
+func buildRayService(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, workloadLabels map[string]string) *corev1apply.ServiceApplyConfiguration {
+	labels := make(map[string]string, len(workloadLabels)+1)
+	for key, value := range workloadLabels {
+		labels[key] = value
+	}
+	labels[rayComponentLabel] = rayComponentValue
+	return corev1apply.Service(rayServiceName(deploySpec.ServiceId), opts.Namespace).
+		WithLabels(labels).
+		WithSpec(corev1apply.ServiceSpec().
+			WithClusterIP(corev1.ClusterIPNone).
+			WithPublishNotReadyAddresses(true).
+			WithSelector(map[string]string{"app": deploySpec.ServiceId}).
+			WithPorts(corev1apply.ServicePort().
+				WithName("ray").
+				WithProtocol(corev1.ProtocolTCP).
+				WithPort(rayHeadPort)))
+}
+
+// exposeSelector selects the Pods serving HTTP: all Pods of a Deployment, or the head of a
+// multi-node deployment.
+func exposeSelector(deploySpec *dto.RequirementSpec) map[string]string {
+	selector := map[string]string{"app": deploySpec.ServiceId}
+	if parallelism, err := engine.ResolveParallelism(deploySpec); err == nil && parallelism.MultiNode() {
+		selector[statefulSetPodNameLabel] = statefulSetName(deploySpec.ServiceId) + "-0"
+	}
+	return selector
+}
+
+// rayLaunchScript returns the shell script run by every Pod of a multi-node deployment.
+// The head starts Ray, waits until all nodes have joined and then execs the inference
+// server; workers keep retrying to join the head and block while it runs.
+func rayLaunchScript(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, parallelism engine.Parallelism, command []string) string {
+	headAddress := rayHeadAddress(deploySpec.ServiceId, opts.Namespace)
+	waitNodes := fmt.Sprintf(`python3 -c 'import ray, sys; ray.init(address="auto"); sys.exit(0 if sum(n["Alive"] for n in ray.nodes()) >= %d else 1)'`, parallelism.Nodes)
+	return strings.Join([]string{
+		`if [ "${POD_NAME##*-}" = "0" ]; then`,
+		fmt.Sprintf(`  ray start --head --port=%d`, rayHeadPort),
+		fmt.Sprintf(`  until %s >/dev/null 2>&1; do echo "waiting for %d ray nodes"; sleep 5; done`, waitNodes, parallelism.Nodes),
+		`  exec ` + shellJoin(command),
+		`else`,
+		fmt.Sprintf(`  until ray start --address=%s:%d --block; do sleep 5; done`, headAddress, rayHeadPort),
+		`fi`,
+	}, "\n")
+}
+
+// shellJoin quotes each argument for /bin/sh.
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
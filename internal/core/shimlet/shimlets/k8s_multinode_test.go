@@ -0,0 +1,136 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func multiNodeSpec() *dto.RequirementSpec {
+	return &dto.RequirementSpec{
+		ServiceId: "1a2b3c4d", ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1,
+		ResourceRequirements: &dto.ResourceRequirements{AcceleratorType: "nvidia.com/gpu", AcceleratorCount: 8},
+		Parallelism:          &dto.ParallelismSpec{Nodes: 2},
+	}
+}
+
+func TestK8sBuildStatefulSet(t *testing.T) {
+	spec := multiNodeSpec()
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Namespace: "llm", Expose: &dto.K8sExpose{Mode: K8sExposeClusterIP}})
+	require.NoError(t, err)
+	parallelism, err := engine.ResolveParallelism(spec)
+	require.NoError(t, err)
+
+	sts, err := buildStatefulSet(spec, opts, parallelism, opts.Expose.Port, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "xmod-1a2b3c4d", *sts.Name)
+	assert.Equal(t, "llm", *sts.Namespace)
+	assert.Equal(t, int32(2), *sts.Spec.Replicas)
+	assert.Equal(t, "xmod-1a2b3c4d-ray", *sts.Spec.ServiceName)
+	assert.Equal(t, appsv1.ParallelPodManagement, *sts.Spec.PodManagementPolicy)
+	assert.JSONEq(t, `{"nodes":2}`, sts.Annotations[k8sParallelismAnnotation])
+
+	podSpec := sts.Spec.Template.Spec
+	assert.Equal(t, "xmod-1a2b3c4d-ray", *podSpec.Subdomain)
+	container := podSpec.Containers[0]
+	require.Len(t, container.Command, 3)
+	assert.Equal(t, []string{"/bin/sh", "-c"}, container.Command[:2])
+	assert.Contains(t, container.Command[2], "'--pipeline-parallel-size=2'")
+	assert.Contains(t, container.Command[2], "xmod-1a2b3c4d-0.xmod-1a2b3c4d-ray.llm.svc.cluster.local:6379")
+	assert.Empty(t, container.Args)
+	gpus := (*container.Resources.Limits)["nvidia.com/gpu"]
+	assert.Equal(t, "8", gpus.String(), "accelerators are per pod")
+
+	var podName bool
+	for _, env := range container.Env {
+		if *env.Name == "POD_NAME" {
+			podName = true
+			assert.Equal(t, "metadata.name", *env.ValueFrom.FieldRef.FieldPath)
+		}
+	}
+	assert.True(t, podName)
+	volumes := make([]string, 0, len(podSpec.Volumes))
+	for _, volume := range podSpec.Volumes {
+		volumes = append(volumes, *volume.Name)
+	}
+	assert.Contains(t, volumes, "dshm")
+}
+
+func TestK8sBuildStatefulSet_HostNetwork(t *testing.T) {
+	spec := multiNodeSpec()
+	opts, err := (&K8sShimlet{}).resolveOptions(nil)
+	require.NoError(t, err)
+	require.Equal(t, K8sExposeHostNetwork, opts.Expose.Mode)
+	parallelism, err := engine.ResolveParallelism(spec)
+	require.NoError(t, err)
+
+	sts, err := buildStatefulSet(spec, opts, parallelism, 31234, 31234, 0)
+	require.NoError(t, err)
+	assert.True(t, *sts.Spec.Template.Spec.HostNetwork)
+	assert.Equal(t, corev1.DNSClusterFirstWithHostNet, *sts.Spec.Template.Spec.DNSPolicy)
+}
+
+func TestBuildRayService(t *testing.T) {
+	spec := multiNodeSpec()
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Namespace: "llm"})
+	require.NoError(t, err)
+
+	svc := buildRayService(spec, opts, workloadLabels(spec, opts))
+	assert.Equal(t, "xmod-1a2b3c4d-ray", *svc.Name)
+	assert.Equal(t, rayComponentValue, svc.Labels[rayComponentLabel])
+	assert.Equal(t, corev1.ClusterIPNone, *svc.Spec.ClusterIP)
+	assert.True(t, *svc.Spec.PublishNotReadyAddresses)
+	assert.Equal(t, map[string]string{"app": "1a2b3c4d"}, svc.Spec.Selector)
+
+	// The exposing Service only targets the head
+	exposed := buildExposeService(spec, opts, workloadLabels(spec, opts), 0)
+	assert.Equal(t, map[string]string{"app": "1a2b3c4d", statefulSetPodNameLabel: "xmod-1a2b3c4d-0"}, exposed.Spec.Selector)
+}
+
+func TestRayLaunchScript(t *testing.T) {
+	spec := multiNodeSpec()
+	opts := &dto.K8sOptions{Namespace: "default"}
+	parallelism, err := engine.ResolveParallelism(spec)
+	require.NoError(t, err)
+
+	script := rayLaunchScript(spec, opts, parallelism, []string{"python3", "-m", "vllm", "--served-model-name=it's"})
+	assert.Contains(t, script, `if [ "${POD_NAME##*-}" = "0" ]; then`)
+	assert.Contains(t, script, "ray start --head --port=6379")
+	assert.Contains(t, script, ">= 2 else 1")
+	assert.Contains(t, script, `exec 'python3' '-m' 'vllm' '--served-model-name=it'\''s'`)
+	assert.Contains(t, script, "until ray start --address=xmod-1a2b3c4d-0.xmod-1a2b3c4d-ray.default.svc.cluster.local:6379 --block")
+}
+
+func TestPartialScheduleReason(t *testing.T) {
+	now := time.Now()
+	unschedulable := func(name string, since time.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				LastTransitionTime: metav1.NewTime(since),
+			}}},
+		}
+	}
+	bound := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sts-0"}, Spec: corev1.PodSpec{NodeName: "gpu-node-1"}}
+
+	// Still within the timeout
+	assert.Empty(t, partialScheduleReason([]corev1.Pod{bound, unschedulable("sts-1", now.Add(-time.Minute))}, now))
+
+	// Nothing bound: no GPUs are held, the group just waits
+	stuck := unschedulable("sts-1", now.Add(-time.Hour))
+	assert.Empty(t, partialScheduleReason([]corev1.Pod{unschedulable("sts-0", now.Add(-time.Hour)), stuck}, now))
+
+	// Part of the group bound for longer than the timeout
+	assert.Equal(t, "only 1/2 pods of the group scheduled, pod sts-1 unschedulable for 1h0m0s",
+		partialScheduleReason([]corev1.Pod{bound, stuck}, now))
+}
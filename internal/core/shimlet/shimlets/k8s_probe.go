@@ -0,0 +1,232 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+)
+
+const (
+	// defaultK8sReadinessPath is served by every built-in engine once the model is loaded
+	defaultK8sReadinessPath   = "/v1/models"
+	defaultK8sProbePeriod     = 10
+	k8sProbeTimeoutSeconds    = 5
+	k8sProbeFailureThreshold  = 3
+	k8sStartupBaseSeconds     = 300
+	k8sStartupSecondsPerGiB   = 15
+	k8sStartupMaxSeconds      = 3600
+	k8sStartupUnknownSeconds  = 1800
+	k8sProgressDeadlineMargin = 600
+	bytesPerParam             = 2 // bf16 / fp16 weights
+)
+
+// modelParamsPattern matches parameter counts in model names, e.g. qwen3-1.5b, Llama-3-70B, Mixtral-8x7B, 500m.
+var modelParamsPattern = regexp.MustCompile(`(?i)(?:^|[^a-z0-9.])(?:(\d+)x)?(\d+(?:\.\d+)?)([bm])(?:[^a-z0-9]|$)`)
+
+// mergeK8sProbes overlays the request's probe settings on the configured defaults.
+func mergeK8sProbes(defaults, override *dto.K8sProbes) *dto.K8sProbes {
+	probes := &dto.K8sProbes{}
+	if defaults != nil {
+		*probes = *defaults
+	}
+	if override == nil {
+		return probes
+	}
+	if override.Enabled != nil {
+		probes.Enabled = override.Enabled
+	}
+	if override.ReadinessPath != "" {
+		probes.ReadinessPath = override.ReadinessPath
+	}
+	if override.PeriodSeconds != 0 {
+		probes.PeriodSeconds = override.PeriodSeconds
+	}
+	if override.StartupTimeoutSeconds != 0 {
+		probes.StartupTimeoutSeconds = override.StartupTimeoutSeconds
+	}
+	return probes
+}
+
+// completeK8sProbes fills in the built-in defaults and validates the probes. The startup
+// timeout depends on the model and is resolved when the Pod template is built.
+func completeK8sProbes(probes *dto.K8sProbes) error {
+	if probes.ReadinessPath == "" {
+		probes.ReadinessPath = defaultK8sReadinessPath
+	}
+	if probes.PeriodSeconds == 0 {
+		probes.PeriodSeconds = defaultK8sProbePeriod
+	}
+	if !strings.HasPrefix(probes.ReadinessPath, "/") {
+		return fmt.Errorf("readiness path must start with /: %s", probes.ReadinessPath)
+	}
+	if probes.PeriodSeconds < 1 {
+		return fmt.Errorf("invalid probe period: %d", probes.PeriodSeconds)
+	}
+	if probes.StartupTimeoutSeconds < 0 {
+		return fmt.Errorf("invalid startup timeout: %d", probes.StartupTimeoutSeconds)
+	}
+	return nil
+}
+
+// probesEnabled reports whether probes are configured, true unless disabled explicitly.
+func probesEnabled(probes *dto.K8sProbes) bool {
+	return probes == nil || probes.Enabled == nil || *probes.Enabled
+}
+
+// startupTimeoutSeconds returns how long the model may take to load: the configured
+// timeout, or an estimate from the model size (see modelSizeEstimate).
+func startupTimeoutSeconds(sizeGiB float64, probes *dto.K8sProbes) int {
+	if probes != nil && probes.StartupTimeoutSeconds > 0 {
+		return probes.StartupTimeoutSeconds
+	}
+	if sizeGiB <= 0 {
+		return k8sStartupUnknownSeconds
+	}
+	return min(k8sStartupBaseSeconds+int(sizeGiB*k8sStartupSecondsPerGiB), k8sStartupMaxSeconds)
+}
+
+// modelSizeEstimate returns the model size recorded on the service's workload for the same
+// model path, so re-applies do not walk the model directory again; otherwise it estimates
+// the size with modelSizeGiB. The result is recorded in k8sModelSizeAnnotation on apply.
+func (k *K8sShimlet) modelSizeEstimate(deploySpec *dto.RequirementSpec, namespace string) float64 {
+	selector := labels.Set{"app": deploySpec.ServiceId}.AsSelector().String()
+	workloads, err := k.listWorkloads(namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		log.Warn("Failed to list workloads for service %s, estimating the model size again: %v", deploySpec.ServiceId, err)
+	}
+	for _, w := range workloads {
+		if w.annotations[k8sModelPathAnnotation] != deploySpec.ModelFileDir {
+			continue
+		}
+		if size, err := strconv.ParseFloat(w.annotations[k8sModelSizeAnnotation], 64); err == nil {
+			return size
+		}
+	}
+	return modelSizeGiB(deploySpec)
+}
+
+// modelSizeGiB estimates the size of the model weights: the files on disk when the shim
+// can read the model path, otherwise the parameter count in the model name. 0 if unknown.
+func modelSizeGiB(deploySpec *dto.RequirementSpec) float64 {
+	if size := diskSize(deploySpec.ModelFileDir); size > 0 {
+		return float64(size) / (1 << 30)
+	}
+	for _, name := range []string{deploySpec.ModelName, filepath.Base(deploySpec.ModelFileDir)} {
+		if params := modelParams(name); params > 0 {
+			return params * bytesPerParam / (1 << 30)
+		}
+	}
+	return 0
+}
+
+// diskSize returns the total size of the files under path, 0 if it cannot be read.
+func diskSize(path string) int64 {
+	if path == "" {
+		return 0
+	}
+	var total int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// modelParams parses the parameter count from a model name, 0 if it has none.
+func modelParams(name string) float64 {
+	match := modelParamsPattern.FindStringSubmatch(name)
+	if match == nil {
+		return 0
+	}
+	params, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0
+	}
+	if match[1] != "" {
+		experts, _ := strconv.Atoi(match[1])
+		params *= float64(experts)
+	}
+	if strings.EqualFold(match[3], "m") {
+		return params * 1e6
+	}
+	return params * 1e9
+}
+
+// applyProbes sets the startup, liveness and readiness probes of the inference container.
+// Startup and liveness probes use the engine's health endpoint; the readiness probe only
+// passes once the model is listed, so the workload becomes available when it serves.
+// The Pods of a multi-node deployment share one template: probes run an HTTP check on the
+// head and always pass on the workers, which exit when they lose the head.
+func applyProbes(container *corev1apply.ContainerApplyConfiguration, modelSizeGiB float64, opts *dto.K8sOptions,
+	rendered *engine.Spec, port int) {
+	if !probesEnabled(opts.Probes) {
+		return
+	}
+	period := int32(opts.Probes.PeriodSeconds)
+	startupThreshold := int32((startupTimeoutSeconds(modelSizeGiB, opts.Probes) + int(period) - 1) / int(period))
+
+	probe := func(path string, failureThreshold int32) *corev1apply.ProbeApplyConfiguration {
+		result := corev1apply.Probe().
+			WithPeriodSeconds(period).
+			WithTimeoutSeconds(k8sProbeTimeoutSeconds).
+			WithFailureThreshold(failureThreshold)
+		if rendered.Parallelism.MultiNode() {
+			return result.WithExec(corev1apply.ExecAction().WithCommand("/bin/sh", "-c", headHTTPCheck(port, path)))
+		}
+		return result.WithHTTPGet(corev1apply.HTTPGetAction().
+			WithPath(path).
+			WithPort(intstr.FromString("http")).
+			WithScheme(corev1.URISchemeHTTP))
+	}
+	container.WithStartupProbe(probe(rendered.HealthPath, startupThreshold))
+	container.WithLivenessProbe(probe(rendered.HealthPath, k8sProbeFailureThreshold))
+	container.WithReadinessProbe(probe(opts.Probes.ReadinessPath, k8sProbeFailureThreshold))
+}
+
+// progressDeadlineSeconds gives a Deployment enough time for its Pods to load the model
+// before Kubernetes reports it as not progressing.
+func progressDeadlineSeconds(modelSizeGiB float64, opts *dto.K8sOptions) int32 {
+	return int32(startupTimeoutSeconds(modelSizeGiB, opts.Probes) + k8sProgressDeadlineMargin)
+}
+
+// headHTTPCheck returns the exec probe of multi-node Pods: workers pass, the head
+// requests path on the inference server.
+func headHTTPCheck(port int, path string) string {
+	return fmt.Sprintf(`[ "${POD_NAME##*-}" != "0" ] || python3 -c 'import urllib.request; urllib.request.urlopen("http://127.0.0.1:%d%s", timeout=%d)'`,
+		port, path, k8sProbeTimeoutSeconds)
+}
+
+// podFailureReason returns why a Pod cannot start, empty while it is starting or running.
+// Slow model loading is not a failure: the startup probe restarts containers that exceed
+// the startup timeout, which ends in CrashLoopBackOff.
+func podFailureReason(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+			return status.State.Waiting.Reason
+		}
+	}
+	return ""
+}
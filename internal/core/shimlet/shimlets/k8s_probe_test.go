@@ -0,0 +1,179 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/k8s"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestModelParams(t *testing.T) {
+	assert.Equal(t, 1.5e9, modelParams("qwen3-1.5b"))
+	assert.Equal(t, 70e9, modelParams("Llama-3-70B-Instruct"))
+	assert.Equal(t, 56e9, modelParams("Mixtral-8x7B-v0.1"))
+	assert.Equal(t, 500e6, modelParams("qwen2-500m"))
+	assert.Zero(t, modelParams("deepseek-coder"))
+	assert.Zero(t, modelParams("bge-m3"), "version numbers are not parameter counts")
+}
+
+func TestStartupTimeoutSeconds(t *testing.T) {
+	// Explicit timeout wins
+	assert.Equal(t, 120, startupTimeoutSeconds(modelSizeGiB(&dto.RequirementSpec{ModelName: "qwen3-72b"}), &dto.K8sProbes{StartupTimeoutSeconds: 120}))
+
+	// Unknown size
+	assert.Equal(t, k8sStartupUnknownSeconds, startupTimeoutSeconds(modelSizeGiB(&dto.RequirementSpec{ModelName: "my-model", ModelFileDir: "/no/such/dir"}), nil))
+
+	// Estimated from the name: 7B bf16 weights are ~13 GiB
+	assert.Equal(t, 300+195, startupTimeoutSeconds(modelSizeGiB(&dto.RequirementSpec{ModelName: "qwen2-7b"}), nil))
+	assert.Equal(t, k8sStartupMaxSeconds, startupTimeoutSeconds(modelSizeGiB(&dto.RequirementSpec{ModelName: "llama-405b"}), nil))
+
+	// Files on disk take precedence over the name
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "model.safetensors"))
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(4<<30))
+	require.NoError(t, f.Close())
+	assert.Equal(t, 300+4*15, startupTimeoutSeconds(modelSizeGiB(&dto.RequirementSpec{ModelName: "qwen2-72b", ModelFileDir: dir}), nil))
+}
+
+func TestK8sBuildDeployment_Probes(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen2-7b", ModelFileDir: "/models/qwen2-7b", ReplicaCount: 1}
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Expose: &dto.K8sExpose{Mode: K8sExposeClusterIP}})
+	require.NoError(t, err)
+	assert.Equal(t, &dto.K8sProbes{ReadinessPath: "/v1/models", PeriodSeconds: 10}, opts.Probes)
+
+	deployment, err := buildDeployment(spec, opts, opts.Expose.Port, 0, modelSizeGiB(spec))
+	require.NoError(t, err)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	require.NotNil(t, container.StartupProbe)
+	assert.Equal(t, "/health", *container.StartupProbe.HTTPGet.Path)
+	assert.Equal(t, "http", container.StartupProbe.HTTPGet.Port.StrVal)
+	timeout := startupTimeoutSeconds(modelSizeGiB(spec), opts.Probes)
+	assert.Equal(t, int32((timeout+9)/10), *container.StartupProbe.FailureThreshold)
+	assert.Equal(t, "/health", *container.LivenessProbe.HTTPGet.Path)
+	assert.Equal(t, "/v1/models", *container.ReadinessProbe.HTTPGet.Path)
+	assert.Equal(t, int32(timeout+k8sProgressDeadlineMargin), *deployment.Spec.ProgressDeadlineSeconds)
+	annotations := deployment.Annotations
+	assert.Equal(t, "/models/qwen2-7b", annotations[k8sModelPathAnnotation])
+	assert.Equal(t, strconv.FormatFloat(modelSizeGiB(spec), 'f', 2, 64), annotations[k8sModelSizeAnnotation])
+
+	// Per-request override
+	disabled := false
+	opts, err = (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Probes: &dto.K8sProbes{Enabled: &disabled}})
+	require.NoError(t, err)
+	deployment, err = buildDeployment(spec, opts, opts.Expose.Port, 0, 0)
+	require.NoError(t, err)
+	assert.Nil(t, deployment.Spec.Template.Spec.Containers[0].StartupProbe)
+
+	_, err = (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Probes: &dto.K8sProbes{ReadinessPath: "health"}})
+	assert.Error(t, err)
+}
+
+func TestK8sModelSizeEstimate_ReusesAnnotation(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "model.safetensors"))
+	require.NoError(t, err)
+	require.NoError(t, f.Truncate(2<<30))
+	require.NoError(t, f.Close())
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        "svc-1",
+		Namespace:   "default",
+		Labels:      map[string]string{"app": "svc-1"},
+		Annotations: map[string]string{k8sModelPathAnnotation: dir, k8sModelSizeAnnotation: "12.50"},
+	}}
+	client, err := k8s.NewK8sClientForClientset(fake.NewClientset(deployment))
+	require.NoError(t, err)
+	t.Cleanup(client.Stop)
+	shimlet := &K8sShimlet{client: client}
+
+	// Same model path: the recorded estimate is reused instead of walking the directory
+	spec := &dto.RequirementSpec{ServiceId: "svc-1", ModelFileDir: dir}
+	assert.Equal(t, 12.5, shimlet.modelSizeEstimate(spec, "default"))
+
+	// Model path changed: estimate again
+	spec.ModelFileDir = t.TempDir()
+	spec.ModelName = "qwen2-7b"
+	assert.Equal(t, modelSizeGiB(spec), shimlet.modelSizeEstimate(spec, "default"))
+}
+
+func TestK8sBuildStatefulSet_Probes(t *testing.T) {
+	spec := multiNodeSpec()
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Expose: &dto.K8sExpose{Mode: K8sExposeClusterIP}})
+	require.NoError(t, err)
+
+	parallelism, err := engine.ResolveParallelism(spec)
+	require.NoError(t, err)
+
+	sts, err := buildStatefulSet(spec, opts, parallelism, opts.Expose.Port, 0, 0)
+	require.NoError(t, err)
+	readiness := sts.Spec.Template.Spec.Containers[0].ReadinessProbe
+	require.NotNil(t, readiness.Exec)
+	assert.Nil(t, readiness.HTTPGet)
+	assert.Contains(t, readiness.Exec.Command[2], `[ "${POD_NAME##*-}" != "0" ] ||`)
+	assert.Contains(t, readiness.Exec.Command[2], "http://127.0.0.1:8000/v1/models")
+}
+
+func TestDeploymentWorkload_Phase(t *testing.T) {
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}}
+	deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UnavailableReplicas: 1}
+	assert.Equal(t, dto.PhasePending, deploymentWorkload(deployment).phase, "loading the model")
+
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+	}}
+	assert.Equal(t, dto.PhaseFailed, deploymentWorkload(deployment).phase)
+
+	deployment.Status = appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	assert.Equal(t, dto.PhaseRunning, deploymentWorkload(deployment).phase)
+
+	// A new spec the controller has not observed yet, then old Pods still serving mid-rollout
+	deployment.Generation = 2
+	deployment.Status.ObservedGeneration = 1
+	assert.Equal(t, dto.PhasePending, deploymentWorkload(deployment).phase, "spec not observed")
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2}
+	assert.Equal(t, dto.PhasePending, deploymentWorkload(deployment).phase, "old replica still running")
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	assert.Equal(t, dto.PhaseRunning, deploymentWorkload(deployment).phase)
+}
+
+func TestStatefulSetWorkload_Phase(t *testing.T) {
+	replicas := int32(2)
+	sts := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}
+	sts.Generation = 2
+	sts.Status = appsv1.StatefulSetStatus{
+		ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2,
+		CurrentRevision: "rev-1", UpdateRevision: "rev-2",
+	}
+	assert.Equal(t, dto.PhasePending, statefulSetWorkload(sts).phase, "revision rollout not finished")
+
+	sts.Status.CurrentRevision = "rev-2"
+	assert.Equal(t, dto.PhaseRunning, statefulSetWorkload(sts).phase)
+
+	sts.Status.ObservedGeneration = 1
+	assert.Equal(t, dto.PhasePending, statefulSetWorkload(sts).phase, "spec not observed")
+}
+
+func TestPodFailureReason(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}}}
+	assert.Empty(t, podFailureReason(pod))
+
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}
+	assert.Empty(t, podFailureReason(pod))
+
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}
+	assert.Equal(t, "CrashLoopBackOff", podFailureReason(pod))
+}
@@ -2,6 +2,7 @@ package shimlets
 
 import (
 	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/engine"
 	"astron-xmod-shim/internal/core/shimlet"
 	cfg "astron-xmod-shim/internal/dto/config"
 	dto "astron-xmod-shim/internal/dto/deploy"
@@ -9,12 +10,14 @@ import (
 	"astron-xmod-shim/pkg/log"
 	"astron-xmod-shim/pkg/utils"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
+	"maps"
+	"slices"
 	"strconv"
+	"sync"
 
-	"path/filepath"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +32,27 @@ import (
 // Ensure K8sShimlet implements the Shimlet interface at compile time
 var _ shimlet.Shimlet = (*K8sShimlet)(nil)
 
+const (
+	defaultK8sNamespace = "default"
+	// k8sOptionsAnnotation records the request's K8sOptions so Status can rebuild the spec
+	k8sOptionsAnnotation = "astron-xmod-shim/k8s-options"
+	// k8sEngineAnnotation and k8sEngineOptionsAnnotation record the inference engine request
+	k8sEngineAnnotation        = "astron-xmod-shim/engine"
+	k8sEngineOptionsAnnotation = "astron-xmod-shim/engine-options"
+	// k8sParallelismAnnotation records the requested parallelism
+	k8sParallelismAnnotation = "astron-xmod-shim/parallelism"
+	// k8sModelPathAnnotation records the model path; k8sModelSizeAnnotation the model size
+	// in GiB estimated for that path, reused by later applies
+	k8sModelPathAnnotation = "astron-xmod-shim/model-path"
+	k8sModelSizeAnnotation = "astron-xmod-shim/model-size-gib"
+)
+
+// reservedK8sLabels are set by the shim itself and select the service's Pods.
+var reservedK8sLabels = map[string]struct{}{
+	"app":        {},
+	"managed-by": {},
+}
+
 func init() {
 	shimlet.Registry.AutoRegister(&K8sShimlet{})
 }
@@ -37,6 +61,11 @@ func init() {
 // It uses server-side apply to declaratively manage Deployment resources.
 type K8sShimlet struct {
 	client *k8s.K8sClient
+	conf   *cfg.K8sConfig
+
+	// portsMu guards ports, the node ports and host ports reserved per service
+	portsMu sync.Mutex
+	ports   map[int]string
 }
 
 // ID returns the unique identifier for this shimlet.
@@ -49,6 +78,9 @@ func (k *K8sShimlet) InitWithConfig(confPath string) error {
 	if err != nil {
 		return err
 	}
+	if err := completeK8sOptions(mergeK8sOptions(k8sCfg.Defaults, nil)); err != nil {
+		return fmt.Errorf("invalid k8s defaults: %w", err)
+	}
 	client, err := k8s.NewK8sClient(k8sCfg)
 	if err != nil {
 		return errors.New("failed to initialize K8s client")
@@ -57,47 +89,272 @@ func (k *K8sShimlet) InitWithConfig(confPath string) error {
 		return nil
 	}
 	k.client = client
+	k.conf = k8sCfg
 	return nil
 }
 
 // Apply deploys a model server using a Kubernetes Deployment via Server-Side Apply.
 // Key fixes and features:
+//   - Image, command and args are rendered by the inference engine named in the spec
 //   - Strips 'local:' prefix from model path (vLLM does not accept it)
 //   - Ensures modelDirPath refers to a directory, not a file
 //   - Correctly sets HostPath volume type to Directory
 //   - Mounts the model volume at the same path used in --model and MODEL env
+//   - Namespace, image and scheduling come from the configured defaults,
+//     overridden per request by deploySpec.K8s
+//   - Exposes the server according to the expose mode; node ports and host ports
+//     are allocated deterministically and kept across re-applies
+//   - Multi-node deployments (parallelism.nodes > 1) run as a StatefulSet forming a Ray
+//     cluster, with a headless Service for the workers to reach the head
 //
 // Returns a success message with exposed port, or an error if deployment fails.
 func (k *K8sShimlet) Apply(deploySpec *dto.RequirementSpec) error {
-	// Generate deployment and container names
-	deploymentName := utils.ModelNameToDeploymentName(deploySpec.ModelName) + "-" + deploySpec.ServiceId
-	mainContainerName := utils.ModelNameToDeploymentName(deploySpec.ModelName)
-	imageName := "artifacts.iflytek.com/docker-private/aiaas/vllm-openai:v0.4.2"
-	modelDirPath := deploySpec.ModelFileDir // Use mapped model path from pipeline
+	if k.client == nil {
+		return errors.New("K8s client is not initialized")
+	}
+	opts, err := k.resolveOptions(deploySpec.K8s)
+	if err != nil {
+		return err
+	}
+	parallelism, err := engine.ResolveParallelism(deploySpec)
+	if err != nil {
+		return err
+	}
+
+	// Host networking listens on the allocated port itself; otherwise the Service port is used
+	containerPort, allocatedPort := opts.Expose.Port, 0
+	if allocatesPort(opts.Expose.Mode) {
+		allocatedPort, err = k.allocatePort(deploySpec.ServiceId, opts.Namespace, opts.Expose)
+		if err != nil {
+			return err
+		}
+		if opts.Expose.Mode == K8sExposeHostNetwork {
+			containerPort = allocatedPort
+		}
+	}
+
+	sizeGiB := k.modelSizeEstimate(deploySpec, opts.Namespace)
+
+	applyOpts := metav1.ApplyOptions{FieldManager: "astron-xmod-shim", Force: true}
+	kind := k8sKindDeployment
+	if parallelism.MultiNode() {
+		kind = k8sKindStatefulSet
+		statefulSetApply, err := buildStatefulSet(deploySpec, opts, parallelism, containerPort, allocatedPort, sizeGiB)
+		if err != nil {
+			return err
+		}
+		result, err := k.client.GetClientSet().AppsV1().StatefulSets(opts.Namespace).Apply(context.Background(), statefulSetApply, applyOpts)
+		if err != nil {
+			return fmt.Errorf("failed to deploy application: %w", err)
+		}
+		log.Info("StatefulSet %s/%s succeeded with %d nodes, exposed via %s on port %d",
+			result.Namespace, result.Name, parallelism.Nodes, opts.Expose.Mode, containerPort)
+	} else {
+		deploymentApply, err := buildDeployment(deploySpec, opts, containerPort, allocatedPort, sizeGiB)
+		if err != nil {
+			return err
+		}
+
+		// Perform Server-Side Apply to create or update the Deployment
+		result, err := k.client.GetClientSet().AppsV1().Deployments(opts.Namespace).Apply(context.Background(), deploymentApply, applyOpts)
+		if err != nil {
+			return fmt.Errorf("failed to deploy application: %w", err)
+		}
+		log.Info("Deployment %s/%s succeeded, exposed via %s on port %d", result.Namespace, result.Name, opts.Expose.Mode, containerPort)
+	}
+
+	if err := k.applyExposure(deploySpec, opts, workloadLabels(deploySpec, opts), allocatedPort); err != nil {
+		return err
+	}
+
+	// The namespace override or the topology may have changed since the last apply:
+	// remove workloads left behind
+	_, err = k.deleteWorkloads(deploySpec.ServiceId, func(w *k8sWorkload) bool {
+		return w.namespace != opts.Namespace || w.kind != kind
+	})
+	return err
+}
+
+// resolveOptions merges the request overrides into the configured defaults.
+func (k *K8sShimlet) resolveOptions(override *dto.K8sOptions) (*dto.K8sOptions, error) {
+	defaults := dto.K8sOptions{}
+	if k.conf != nil {
+		defaults = k.conf.Defaults
+	}
+	opts := mergeK8sOptions(defaults, override)
+	if err := completeK8sOptions(opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// completeK8sOptions fills in the built-in defaults and validates the options.
+func completeK8sOptions(opts *dto.K8sOptions) error {
+	if opts.Namespace == "" {
+		opts.Namespace = defaultK8sNamespace
+	}
+	switch corev1.PullPolicy(opts.ImagePullPolicy) {
+	case "":
+		opts.ImagePullPolicy = string(corev1.PullIfNotPresent)
+	case corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		return fmt.Errorf("unsupported image pull policy: %s", opts.ImagePullPolicy)
+	}
+	for key := range opts.Labels {
+		if _, reserved := reservedK8sLabels[key]; reserved {
+			return fmt.Errorf("label %s is managed by the shim and cannot be overridden", key)
+		}
+	}
+	if opts.Expose == nil {
+		opts.Expose = &dto.K8sExpose{}
+	}
+	if opts.Probes == nil {
+		opts.Probes = &dto.K8sProbes{}
+	}
+	if err := completeK8sProbes(opts.Probes); err != nil {
+		return err
+	}
+	return completeK8sExpose(opts.Expose)
+}
 
-	// Validate model path is provided
-	if modelDirPath == "" {
-		return errors.New("model path cannot be empty; please provide a valid model name")
+// mergeK8sOptions overlays override on defaults, see dto.K8sOptions for the rules.
+func mergeK8sOptions(defaults dto.K8sOptions, override *dto.K8sOptions) *dto.K8sOptions {
+	opts := defaults
+	opts.Annotations = maps.Clone(defaults.Annotations)
+	opts.Labels = maps.Clone(defaults.Labels)
+	if override == nil {
+		opts.Expose = mergeK8sExpose(defaults.Expose, nil)
+		opts.Probes = mergeK8sProbes(defaults.Probes, nil)
+		return &opts
+	}
+	opts.Expose = mergeK8sExpose(defaults.Expose, override.Expose)
+	opts.Probes = mergeK8sProbes(defaults.Probes, override.Probes)
+	if override.Namespace != "" {
+		opts.Namespace = override.Namespace
+	}
+	if override.Image != "" {
+		opts.Image = override.Image
+	}
+	if override.ImagePullPolicy != "" {
+		opts.ImagePullPolicy = override.ImagePullPolicy
+	}
+	if override.ImagePullSecrets != nil {
+		opts.ImagePullSecrets = override.ImagePullSecrets
+	}
+	if override.NodeSelector != nil {
+		opts.NodeSelector = override.NodeSelector
 	}
+	if override.Affinity != nil {
+		opts.Affinity = override.Affinity
+	}
+	if override.Tolerations != nil {
+		opts.Tolerations = override.Tolerations
+	}
+	if override.PriorityClassName != "" {
+		opts.PriorityClassName = override.PriorityClassName
+	}
+	if override.ServiceAccountName != "" {
+		opts.ServiceAccountName = override.ServiceAccountName
+	}
+	if len(override.Annotations) > 0 {
+		if opts.Annotations == nil {
+			opts.Annotations = map[string]string{}
+		}
+		maps.Copy(opts.Annotations, override.Annotations)
+	}
+	if len(override.Labels) > 0 {
+		if opts.Labels == nil {
+			opts.Labels = map[string]string{}
+		}
+		maps.Copy(opts.Labels, override.Labels)
+	}
+	return &opts
+}
 
-	// If the path points to a model file, extract its parent directory
-	if strings.HasSuffix(strings.ToLower(modelDirPath), ".bin") ||
-		strings.HasSuffix(strings.ToLower(modelDirPath), ".safetensors") ||
-		strings.HasSuffix(strings.ToLower(modelDirPath), ".pt") ||
-		strings.HasSuffix(strings.ToLower(modelDirPath), ".gguf") {
-		modelDirPath = filepath.Dir(modelDirPath)
+// workloadLabels returns the labels of every resource created for the service.
+// Extra labels go first so that the shim's own labels always win.
+func workloadLabels(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions) map[string]string {
+	result := maps.Clone(opts.Labels)
+	if result == nil {
+		result = map[string]string{}
 	}
+	result["app"] = deploySpec.ServiceId
+	result["managed-by"] = "astron-xmod-shim"
+	return result
+}
 
-	// Final validation of resolved model directory path
-	if modelDirPath == "" || modelDirPath == "." || modelDirPath == "/" {
-		return errors.New("resolved model path is invalid")
+// buildDeployment renders the Deployment apply configuration for the spec.
+// allocatedPort is the node port or host port recorded for later re-applies, 0 if none;
+// modelSizeGiB sizes the startup timeout, 0 if unknown.
+func buildDeployment(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, containerPort, allocatedPort int, modelSizeGiB float64) (*appsv1apply.DeploymentApplyConfiguration, error) {
+	template, annotations, err := buildPodTemplate(deploySpec, opts, containerPort, allocatedPort, modelSizeGiB)
+	if err != nil {
+		return nil, err
+	}
+	// Generate deployment name
+	deploymentName := utils.ModelNameToDeploymentName(deploySpec.ModelName) + "-" + deploySpec.ServiceId
+
+	// Build Deployment object using Apply Configuration pattern
+	deploymentApply := &appsv1apply.DeploymentApplyConfiguration{}
+	deploymentApply.WithAPIVersion("apps/v1")
+	deploymentApply.WithKind("Deployment")
+	deploymentApply.WithName(deploymentName)
+	deploymentApply.WithNamespace(opts.Namespace)
+	deploymentApply.WithLabels(workloadLabels(deploySpec, opts))
+	deploymentApply.WithAnnotations(annotations)
+
+	// Configure Deployment spec
+	spec := &appsv1apply.DeploymentSpecApplyConfiguration{}
+	spec.WithReplicas(int32(deploySpec.ReplicaCount))
+	// Pods loading a large model stay unready for a long time; only report the rollout
+	// as stuck once they exceed the startup timeout
+	spec.WithProgressDeadlineSeconds(progressDeadlineSeconds(modelSizeGiB, opts))
+
+	// Define label selector for Pod matching
+	selector := &metav1apply.LabelSelectorApplyConfiguration{}
+	selector.WithMatchLabels(map[string]string{"app": deploySpec.ServiceId})
+	spec.WithSelector(selector)
+
+	// Attach template to Deployment spec
+	spec.WithTemplate(template)
+	deploymentApply.WithSpec(spec)
+	return deploymentApply, nil
+}
+
+// buildPodTemplate renders the Pod template shared by Deployments and multi-node StatefulSets,
+// together with the annotations recording the spec on the workload.
+func buildPodTemplate(deploySpec *dto.RequirementSpec, opts *dto.K8sOptions, containerPort, allocatedPort int, modelSizeGiB float64) (*corev1apply.PodTemplateSpecApplyConfiguration, map[string]string, error) {
+	port := int32(containerPort)
+	// Generate container name
+	mainContainerName := utils.ModelNameToDeploymentName(deploySpec.ModelName)
+	// Use mapped model path from pipeline; a weight file path resolves to its parent directory
+	modelDirPath, err := resolveModelDir(deploySpec.ModelFileDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Render image, command and args for the inference engine; an empty image
+	// in the options falls back to the engine's image. Multi-node Pods wrap the full
+	// command in the Ray launch script.
+	parallelism, err := engine.ResolveParallelism(deploySpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendered, err := engine.Registry.Render(deploySpec, engine.Target{
+		ModelDir: modelDirPath,
+		Port:     containerPort,
+		Image:    opts.Image,
+		Local:    parallelism.MultiNode(),
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Initialize container configuration
 	container := &corev1apply.ContainerApplyConfiguration{}
 	container.WithName(mainContainerName)
-	container.WithImage(imageName)
-	container.WithImagePullPolicy(corev1.PullIfNotPresent)
+	container.WithImage(rendered.Image)
+	container.WithImagePullPolicy(corev1.PullPolicy(opts.ImagePullPolicy))
 
 	// Configure resource requirements if specified
 	if deploySpec.ResourceRequirements != nil {
@@ -115,9 +372,7 @@ func (k *K8sShimlet) Apply(deploySpec *dto.RequirementSpec) error {
 		container.WithResources(resources)
 	}
 
-	// Allocate random NodePort in range 30000–32767
-	randomPort := rand.Int31n(2768) + 30000
-	portStr := fmt.Sprintf("%d", randomPort)
+	portStr := fmt.Sprintf("%d", port)
 
 	// Define environment variables for the container
 	envVars := []*corev1apply.EnvVarApplyConfiguration{
@@ -133,22 +388,29 @@ func (k *K8sShimlet) Apply(deploySpec *dto.RequirementSpec) error {
 			Name:  ptr("PORT"),
 			Value: &portStr,
 		},
-		{
-			Name:  ptr("TRANSFORMERS_OFFLINE"),
-			Value: ptr("1"), // Enforce offline mode to prevent Hugging Face downloads
-		},
-		{
-			Name:  ptr("HF_HOME"),
-			Value: ptr("/tmp"), // Avoid permission issues with default HF cache location
-		},
 		{
 			Name:  ptr("SERVICE_ID"),
 			Value: ptr(deploySpec.ServiceId), // Persist serviceId in environment variable
 		},
 	}
 
-	// Append custom environment variables from deployment spec
-	for _, env := range deploySpec.Env {
+	if deploySpec.ContextLength > 0 {
+		// Recorded so Status can report the requested context length
+		envVars = append(envVars, corev1apply.EnvVar().
+			WithName("CONTEXT_LENGTH").
+			WithValue(strconv.Itoa(deploySpec.ContextLength)))
+	}
+
+	if parallelism.MultiNode() {
+		// The launch script tells the head from the workers by the Pod's ordinal
+		envVars = append(envVars, corev1apply.EnvVar().
+			WithName("POD_NAME").
+			WithValueFrom(corev1apply.EnvVarSource().
+				WithFieldRef(corev1apply.ObjectFieldSelector().WithFieldPath("metadata.name"))))
+	}
+
+	// Append the engine's environment, then custom environment variables from deployment spec
+	for _, env := range append(rendered.Env, deploySpec.Env...) {
 		envVar := &corev1apply.EnvVarApplyConfiguration{}
 		envVar.WithName(env.Key)
 		envVar.WithValue(env.Value)
@@ -160,68 +422,91 @@ func (k *K8sShimlet) Apply(deploySpec *dto.RequirementSpec) error {
 	container.WithPorts(
 		corev1apply.ContainerPort().
 			WithName("http").
-			WithContainerPort(randomPort),
+			WithContainerPort(port),
 	)
 
-	// Set command-line arguments for vLLM OpenAI API server
-	container.WithArgs(
-		"--host=0.0.0.0",
-		"--port="+portStr,
-		"--model="+modelDirPath, // Model path must match volume mount
-		"--dtype=auto",
-		"--served-model-name="+deploySpec.ModelName,
-		"--trust-remote-code", // Required for models like Qwen
-	)
+	// Probe the inference server so the Pod only becomes ready once the model serves
+	applyProbes(container, modelSizeGiB, opts, rendered, containerPort)
 
-	// Build Deployment object using Apply Configuration pattern
-	deploymentApply := &appsv1apply.DeploymentApplyConfiguration{}
-	deploymentApply.WithAPIVersion("apps/v1")
-	deploymentApply.WithKind("Deployment")
-	deploymentApply.WithName(deploymentName)
-	deploymentApply.WithNamespace("default")
-	deploymentApply.WithLabels(map[string]string{
-		"app":        deploySpec.ServiceId,
-		"managed-by": "astron-xmod-shim",
-	})
-	deploymentApply.WithAnnotations(map[string]string{
-		"astron-xmod-shim/service-id": deploySpec.ServiceId,
-		"astron-xmod-shim/model-name": deploySpec.ModelName,
-	})
+	// Set the engine's entrypoint (if the image has none) and command-line arguments
+	switch {
+	case parallelism.MultiNode():
+		command := append(slices.Clone(rendered.Command), rendered.Args...)
+		container.WithCommand("/bin/sh", "-c", rayLaunchScript(deploySpec, opts, parallelism, command))
+	case len(rendered.Command) > 0:
+		container.WithCommand(rendered.Command...)
+		container.WithArgs(rendered.Args...)
+	default:
+		container.WithArgs(rendered.Args...)
+	}
 
-	// Configure Deployment spec
-	spec := &appsv1apply.DeploymentSpecApplyConfiguration{}
-	spec.WithReplicas(int32(deploySpec.ReplicaCount))
+	resourceLabels := workloadLabels(deploySpec, opts)
 
-	// Define label selector for Pod matching
-	selector := &metav1apply.LabelSelectorApplyConfiguration{}
-	selector.WithMatchLabels(map[string]string{"app": deploySpec.ServiceId})
-	spec.WithSelector(selector)
+	// Record the spec fields that Status needs to rebuild the RequirementSpec,
+	// so services can be adopted again after the shim restarts
+	annotations := maps.Clone(opts.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations["astron-xmod-shim/service-id"] = deploySpec.ServiceId
+	annotations["astron-xmod-shim/model-name"] = deploySpec.ModelName
+	annotations[k8sModelPathAnnotation] = deploySpec.ModelFileDir
+	if modelSizeGiB > 0 {
+		annotations[k8sModelSizeAnnotation] = strconv.FormatFloat(modelSizeGiB, 'f', 2, 64)
+	}
+	annotations["astron-xmod-shim/goal-set-name"] = deploySpec.GoalSetName
+	annotations["astron-xmod-shim/shimlet-name"] = deploySpec.ShimletName
+	annotations[k8sExposeModeAnnotation] = opts.Expose.Mode
+	annotations[k8sEngineAnnotation] = rendered.Engine
+	if allocatedPort != 0 {
+		annotations[k8sPortAnnotation] = strconv.Itoa(allocatedPort)
+	}
+	if deploySpec.K8s != nil {
+		// Only the request overrides are recorded; defaults may change with the config
+		data, err := json.Marshal(deploySpec.K8s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode k8s options: %w", err)
+		}
+		annotations[k8sOptionsAnnotation] = string(data)
+	}
+	if deploySpec.EngineOptions != nil {
+		data, err := json.Marshal(deploySpec.EngineOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode engine options: %w", err)
+		}
+		annotations[k8sEngineOptionsAnnotation] = string(data)
+	}
+	if deploySpec.Parallelism != nil {
+		data, err := json.Marshal(deploySpec.Parallelism)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode parallelism: %w", err)
+		}
+		annotations[k8sParallelismAnnotation] = string(data)
+	}
 
 	// Configure Pod template
 	template := &corev1apply.PodTemplateSpecApplyConfiguration{}
-	template.WithLabels(map[string]string{"app": deploySpec.ServiceId})
+	template.WithLabels(resourceLabels)
+	if len(opts.Annotations) > 0 {
+		template.WithAnnotations(opts.Annotations)
+	}
 
 	// Configure Pod specification
 	podSpec := &corev1apply.PodSpecApplyConfiguration{}
-	podSpec.WithHostNetwork(true) // Use host network for direct port exposure
-
-	// Set nodeSelector as a local variable
-	// This can be modified to match specific node requirements
-	nodeSelector := map[string]string{}
-	nodeSelector["kubernetes.io/hostname"] = "dx-l20-10.246.53.166.maas.cn"
-	// Example: To schedule on nodes with GPU label
-	// nodeSelector["nvidia.com/gpu.present"] = "true"
-	// Enable nodeSelector if it contains any key-value pairs
-	if len(nodeSelector) > 0 {
-		podSpec.WithNodeSelector(nodeSelector)
-	}
-
-	// Tolerate all taints to allow scheduling on dedicated GPU nodes
-	podSpec.WithTolerations(
-		corev1apply.Toleration().
-			WithKey("").
-			WithOperator(corev1.TolerationOpExists),
-	)
+	if opts.Expose.Mode == K8sExposeHostNetwork {
+		podSpec.WithHostNetwork(true) // Use host network for direct port exposure
+		if parallelism.MultiNode() {
+			// Workers resolve the head through cluster DNS
+			podSpec.WithDNSPolicy(corev1.DNSClusterFirstWithHostNet)
+		}
+	}
+	if parallelism.MultiNode() {
+		// Ray headless Service gives each Pod a stable DNS name
+		podSpec.WithSubdomain(rayServiceName(deploySpec.ServiceId))
+	}
+	if err := applyScheduling(podSpec, opts); err != nil {
+		return nil, nil, err
+	}
 
 	// Mount host model directory into the container using HostPath
 	podSpec.WithVolumes(
@@ -240,28 +525,103 @@ func (k *K8sShimlet) Apply(deploySpec *dto.RequirementSpec) error {
 			WithName("models").
 			WithMountPath(modelDirPath), // Must match --model argument
 	)
+	if parallelism.MultiNode() {
+		// Ray's object store lives in shared memory, which defaults to 64Mi in containers
+		podSpec.WithVolumes(corev1apply.Volume().
+			WithName("dshm").
+			WithEmptyDir(corev1apply.EmptyDirVolumeSource().WithMedium(corev1.StorageMediumMemory)))
+		container.WithVolumeMounts(corev1apply.VolumeMount().WithName("dshm").WithMountPath("/dev/shm"))
+	}
 
 	// Attach container to Pod spec
 	podSpec.WithContainers(container)
 
 	// Attach Pod spec to template
 	template.WithSpec(podSpec)
+	return template, annotations, nil
+}
 
-	// Attach template to Deployment spec
-	spec.WithTemplate(template)
-	deploymentApply.WithSpec(spec)
+// applyScheduling sets pull secrets, placement and identity on the Pod spec.
+func applyScheduling(podSpec *corev1apply.PodSpecApplyConfiguration, opts *dto.K8sOptions) error {
+	for _, secret := range opts.ImagePullSecrets {
+		podSpec.WithImagePullSecrets(corev1apply.LocalObjectReference().WithName(secret))
+	}
+	if len(opts.NodeSelector) > 0 {
+		podSpec.WithNodeSelector(opts.NodeSelector)
+	}
+	if opts.Affinity != nil {
+		affinity, err := affinityApply(opts.Affinity)
+		if err != nil {
+			return err
+		}
+		podSpec.WithAffinity(affinity)
+	}
+	for _, t := range opts.Tolerations {
+		toleration := corev1apply.Toleration().
+			WithKey(t.Key).
+			WithOperator(t.Operator).
+			WithValue(t.Value).
+			WithEffect(t.Effect)
+		if t.TolerationSeconds != nil {
+			toleration.WithTolerationSeconds(*t.TolerationSeconds)
+		}
+		podSpec.WithTolerations(toleration)
+	}
+	if opts.PriorityClassName != "" {
+		podSpec.WithPriorityClassName(opts.PriorityClassName)
+	}
+	if opts.ServiceAccountName != "" {
+		podSpec.WithServiceAccountName(opts.ServiceAccountName)
+	}
+	return nil
+}
 
-	// Perform Server-Side Apply to create or update the Deployment
-	result, err := k.client.GetClientSet().AppsV1().Deployments("default").Apply(
-		context.Background(),
-		deploymentApply,
-		metav1.ApplyOptions{FieldManager: "astron-xmod-shim", Force: true},
-	)
+// affinityApply converts an Affinity into its apply configuration.
+// Both share the same JSON shape, so a round trip keeps every field.
+func affinityApply(affinity *corev1.Affinity) (*corev1apply.AffinityApplyConfiguration, error) {
+	data, err := json.Marshal(affinity)
 	if err != nil {
-		return fmt.Errorf("failed to deploy application: %w", err)
+		return nil, fmt.Errorf("failed to encode affinity: %w", err)
 	}
-	log.Info("Deployment %s/%s succeeded with hostNetwork on port %d", result.Namespace, result.Name, randomPort)
-	return nil
+	result := &corev1apply.AffinityApplyConfiguration{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to convert affinity: %w", err)
+	}
+	return result, nil
+}
+
+// deleteWorkloads deletes the service's Deployments and StatefulSets, in any namespace,
+// accepted by match. It returns how many workloads were found.
+func (k *K8sShimlet) deleteWorkloads(serviceID string, match func(*k8sWorkload) bool) (int, error) {
+	// Use label selector to find workloads with the given serviceId
+	labelSelector := labels.Set{"app": serviceID}.AsSelector().String()
+	opts := metav1.ListOptions{LabelSelector: labelSelector}
+
+	workloads, err := k.listWorkloads(metav1.NamespaceAll, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workloads for service %s: %w", serviceID, err)
+	}
+
+	found := 0
+	for _, w := range workloads {
+		if !match(w) {
+			continue
+		}
+		found++
+		// Continue deleting other workloads even if one fails
+		logWorkloadDelete(w, k.deleteWorkload(w))
+	}
+	return found, nil
+}
+
+// builtinEnvNames lists the environment variables injected by Apply itself;
+// the inference engines' own variables are skipped as well.
+var builtinEnvNames = map[string]struct{}{
+	"MODEL":          {},
+	"SERVING_ENGINE": {},
+	"PORT":           {},
+	"SERVICE_ID":     {},
+	"POD_NAME":       {},
 }
 
 // ptr creates a pointer to a string value (helper for ApplyConfigurations).
@@ -269,41 +629,25 @@ func ptr(s string) *string { return &s }
 
 // Delete removes deployed resources associated with the given resourceId.
 // In our implementation, resourceId corresponds to serviceId, which is used to find
-// and delete all Kubernetes Deployments labeled with this serviceId.
+// and delete all Kubernetes Deployments and StatefulSets labeled with this serviceId,
+// together with the Service, Ingress or HTTPRoute exposing them.
 func (k *K8sShimlet) Delete(resourceId string) error {
 	if k.client == nil {
 		return errors.New("K8s client is not initialized")
 	}
 
-	// Use label selector to find deployments with the given serviceId
-	labelSelector := labels.Set{"app": resourceId}.AsSelector().String()
-	opts := metav1.ListOptions{LabelSelector: labelSelector}
-
-	// List deployments with the specified serviceId
-	deployments, err := k.client.ListDeployments("default", opts)
+	found, err := k.deleteWorkloads(resourceId, func(*k8sWorkload) bool { return true })
 	if err != nil {
-		return fmt.Errorf("failed to list deployments for service %s: %w", resourceId, err)
+		return err
 	}
-
-	// Delete each found deployment
-	for _, deployment := range deployments {
-		// Delete the deployment using Kubernetes API
-		err = k.client.GetClientSet().AppsV1().Deployments(deployment.Namespace).Delete(
-			context.Background(),
-			deployment.Name,
-			metav1.DeleteOptions{},
-		)
-		if err != nil {
-			// Continue deleting other deployments even if one fails
-			log.Error("Failed to delete deployment %s/%s: %v", deployment.Namespace, deployment.Name, err)
-		} else {
-			log.Info("Successfully deleted deployment %s/%s", deployment.Namespace, deployment.Name)
-		}
+	if err := k.deleteExposure(resourceId, func(string, string) bool { return false }); err != nil {
+		return err
 	}
+	k.releasePorts(resourceId)
 
 	// If no deployments were found, consider it a success (already deleted)
-	if len(deployments) == 0 {
-		log.Info("No deployments found for service %s", resourceId)
+	if found == 0 {
+		log.Info("No workloads found for service %s", resourceId)
 	}
 
 	return nil
@@ -316,115 +660,64 @@ func (k *K8sShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
 		return nil, errors.New("K8s client is not initialized")
 	}
 
-	// Use label selector to find deployments with the given resourceId (serviceId)
+	// Use label selector to find workloads with the given resourceId (serviceId)
 	labelSelector := labels.Set{"app": resourceId}.AsSelector().String()
 	opts := metav1.ListOptions{LabelSelector: labelSelector}
 
-	// List deployments with the specified resourceId
-	deployments, err := k.client.ListDeployments("default", opts)
+	// List Deployments and StatefulSets with the specified resourceId
+	workloads, err := k.listWorkloads(metav1.NamespaceAll, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list deployments for resource %s: %w", resourceId, err)
+		return nil, fmt.Errorf("failed to list workloads for resource %s: %w", resourceId, err)
 	}
 
-	// If no deployments found, return terminated status
-	if len(deployments) == 0 {
+	// If no workloads found, return terminated status
+	if len(workloads) == 0 {
 		return &dto.RuntimeStatus{
 			DeploySpec: dto.RequirementSpec{ServiceId: resourceId},
 			Status:     dto.PhaseUnknown,
 		}, nil
 	}
 
-	// Get the first deployment (assuming one per serviceId)
-	deployment := deployments[0]
-
-	// Determine deployment status
-	var phase dto.DeployPhase
-	switch {
-	case deployment.Status.Replicas == 0:
-		phase = dto.PhaseTerminating
-	case deployment.Status.UnavailableReplicas > 0:
-		phase = dto.PhaseFailed
-	case deployment.Status.AvailableReplicas == deployment.Status.Replicas:
-		phase = dto.PhaseRunning
-	default:
-		phase = dto.PhasePending
+	// Get the first workload (assuming one per serviceId)
+	workload := workloads[0]
+	phase := workload.phase
+	// Pending covers both loading the model and Pods that cannot start
+	if phase == dto.PhasePending {
+		if reason := k.podFailure(workload); reason != "" {
+			log.Warn("Service %s failed to start: %s", resourceId, reason)
+			phase = dto.PhaseFailed
+		}
 	}
 
 	// Extract model name and path from annotations or labels
 	modelName := "unknown"
 	modelPath := "unknown"
 
-	if val, ok := deployment.Annotations["astron-xmod-shim/model-name"]; ok {
+	if val, ok := workload.annotations["astron-xmod-shim/model-name"]; ok {
 		modelName = val
 	}
-	if val, ok := deployment.Annotations["astron-xmod-shim/model-path"]; ok {
+	if val, ok := workload.annotations["astron-xmod-shim/model-path"]; ok && val != "" {
 		modelPath = val
-	}
-
-	// Extract replica count
-	replicaCount := int(*deployment.Spec.Replicas)
-
-	// 🌟 新增：从 PodTemplate 中提取容器端口（即 NodePort）
-	var nodePort int32 = 0
-	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		for _, c := range deployment.Spec.Template.Spec.Containers {
-			if c.Ports != nil {
-				for _, p := range c.Ports {
-					if p.Name == "http" {
-						nodePort = p.ContainerPort
-						break
-					}
-				}
-			}
-			if nodePort != 0 {
+	} else {
+		// Deployments created before the annotation existed: fall back to the model volume
+		for _, volume := range workload.template.Spec.Volumes {
+			if volume.Name == "models" && volume.HostPath != nil {
+				modelPath = volume.HostPath.Path
 				break
 			}
 		}
 	}
 
-	// 新增：获取任一运行中的 Pod 的 Node IP
-	var nodeIP string
-	if nodePort != 0 {
-		// 列出该 Deployment 的所有 Pod
-		podListOptions := metav1.ListOptions{
-			LabelSelector: labels.Set{"app": resourceId}.AsSelector().String(),
-		}
-		pods, err := k.client.GetClientSet().CoreV1().Pods("default").List(context.Background(), podListOptions)
-		if err != nil {
-			log.Warn("Failed to list pods for deployment %s: %v", deployment.Name, err)
-		} else {
-			for _, pod := range pods.Items {
-				if pod.Spec.NodeName != "" && pod.Status.Phase == corev1.PodRunning {
-					// 获取 Node 对象
-					node, err := k.client.GetClientSet().CoreV1().Nodes().Get(context.Background(), pod.Spec.NodeName, metav1.GetOptions{})
-					if err != nil {
-						continue
-					}
-					// 查找 InternalIP
-					for _, addr := range node.Status.Addresses {
-						if addr.Type == corev1.NodeInternalIP {
-							nodeIP = addr.Address
-							break
-						}
-					}
-					if nodeIP != "" {
-						break // 使用第一个运行中 Pod 的节点 IP
-					}
-				}
-			}
-		}
-	}
+	// Extract replica count
+	replicaCount := workload.replicaCount
 
-	// 🌟 构造 endpoint
-	var endpoint string
-	if nodeIP != "" && nodePort != 0 {
-		endpoint = fmt.Sprintf("http://%s:%d", nodeIP, nodePort)
-	}
+	// 按暴露方式从 Service / Ingress / HTTPRoute（host-network 时为 Pod 所在节点）解析 endpoint
+	endpoint := k.resolveEndpoint(workload, resourceId)
 
-	// 从Deployment中提取ResourceRequirements信息
+	// 从Pod模板中提取ResourceRequirements信息（多节点时为每个节点的显卡数）
 	var resourceRequirements *dto.ResourceRequirements
-	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		container := deployment.Spec.Template.Spec.Containers[0]
+	if len(workload.template.Spec.Containers) > 0 {
+		container := workload.template.Spec.Containers[0]
 		if len(container.Resources.Limits) > 0 {
 			for resourceName, quantity := range container.Resources.Limits {
 				// 检查是否是GPU资源
@@ -439,12 +732,19 @@ func (k *K8sShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
 		}
 	}
 
-	// 从Deployment的环境变量中提取ContextLength和Env信息
+	// 从容器的环境变量中提取ContextLength和Env信息
 	var contextLength int
 	var envVars []dto.Env
-	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		container := deployment.Spec.Template.Spec.Containers[0]
+	engineEnvNames := engine.Registry.BuiltinEnvNames()
+	if len(workload.template.Spec.Containers) > 0 {
+		container := workload.template.Spec.Containers[0]
 		for _, envVar := range container.Env {
+			_, builtin := builtinEnvNames[envVar.Name]
+			_, engineEnv := engineEnvNames[envVar.Name]
+			if builtin || engineEnv {
+				// Injected by Apply, not part of the user's spec
+				continue
+			}
 			switch envVar.Name {
 			case "CONTEXT_LENGTH":
 				if val, err := strconv.Atoi(envVar.Value); err == nil {
@@ -459,17 +759,27 @@ func (k *K8sShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
 		}
 	}
 
-	// 从Deployment注解中提取GoalSetName和ShimletName
+	// 从注解中提取GoalSetName和ShimletName
 	goalSetName := "opensource-llm-deploy" // 默认值
 	shimletName := "k8s"                   // 默认值
 
-	if val, ok := deployment.Annotations["astron-xmod-shim/goal-set-name"]; ok {
+	if val, ok := workload.annotations["astron-xmod-shim/goal-set-name"]; ok {
 		goalSetName = val
 	}
-	if val, ok := deployment.Annotations["astron-xmod-shim/shimlet-name"]; ok {
+	if val, ok := workload.annotations["astron-xmod-shim/shimlet-name"]; ok {
 		shimletName = val
 	}
 
+	// 从注解中还原请求级的 K8s 部署参数、推理引擎参数与并行方式；
+	// 早期部署没有引擎注解，视为默认引擎
+	var k8sOptions *dto.K8sOptions
+	var engineOptions *dto.EngineOptions
+	var parallelism *dto.ParallelismSpec
+	decodeWorkloadAnnotation(workload, k8sOptionsAnnotation, &k8sOptions)
+	decodeWorkloadAnnotation(workload, k8sEngineOptionsAnnotation, &engineOptions)
+	decodeWorkloadAnnotation(workload, k8sParallelismAnnotation, &parallelism)
+	engineID := workload.annotations[k8sEngineAnnotation]
+
 	// Build deploy spec
 	spec := dto.RequirementSpec{
 		ServiceId:            resourceId,
@@ -481,6 +791,10 @@ func (k *K8sShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
 		Env:                  envVars,
 		GoalSetName:          goalSetName,
 		ShimletName:          shimletName,
+		K8s:                  k8sOptions,
+		Engine:               engineID,
+		EngineOptions:        engineOptions,
+		Parallelism:          parallelism,
 	}
 
 	return &dto.RuntimeStatus{
@@ -490,6 +804,21 @@ func (k *K8sShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
 	}, nil
 }
 
+// decodeWorkloadAnnotation decodes a JSON annotation into *target, leaving it nil when the
+// annotation is missing or malformed.
+func decodeWorkloadAnnotation[T any](w *k8sWorkload, key string, target **T) {
+	val := w.annotations[key]
+	if val == "" {
+		return
+	}
+	decoded := new(T)
+	if err := json.Unmarshal([]byte(val), decoded); err != nil {
+		log.Warn("Failed to decode %s of %s %s/%s: %v", key, w.kind, w.namespace, w.name, err)
+		return
+	}
+	*target = decoded
+}
+
 // Description returns a brief description of the shimlet.
 func (k *K8sShimlet) Description() string { return "k8s shimlet" }
 
@@ -505,21 +834,21 @@ func (k *K8sShimlet) ListDeployedServices() ([]string, error) {
 		LabelSelector: labels.Set{"managed-by": "astron-xmod-shim"}.AsSelector().String(),
 	}
 
-	// 调用ListDeployments方法获取所有由astron-xmod-shim管理的部署
-	deployments, err := k.client.ListDeployments("default", listOptions)
+	// 获取所有由astron-xmod-shim管理的 Deployment 与 StatefulSet
+	workloads, err := k.listWorkloads(metav1.NamespaceAll, listOptions)
 	if err != nil {
-		return []string{}, fmt.Errorf("failed to list deployments: %w", err)
+		return []string{}, err
 	}
 
 	// 从部署中提取serviceId
 	var serviceIDs []string
-	for _, deployment := range deployments {
-		// 检查deployment是否有astron-xmod-shim/service-id注解
-		if serviceID, exists := deployment.Annotations["astron-xmod-shim/service-id"]; exists && serviceID != "" {
+	for _, workload := range workloads {
+		// 检查是否有astron-xmod-shim/service-id注解
+		if serviceID, exists := workload.annotations["astron-xmod-shim/service-id"]; exists && serviceID != "" {
 			serviceIDs = append(serviceIDs, serviceID)
 		} else {
 			// 尝试从标签中获取serviceId
-			if appLabel, exists := deployment.Labels["app"]; exists && appLabel != "" {
+			if appLabel, exists := workload.labels["app"]; exists && appLabel != "" {
 				serviceIDs = append(serviceIDs, appLabel)
 			}
 		}
@@ -0,0 +1,176 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestK8sResolveOptions_Defaults(t *testing.T) {
+	k := &K8sShimlet{}
+	opts, err := k.resolveOptions(nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultK8sNamespace, opts.Namespace)
+	assert.Empty(t, opts.Image, "empty image falls back to the engine image")
+	assert.Equal(t, string(corev1.PullIfNotPresent), opts.ImagePullPolicy)
+	assert.Empty(t, opts.NodeSelector)
+}
+
+func TestK8sResolveOptions_Override(t *testing.T) {
+	k := &K8sShimlet{conf: &cfg.K8sConfig{Defaults: dto.K8sOptions{
+		Namespace:        "serving",
+		Image:            "vllm:default",
+		ImagePullSecrets: []string{"default-cred"},
+		NodeSelector:     map[string]string{"kubernetes.io/hostname": "node-a"},
+		Tolerations:      []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		Annotations:      map[string]string{"team": "infra", "cost-center": "42"},
+		Labels:           map[string]string{"tier": "batch"},
+	}}}
+
+	opts, err := k.resolveOptions(&dto.K8sOptions{
+		Namespace:         "llm",
+		NodeSelector:      map[string]string{"gpu": "l20"},
+		PriorityClassName: "high",
+		Annotations:       map[string]string{"team": "nlp"},
+		Labels:            map[string]string{"owner": "alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "llm", opts.Namespace)
+	assert.Equal(t, "vllm:default", opts.Image)
+	assert.Equal(t, []string{"default-cred"}, opts.ImagePullSecrets)
+	// node selector is replaced, not merged
+	assert.Equal(t, map[string]string{"gpu": "l20"}, opts.NodeSelector)
+	assert.Len(t, opts.Tolerations, 1)
+	assert.Equal(t, "high", opts.PriorityClassName)
+	// annotations and labels are merged, the request wins
+	assert.Equal(t, map[string]string{"team": "nlp", "cost-center": "42"}, opts.Annotations)
+	assert.Equal(t, map[string]string{"tier": "batch", "owner": "alice"}, opts.Labels)
+	// the configured defaults are left untouched
+	assert.Equal(t, map[string]string{"team": "infra", "cost-center": "42"}, k.conf.Defaults.Annotations)
+	assert.Equal(t, map[string]string{"tier": "batch"}, k.conf.Defaults.Labels)
+}
+
+func TestK8sResolveOptions_Invalid(t *testing.T) {
+	k := &K8sShimlet{}
+	_, err := k.resolveOptions(&dto.K8sOptions{ImagePullPolicy: "Sometimes"})
+	assert.ErrorContains(t, err, "image pull policy")
+
+	_, err = k.resolveOptions(&dto.K8sOptions{Labels: map[string]string{"app": "other"}})
+	assert.ErrorContains(t, err, "cannot be overridden")
+}
+
+func TestK8sBuildDeployment(t *testing.T) {
+	seconds := int64(30)
+	spec := &dto.RequirementSpec{
+		ServiceId:    "svc-1",
+		ModelName:    "qwen",
+		ModelFileDir: "/models/qwen",
+		ReplicaCount: 2,
+		ShimletName:  "k8s",
+		K8s: &dto.K8sOptions{
+			Namespace:          "llm",
+			Image:              "vllm:v0.6.0",
+			ImagePullPolicy:    "Always",
+			ImagePullSecrets:   []string{"registry-cred"},
+			NodeSelector:       map[string]string{"gpu": "l20"},
+			ServiceAccountName: "runner",
+			PriorityClassName:  "high",
+			Tolerations: []corev1.Toleration{{
+				Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "llm",
+				Effect: corev1.TaintEffectNoExecute, TolerationSeconds: &seconds,
+			}},
+			Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"},
+					}}}},
+				},
+			}},
+			Annotations: map[string]string{"team": "nlp"},
+			Labels:      map[string]string{"tier": "online"},
+		},
+	}
+	opts, err := (&K8sShimlet{}).resolveOptions(spec.K8s)
+	require.NoError(t, err)
+
+	deployment, err := buildDeployment(spec, opts, 31000, 31000, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "llm", *deployment.Namespace)
+	assert.Equal(t, "svc-1", deployment.Labels["app"])
+	assert.Equal(t, "astron-xmod-shim", deployment.Labels["managed-by"])
+	assert.Equal(t, "online", deployment.Labels["tier"])
+	assert.Equal(t, "nlp", deployment.Annotations["team"])
+
+	// the request overrides are recorded for Status
+	var recorded dto.K8sOptions
+	require.NoError(t, json.Unmarshal([]byte(deployment.Annotations[k8sOptionsAnnotation]), &recorded))
+	assert.Equal(t, *spec.K8s, recorded)
+
+	template := deployment.Spec.Template
+	assert.Equal(t, "online", template.Labels["tier"])
+	assert.Equal(t, "nlp", template.Annotations["team"])
+	podSpec := template.Spec
+	require.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "vllm:v0.6.0", *podSpec.Containers[0].Image)
+	assert.Equal(t, corev1.PullAlways, *podSpec.Containers[0].ImagePullPolicy)
+	require.Len(t, podSpec.ImagePullSecrets, 1)
+	assert.Equal(t, "registry-cred", *podSpec.ImagePullSecrets[0].Name)
+	assert.Equal(t, map[string]string{"gpu": "l20"}, podSpec.NodeSelector)
+	assert.Equal(t, "runner", *podSpec.ServiceAccountName)
+	assert.Equal(t, "high", *podSpec.PriorityClassName)
+	require.Len(t, podSpec.Tolerations, 1)
+	assert.Equal(t, "dedicated", *podSpec.Tolerations[0].Key)
+	assert.Equal(t, int64(30), *podSpec.Tolerations[0].TolerationSeconds)
+	require.NotNil(t, podSpec.Affinity)
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Len(t, terms, 1)
+	assert.Equal(t, "zone", *terms[0].MatchExpressions[0].Key)
+}
+
+func TestK8sBuildDeployment_NoOverrides(t *testing.T) {
+	spec := &dto.RequirementSpec{ServiceId: "svc-2", ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1}
+	opts, err := (&K8sShimlet{}).resolveOptions(nil)
+	require.NoError(t, err)
+
+	deployment, err := buildDeployment(spec, opts, 31001, 31001, 0)
+	require.NoError(t, err)
+	assert.Equal(t, defaultK8sNamespace, *deployment.Namespace)
+	assert.NotContains(t, deployment.Annotations, k8sOptionsAnnotation)
+	assert.Empty(t, deployment.Spec.Template.Spec.NodeSelector)
+	assert.Nil(t, deployment.Spec.Template.Spec.Affinity)
+	assert.Empty(t, deployment.Spec.Template.Annotations)
+
+	vllm, err := engine.Registry.Get(engine.DefaultEngine)
+	require.NoError(t, err)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, vllm.DefaultImage(), *container.Image)
+	assert.Empty(t, container.Command)
+	assert.Contains(t, container.Args, "--port=31001")
+	assert.Equal(t, "vllm", deployment.Annotations[k8sEngineAnnotation])
+}
+
+func TestK8sBuildDeployment_Engine(t *testing.T) {
+	spec := &dto.RequirementSpec{
+		ServiceId: "svc-3", ModelName: "qwen", ModelFileDir: "/models/qwen", ReplicaCount: 1,
+		Engine:        "sglang",
+		EngineOptions: &dto.EngineOptions{Quantization: "fp8", ExtraArgs: []string{"--enable-torch-compile"}},
+	}
+	opts, err := (&K8sShimlet{}).resolveOptions(&dto.K8sOptions{Expose: &dto.K8sExpose{Mode: K8sExposeClusterIP}})
+	require.NoError(t, err)
+
+	deployment, err := buildDeployment(spec, opts, opts.Expose.Port, 0, 0)
+	require.NoError(t, err)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "lmsysorg/sglang:latest", *container.Image)
+	assert.Equal(t, []string{"python3", "-m", "sglang.launch_server"}, container.Command)
+	assert.Contains(t, container.Args, "--quantization")
+	assert.Equal(t, "--enable-torch-compile", container.Args[len(container.Args)-1])
+	assert.Equal(t, "sglang", deployment.Annotations[k8sEngineAnnotation])
+	assert.JSONEq(t, `{"quantization":"fp8","extraArgs":["--enable-torch-compile"]}`, deployment.Annotations[k8sEngineOptionsAnnotation])
+}
@@ -0,0 +1,179 @@
+package shimlets
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	k8sKindDeployment  = "Deployment"
+	k8sKindStatefulSet = "StatefulSet"
+)
+
+// k8sWorkload is the Deployment, or the StatefulSet of a multi-node deployment, running a service.
+// It holds what Status, endpoint resolution and port allocation need from either kind.
+type k8sWorkload struct {
+	kind        string
+	namespace   string
+	name        string
+	labels      map[string]string
+	annotations map[string]string
+	template    corev1.PodTemplateSpec
+	// replicaCount is the service's replica count: Deployment replicas, or one
+	// replica group per StatefulSet
+	replicaCount int
+	phase        dto.DeployPhase
+}
+
+// deploymentWorkload wraps a Deployment. Pods only become available once their readiness
+// probe passes, so unavailable Pods are still loading the model unless the rollout
+// exceeded its progress deadline. The Deployment is running only once the controller has
+// observed the latest spec and every replica runs it, so Pods of the previous spec still
+// serving during a rolling update do not count.
+func deploymentWorkload(deployment *appsv1.Deployment) *k8sWorkload {
+	replicas := 1
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+	status := deployment.Status
+	rolledOut := status.ObservedGeneration >= deployment.Generation &&
+		int(status.UpdatedReplicas) == replicas && int(status.Replicas) == replicas
+	var phase dto.DeployPhase
+	switch {
+	case status.Replicas == 0:
+		phase = dto.PhaseTerminating
+	case progressDeadlineExceeded(deployment):
+		phase = dto.PhaseFailed
+	case rolledOut && status.UnavailableReplicas == 0 && status.AvailableReplicas == status.Replicas:
+		phase = dto.PhaseRunning
+	default:
+		phase = dto.PhasePending
+	}
+	return &k8sWorkload{
+		kind:         k8sKindDeployment,
+		namespace:    deployment.Namespace,
+		name:         deployment.Name,
+		labels:       deployment.Labels,
+		annotations:  deployment.Annotations,
+		template:     deployment.Spec.Template,
+		replicaCount: replicas,
+		phase:        phase,
+	}
+}
+
+// progressDeadlineExceeded reports whether Kubernetes gave up waiting for the rollout.
+func progressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+			condition.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// statefulSetWorkload wraps the StatefulSet of a multi-node deployment. The group is
+// running only once every Pod runs the latest revision and is available, so the nodes
+// are monitored as one service.
+func statefulSetWorkload(sts *appsv1.StatefulSet) *k8sWorkload {
+	desired := int32(1)
+	if sts.Spec.Replicas != nil {
+		desired = *sts.Spec.Replicas
+	}
+	status := sts.Status
+	rolledOut := status.ObservedGeneration >= sts.Generation && status.UpdatedReplicas == desired &&
+		status.UpdateRevision == status.CurrentRevision
+	var phase dto.DeployPhase
+	switch {
+	case status.Replicas == 0:
+		phase = dto.PhaseTerminating
+	case rolledOut && status.AvailableReplicas >= desired:
+		phase = dto.PhaseRunning
+	default:
+		phase = dto.PhasePending
+	}
+	return &k8sWorkload{
+		kind:         k8sKindStatefulSet,
+		namespace:    sts.Namespace,
+		name:         sts.Name,
+		labels:       sts.Labels,
+		annotations:  sts.Annotations,
+		template:     sts.Spec.Template,
+		replicaCount: 1,
+		phase:        phase,
+	}
+}
+
+// headPodName returns the Pod serving HTTP for multi-node workloads, empty when every Pod serves.
+func (w *k8sWorkload) headPodName() string {
+	if w.kind != k8sKindStatefulSet {
+		return ""
+	}
+	return w.name + "-0"
+}
+
+// podFailure returns why a Pod of the workload cannot start, or why a multi-node group
+// cannot be fully scheduled, empty if none is failing.
+func (k *K8sShimlet) podFailure(w *k8sWorkload) string {
+	opts := metav1.ListOptions{LabelSelector: labels.Set{"app": w.labels["app"]}.AsSelector().String()}
+	pods, err := k.client.GetClientSet().CoreV1().Pods(w.namespace).List(context.Background(), opts)
+	if err != nil {
+		log.Warn("Failed to list pods for %s %s/%s: %v", w.kind, w.namespace, w.name, err)
+		return ""
+	}
+	for i := range pods.Items {
+		if reason := podFailureReason(&pods.Items[i]); reason != "" {
+			return fmt.Sprintf("pod %s: %s", pods.Items[i].Name, reason)
+		}
+	}
+	if w.kind == k8sKindStatefulSet {
+		return partialScheduleReason(pods.Items, time.Now())
+	}
+	return ""
+}
+
+// listWorkloads lists the Deployments and StatefulSets matching opts.
+func (k *K8sShimlet) listWorkloads(namespace string, opts metav1.ListOptions) ([]*k8sWorkload, error) {
+	deployments, err := k.client.ListDeployments(namespace, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	statefulSets, err := k.client.ListStatefulSets(namespace, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	workloads := make([]*k8sWorkload, 0, len(deployments)+len(statefulSets))
+	for _, deployment := range deployments {
+		workloads = append(workloads, deploymentWorkload(deployment))
+	}
+	for _, sts := range statefulSets {
+		workloads = append(workloads, statefulSetWorkload(sts))
+	}
+	return workloads, nil
+}
+
+// deleteWorkload deletes the Deployment or StatefulSet behind w.
+func (k *K8sShimlet) deleteWorkload(w *k8sWorkload) error {
+	apps := k.client.GetClientSet().AppsV1()
+	if w.kind == k8sKindStatefulSet {
+		return apps.StatefulSets(w.namespace).Delete(context.Background(), w.name, metav1.DeleteOptions{})
+	}
+	return apps.Deployments(w.namespace).Delete(context.Background(), w.name, metav1.DeleteOptions{})
+}
+
+// logWorkloadDelete logs the outcome of deleting a workload.
+func logWorkloadDelete(w *k8sWorkload, err error) {
+	if err != nil {
+		log.Error("Failed to delete %s %s/%s: %v", w.kind, w.namespace, w.name, err)
+		return
+	}
+	log.Info("Successfully deleted %s %s/%s", w.kind, w.namespace, w.name)
+}
@@ -0,0 +1,34 @@
+package shimlets
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// modelFileSuffixes are weight file extensions that may be passed instead of a model directory.
+var modelFileSuffixes = []string{".bin", ".safetensors", ".pt", ".gguf"}
+
+// resolveModelDir returns the model directory to mount for the given ModelFileDir.
+// If the path points to a weight file, its parent directory is used.
+func resolveModelDir(modelFileDir string) (string, error) {
+	// Validate model path is provided
+	if modelFileDir == "" {
+		return "", errors.New("model path cannot be empty; please provide a valid model name")
+	}
+
+	modelDirPath := modelFileDir
+	lowerPath := strings.ToLower(modelDirPath)
+	for _, suffix := range modelFileSuffixes {
+		if strings.HasSuffix(lowerPath, suffix) {
+			modelDirPath = filepath.Dir(modelDirPath)
+			break
+		}
+	}
+
+	// Final validation of resolved model directory path
+	if modelDirPath == "" || modelDirPath == "." || modelDirPath == "/" {
+		return "", errors.New("resolved model path is invalid")
+	}
+	return modelDirPath, nil
+}
@@ -0,0 +1,382 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/engine"
+	"astron-xmod-shim/internal/core/shimlet"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ensure ProcessShimlet implements the Shimlet interface at compile time
+var _ shimlet.Shimlet = (*ProcessShimlet)(nil)
+
+func init() {
+	shimlet.Registry.AutoRegister(&ProcessShimlet{})
+}
+
+const (
+	defaultProcessStateDir = "./data/process"
+	defaultProcessHost     = "127.0.0.1"
+	defaultFailedRestarts  = 3
+	defaultRestartBackoff  = time.Second
+	defaultMaxBackoff      = time.Minute
+	portProbeTimeout       = 500 * time.Millisecond
+)
+
+// processOfflineEnv is injected for configured commands; engines bring their own env.
+var processOfflineEnv = []dto.Env{
+	{Key: "TRANSFORMERS_OFFLINE", Value: "1"}, // Enforce offline mode to prevent Hugging Face downloads
+}
+
+// ProcessShimlet runs inference servers (vLLM, llama.cpp server, ...) as supervised child processes.
+// Each service gets a directory under the state dir holding its process record (pid, port, spec)
+// and its combined stdout/stderr log; crashed processes are restarted with exponential backoff.
+type ProcessShimlet struct {
+	conf           *cfg.ProcessConfig
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mu          sync.Mutex
+	supervisors map[string]*processSupervisor
+}
+
+// ID returns the unique identifier for this shimlet.
+func (p *ProcessShimlet) ID() string { return "process" }
+
+// Description returns a brief description of the shimlet.
+func (p *ProcessShimlet) Description() string { return "local process shimlet" }
+
+// InitWithConfig reads the process-specific config and resumes supervision of recorded services.
+func (p *ProcessShimlet) InitWithConfig(confPath string) error {
+	processCfg, err := config.GetConfFromFileDir[cfg.ProcessConfig](confPath)
+	if err != nil {
+		return err
+	}
+	return p.initWithProcessConfig(processCfg)
+}
+
+// initWithProcessConfig applies defaults, then restores services from the state dir:
+// live processes are adopted, dead ones are restarted.
+func (p *ProcessShimlet) initWithProcessConfig(processCfg *cfg.ProcessConfig) error {
+	if processCfg.StateDir == "" {
+		processCfg.StateDir = defaultProcessStateDir
+	}
+	if processCfg.Host == "" {
+		processCfg.Host = defaultProcessHost
+	}
+	if processCfg.PortRangeStart <= 0 || processCfg.PortRangeEnd < processCfg.PortRangeStart {
+		processCfg.PortRangeStart, processCfg.PortRangeEnd = defaultPortRangeStart, defaultPortRangeEnd
+	}
+	if processCfg.FailedRestarts <= 0 {
+		processCfg.FailedRestarts = defaultFailedRestarts
+	}
+	p.initialBackoff = time.Duration(processCfg.RestartBackoffMs) * time.Millisecond
+	if p.initialBackoff <= 0 {
+		p.initialBackoff = defaultRestartBackoff
+	}
+	p.maxBackoff = time.Duration(processCfg.MaxBackoffMs) * time.Millisecond
+	if p.maxBackoff < p.initialBackoff {
+		p.maxBackoff = defaultMaxBackoff
+	}
+	if err := os.MkdirAll(processCfg.StateDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir %s: %w", processCfg.StateDir, err)
+	}
+	p.conf = processCfg
+	p.supervisors = make(map[string]*processSupervisor)
+
+	entries, err := os.ReadDir(processCfg.StateDir)
+	if err != nil {
+		return fmt.Errorf("failed to read state dir %s: %w", processCfg.StateDir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(processCfg.StateDir, entry.Name())
+		record, err := readProcessRecord(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Warn("skip process state %s: %v", dir, err)
+			}
+			continue
+		}
+		if record.State == processStateStopped {
+			continue
+		}
+
+		adoptPID := 0
+		if processIsOurs(record.PID, record.expectedIdentity()) {
+			adoptPID = record.PID
+		}
+		supervisor := newProcessSupervisor(dir, *record, p.initialBackoff, p.maxBackoff)
+		p.supervisors[record.Spec.ServiceId] = supervisor
+		supervisor.start(adoptPID)
+		log.Info("process of service %s restored (adopted pid: %d)", record.Spec.ServiceId, adoptPID)
+	}
+	return nil
+}
+
+// Apply starts the service's process, replacing a running one so spec changes take effect.
+// The allocated port is kept across re-applies so the endpoint stays stable.
+func (p *ProcessShimlet) Apply(deploySpec *dto.RequirementSpec) error {
+	if p.conf == nil {
+		return errors.New("process shimlet is not initialized")
+	}
+	modelDirPath, err := resolveModelDir(deploySpec.ModelFileDir)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	existing, hasExisting := p.supervisors[deploySpec.ServiceId]
+	port := 0
+	if hasExisting {
+		port = existing.snapshot().Port
+	}
+	if port == 0 {
+		if port, err = p.allocatePortLocked(deploySpec.ServiceId); err != nil {
+			return err
+		}
+	}
+	// Render before stopping the old process so an invalid engine spec keeps it running
+	command, args, engineEnv, err := p.renderCommand(deploySpec, modelDirPath, port)
+	if err != nil {
+		return err
+	}
+	if hasExisting {
+		existing.stop()
+		delete(p.supervisors, deploySpec.ServiceId)
+	}
+
+	dir := filepath.Join(p.conf.StateDir, url.PathEscape(deploySpec.ServiceId))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir for service %s: %w", deploySpec.ServiceId, err)
+	}
+
+	record := processRecord{
+		Spec:    *deploySpec,
+		Command: command,
+		Args:    args,
+		Env:     p.buildEnv(deploySpec, port, engineEnv),
+		Port:    port,
+		State:   processStateStarting,
+	}
+	if err := writeProcessRecord(dir, &record); err != nil {
+		return fmt.Errorf("failed to persist process state: %w", err)
+	}
+
+	supervisor := newProcessSupervisor(dir, record, p.initialBackoff, p.maxBackoff)
+	p.supervisors[deploySpec.ServiceId] = supervisor
+	supervisor.start(0)
+
+	log.Info("process of service %s started with %s on port %d, logs: %s",
+		deploySpec.ServiceId, command, port, filepath.Join(dir, processLogFile))
+	return nil
+}
+
+// renderCommand returns the command line and env of the inference server. The configured
+// command is used unless it is empty or the spec names an engine explicitly.
+func (p *ProcessShimlet) renderCommand(deploySpec *dto.RequirementSpec, modelDirPath string, port int) (string, []string, []dto.Env, error) {
+	if parallelism, err := engine.ResolveParallelism(deploySpec); err != nil {
+		return "", nil, nil, err
+	} else if parallelism.MultiNode() {
+		return "", nil, nil, fmt.Errorf("%w: multi-node deployments need the k8s shimlet", engine.ErrInvalidParallelism)
+	}
+	if deploySpec.Engine == "" && p.conf.Command != "" {
+		return p.conf.Command, p.renderArgs(deploySpec, modelDirPath, port), processOfflineEnv, nil
+	}
+	rendered, err := engine.Registry.Render(deploySpec, engine.Target{
+		ModelDir: modelDirPath,
+		Host:     p.conf.Host,
+		Port:     port,
+		Local:    true,
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(rendered.Command) == 0 {
+		return "", nil, nil, fmt.Errorf("engine %s has no local command", rendered.Engine)
+	}
+	args := append(slices.Clone(rendered.Command[1:]), rendered.Args...)
+	return rendered.Command[0], args, rendered.Env, nil
+}
+
+// renderArgs substitutes placeholders in the configured args.
+func (p *ProcessShimlet) renderArgs(deploySpec *dto.RequirementSpec, modelDirPath string, port int) []string {
+	replacer := strings.NewReplacer(
+		"{{model_name}}", deploySpec.ModelName,
+		"{{model_path}}", deploySpec.ModelFileDir,
+		"{{model_dir}}", modelDirPath,
+		"{{port}}", strconv.Itoa(port),
+		"{{host}}", p.conf.Host,
+		"{{service_id}}", deploySpec.ServiceId,
+		"{{context_length}}", strconv.Itoa(deploySpec.ContextLength),
+	)
+	args := make([]string, 0, len(p.conf.Args))
+	for _, arg := range p.conf.Args {
+		args = append(args, replacer.Replace(arg))
+	}
+	return args
+}
+
+// buildEnv returns the env injected on top of the shim's own environment.
+func (p *ProcessShimlet) buildEnv(deploySpec *dto.RequirementSpec, port int, engineEnv []dto.Env) []string {
+	env := []string{
+		"MODEL=" + deploySpec.ModelName,
+		"PORT=" + strconv.Itoa(port),
+		"SERVICE_ID=" + deploySpec.ServiceId,
+	}
+	for _, e := range engineEnv {
+		env = append(env, e.Key+"="+e.Value)
+	}
+	env = append(env, p.conf.Env...)
+	for _, e := range deploySpec.Env {
+		env = append(env, e.Key+"="+e.Value)
+	}
+	return env
+}
+
+// allocatePortLocked picks a port derived from the serviceId, skipping ports held by other
+// services or already bound on the host.
+func (p *ProcessShimlet) allocatePortLocked(serviceID string) (int, error) {
+	used := make(map[int]struct{}, len(p.supervisors))
+	for _, supervisor := range p.supervisors {
+		used[supervisor.snapshot().Port] = struct{}{}
+	}
+
+	size := p.conf.PortRangeEnd - p.conf.PortRangeStart + 1
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(serviceID))
+	offset := int(h.Sum32() % uint32(size))
+	for i := 0; i < size; i++ {
+		port := p.conf.PortRangeStart + (offset+i)%size
+		if _, taken := used[port]; taken {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(p.conf.Host, strconv.Itoa(port)))
+		if err != nil {
+			continue
+		}
+		_ = listener.Close()
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port in range %d-%d", p.conf.PortRangeStart, p.conf.PortRangeEnd)
+}
+
+// Delete stops the service's process. The log file is kept for troubleshooting.
+func (p *ProcessShimlet) Delete(resourceId string) error {
+	if p.conf == nil {
+		return errors.New("process shimlet is not initialized")
+	}
+	p.mu.Lock()
+	supervisor, ok := p.supervisors[resourceId]
+	delete(p.supervisors, resourceId)
+	p.mu.Unlock()
+
+	if !ok {
+		log.Info("No process found for service %s", resourceId)
+		return nil
+	}
+	supervisor.stop()
+	if err := os.Remove(filepath.Join(supervisor.dir, processRecordFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove process state of service %s: %w", resourceId, err)
+	}
+	log.Info("Successfully stopped process of service %s", resourceId)
+	return nil
+}
+
+// Status maps the supervised process onto a DeployPhase:
+// running and accepting connections → running; starting, not yet listening or
+// restarting after a few crashes → pending; crash looping → failed.
+func (p *ProcessShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
+	if p.conf == nil {
+		return nil, errors.New("process shimlet is not initialized")
+	}
+	p.mu.Lock()
+	supervisor, ok := p.supervisors[resourceId]
+	p.mu.Unlock()
+	if !ok {
+		return &dto.RuntimeStatus{
+			DeploySpec: dto.RequirementSpec{ServiceId: resourceId},
+			Status:     dto.PhaseUnknown,
+		}, nil
+	}
+
+	record := supervisor.snapshot()
+	var phase dto.DeployPhase
+	var endpoint string
+	switch record.State {
+	case processStateRunning:
+		phase = dto.PhasePending
+		if p.portListening(record.Port) {
+			phase = dto.PhaseRunning
+			endpoint = fmt.Sprintf("http://%s:%d", p.conf.Host, record.Port)
+		}
+	case processStateStarting:
+		phase = dto.PhasePending
+	case processStateBackoff:
+		phase = dto.PhasePending
+		if record.Restarts >= p.conf.FailedRestarts {
+			phase = dto.PhaseFailed
+		}
+	case processStateStopped:
+		phase = dto.PhaseTerminated
+	default:
+		phase = dto.PhaseUnknown
+	}
+
+	spec := record.Spec
+	spec.ServiceId = resourceId
+	spec.ReplicaCount = 1
+	if spec.ShimletName == "" {
+		spec.ShimletName = p.ID()
+	}
+
+	return &dto.RuntimeStatus{
+		DeploySpec: spec,
+		Status:     phase,
+		EndPoint:   endpoint,
+	}, nil
+}
+
+// portListening reports whether the server accepts TCP connections on the port.
+func (p *ProcessShimlet) portListening(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(p.conf.Host, strconv.Itoa(port)), portProbeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// ListDeployedServices 获取所有受管进程对应的 serviceId
+func (p *ProcessShimlet) ListDeployedServices() ([]string, error) {
+	if p.conf == nil {
+		return []string{}, errors.New("process shimlet is not initialized")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	serviceIDs := make([]string, 0, len(p.supervisors))
+	for serviceID := range p.supervisors {
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Strings(serviceIDs)
+	return serviceIDs, nil
+}
@@ -0,0 +1,207 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/core/engine"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const processHelperEnv = "XMOD_PROCESS_HELPER"
+
+// TestProcessHelper is not a real test: the process shimlet tests re-exec the test binary
+// with processHelperEnv set to act as a fake inference server.
+func TestProcessHelper(t *testing.T) {
+	mode := os.Getenv(processHelperEnv)
+	if mode == "" {
+		return
+	}
+	if mode == "crash" {
+		os.Exit(3)
+	}
+	// args after "--": host port
+	var args []string
+	for i, arg := range os.Args {
+		if arg == "--" {
+			args = os.Args[i+1:]
+			break
+		}
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(args[0], args[1]))
+	if err != nil {
+		os.Exit(2)
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			os.Exit(0)
+		}
+		_ = conn.Close()
+	}
+}
+
+func newTestProcessShimlet(t *testing.T, stateDir, mode string) *ProcessShimlet {
+	p := &ProcessShimlet{}
+	require.NoError(t, p.initWithProcessConfig(&cfg.ProcessConfig{
+		StateDir:         stateDir,
+		Command:          os.Args[0],
+		Args:             []string{"-test.run=^TestProcessHelper$", "--", "{{host}}", "{{port}}"},
+		Env:              []string{processHelperEnv + "=" + mode},
+		PortRangeStart:   31000,
+		PortRangeEnd:     31999,
+		RestartBackoffMs: 10,
+		MaxBackoffMs:     50,
+		FailedRestarts:   2,
+	}))
+	return p
+}
+
+func waitForPhase(t *testing.T, p *ProcessShimlet, serviceID string, phase dto.DeployPhase) *dto.RuntimeStatus {
+	var status *dto.RuntimeStatus
+	require.Eventually(t, func() bool {
+		var err error
+		status, err = p.Status(serviceID)
+		return err == nil && status.Status == phase
+	}, 10*time.Second, 20*time.Millisecond, "service %s never reached phase %s", serviceID, phase)
+	return status
+}
+
+func TestProcessShimlet_ApplyStatusDelete(t *testing.T) {
+	stateDir := t.TempDir()
+	p := newTestProcessShimlet(t, stateDir, "serve")
+
+	spec := &dto.RequirementSpec{ServiceId: "svc-proc", ModelName: "qwen", ModelFileDir: "/models/qwen/model.safetensors"}
+	require.NoError(t, p.Apply(spec))
+
+	status := waitForPhase(t, p, "svc-proc", dto.PhaseRunning)
+	assert.Contains(t, status.EndPoint, "http://127.0.0.1:31")
+	assert.Equal(t, "qwen", status.DeploySpec.ModelName)
+
+	record, err := readProcessRecord(filepath.Join(stateDir, "svc-proc"))
+	require.NoError(t, err)
+	assert.Positive(t, record.PID)
+	assert.Contains(t, record.Env, "MODEL=qwen")
+
+	ids, err := p.ListDeployedServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc-proc"}, ids)
+
+	// 重新 Apply 保持端口不变
+	port := record.Port
+	require.NoError(t, p.Apply(spec))
+	waitForPhase(t, p, "svc-proc", dto.PhaseRunning)
+	record, err = readProcessRecord(filepath.Join(stateDir, "svc-proc"))
+	require.NoError(t, err)
+	assert.Equal(t, port, record.Port)
+
+	require.NoError(t, p.Delete("svc-proc"))
+	status, err = p.Status("svc-proc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseUnknown, status.Status)
+	assert.FileExists(t, filepath.Join(stateDir, "svc-proc", processLogFile))
+	assert.NoFileExists(t, filepath.Join(stateDir, "svc-proc", processRecordFile))
+}
+
+func TestProcessShimlet_CrashLoopFails(t *testing.T) {
+	p := newTestProcessShimlet(t, t.TempDir(), "crash")
+	require.NoError(t, p.Apply(&dto.RequirementSpec{ServiceId: "svc-crash", ModelName: "qwen", ModelFileDir: "/models/qwen"}))
+
+	waitForPhase(t, p, "svc-crash", dto.PhaseFailed)
+	record := p.supervisors["svc-crash"].snapshot()
+	assert.Equal(t, 3, record.LastExitCode)
+	require.NoError(t, p.Delete("svc-crash"))
+}
+
+func TestProcessShimlet_RestoresFromStateDir(t *testing.T) {
+	stateDir := t.TempDir()
+	p := newTestProcessShimlet(t, stateDir, "serve")
+	require.NoError(t, p.Apply(&dto.RequirementSpec{ServiceId: "svc-restore", ModelName: "qwen", ModelFileDir: "/models/qwen"}))
+	waitForPhase(t, p, "svc-restore", dto.PhaseRunning)
+	require.NoError(t, p.Delete("svc-restore"))
+
+	// 模拟 shim 重启前记录的服务：进程已不存在，重新初始化后应被拉起
+	dir := filepath.Join(stateDir, "svc-restore")
+	require.NoError(t, writeProcessRecord(dir, &processRecord{
+		Spec:    dto.RequirementSpec{ServiceId: "svc-restore", ModelName: "qwen"},
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestProcessHelper$", "--", "127.0.0.1", "31500"},
+		Env:     []string{processHelperEnv + "=serve"},
+		Port:    31500,
+		State:   processStateRunning,
+	}))
+
+	restored := newTestProcessShimlet(t, stateDir, "serve")
+	status := waitForPhase(t, restored, "svc-restore", dto.PhaseRunning)
+	assert.Equal(t, "http://127.0.0.1:31500", status.EndPoint)
+	require.NoError(t, restored.Delete("svc-restore"))
+}
+
+// 记录的 pid 已被无关进程（这里是测试进程自身）复用时，不能接管或向其发送信号
+func TestProcessShimlet_RestoreIgnoresReusedPID(t *testing.T) {
+	stateDir := t.TempDir()
+	dir := filepath.Join(stateDir, "svc-reused")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, writeProcessRecord(dir, &processRecord{
+		Spec:    dto.RequirementSpec{ServiceId: "svc-reused", ModelName: "qwen"},
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestProcessHelper$", "--", "127.0.0.1", "31501"},
+		Env:     []string{processHelperEnv + "=serve"},
+		Port:    31501,
+		PID:     os.Getpid(),
+		State:   processStateRunning,
+	}))
+
+	restored := newTestProcessShimlet(t, stateDir, "serve")
+	waitForPhase(t, restored, "svc-reused", dto.PhaseRunning)
+	record := restored.supervisors["svc-reused"].snapshot()
+	assert.NotEqual(t, os.Getpid(), record.PID, "the reused pid must not be adopted")
+	require.NoError(t, restored.Delete("svc-reused"))
+}
+
+func TestProcessIsOurs(t *testing.T) {
+	self, err := readProcessIdentity(os.Getpid())
+	if errors.Is(err, errProcessIdentityUnsupported) {
+		t.Skip("process identities are not available on this platform")
+	}
+	require.NoError(t, err)
+	assert.Positive(t, self.StartTicks)
+	assert.Equal(t, os.Args, self.Cmdline)
+
+	assert.True(t, processIsOurs(os.Getpid(), self))
+	assert.False(t, processIsOurs(os.Getpid(), processIdentity{StartTicks: self.StartTicks + 1, Cmdline: self.Cmdline}), "restarted process")
+	assert.False(t, processIsOurs(os.Getpid(), processIdentity{Cmdline: []string{"vllm", "serve"}}), "different command")
+}
+
+func TestProcessShimlet_RenderCommand(t *testing.T) {
+	p := &ProcessShimlet{conf: &cfg.ProcessConfig{Command: "my-server", Args: []string{"--port", "{{port}}"}, Host: "127.0.0.1"}}
+
+	// 未指定 engine 时使用配置的命令
+	command, args, env, err := p.renderCommand(&dto.RequirementSpec{ModelName: "qwen"}, "/models/qwen", 31000)
+	require.NoError(t, err)
+	assert.Equal(t, "my-server", command)
+	assert.Equal(t, []string{"--port", "31000"}, args)
+	assert.Equal(t, processOfflineEnv, env)
+
+	// 指定 engine 时由引擎生成本机命令
+	spec := &dto.RequirementSpec{ModelName: "qwen", ModelFileDir: "/models/qwen/qwen-q4.gguf", Engine: "llamacpp"}
+	command, args, _, err = p.renderCommand(spec, "/models/qwen", 31000)
+	require.NoError(t, err)
+	assert.Equal(t, "llama-server", command)
+	assert.Equal(t, []string{"--host", "127.0.0.1", "--port", "31000", "-m", "/models/qwen/qwen-q4.gguf", "--alias", "qwen"}, args)
+
+	_, _, _, err = p.renderCommand(&dto.RequirementSpec{ModelName: "qwen", Engine: "unknown"}, "/models/qwen", 31000)
+	assert.Error(t, err)
+
+	// 多节点部署需要 k8s shimlet
+	_, _, _, err = p.renderCommand(&dto.RequirementSpec{ModelName: "qwen", Parallelism: &dto.ParallelismSpec{Nodes: 2}}, "/models/qwen", 31000)
+	assert.ErrorIs(t, err, engine.ErrInvalidParallelism)
+}
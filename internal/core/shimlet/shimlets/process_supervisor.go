@@ -0,0 +1,333 @@
+package shimlets
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Process states recorded in the state directory
+const (
+	processStateStarting = "starting" // command is being launched
+	processStateRunning  = "running"  // process is alive
+	processStateBackoff  = "backoff"  // process exited unexpectedly, waiting to restart
+	processStateStopped  = "stopped"  // stopped by Delete
+)
+
+const (
+	processRecordFile = "process.json"
+	processLogFile    = "server.log"
+
+	// processStopTimeout is how long a stopping process gets before SIGKILL
+	processStopTimeout = 10 * time.Second
+	// processStableRuntime resets the restart backoff once a process has run this long
+	processStableRuntime = time.Minute
+	// adoptedPollInterval is how often an adopted (non-child) process is checked
+	adoptedPollInterval = 2 * time.Second
+)
+
+// processRecord is the persisted state of a supervised service.
+type processRecord struct {
+	Spec         dto.RequirementSpec `json:"spec"`
+	Command      string              `json:"command"`
+	Args         []string            `json:"args"`
+	Env          []string            `json:"env"`
+	Port         int                 `json:"port"`
+	PID          int                 `json:"pid"`
+	State        string              `json:"state"`
+	Restarts     int                 `json:"restarts"` // consecutive crashes, reset once the process is stable
+	LastExitCode int                 `json:"lastExitCode"`
+	LastError    string              `json:"lastError"`
+	StartedAt    time.Time           `json:"startedAt"`
+	LastExitAt   time.Time           `json:"lastExitAt"`
+	// Identity tells the recorded process apart from an unrelated one reusing its pid
+	Identity processIdentity `json:"identity"`
+}
+
+// processIdentity identifies a process beyond its pid, which the kernel reuses.
+type processIdentity struct {
+	StartTicks uint64   `json:"startTicks,omitempty"` // start time in clock ticks since boot
+	Cmdline    []string `json:"cmdline,omitempty"`
+}
+
+// errProcessIdentityUnsupported is returned where process identities cannot be read.
+var errProcessIdentityUnsupported = errors.New("process identity is not supported on this platform")
+
+// expectedIdentity returns the identity the recorded process must have. Records written
+// before identities were persisted are matched by their command line alone.
+func (r processRecord) expectedIdentity() processIdentity {
+	identity := r.Identity
+	if len(identity.Cmdline) == 0 {
+		identity.Cmdline = append([]string{r.Command}, r.Args...)
+	}
+	return identity
+}
+
+// processIsOurs reports whether pid is alive and still the process described by want.
+// A process whose identity does not match is treated as exited so it is never signaled.
+func processIsOurs(pid int, want processIdentity) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	got, err := readProcessIdentity(pid)
+	if errors.Is(err, errProcessIdentityUnsupported) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	if want.StartTicks != 0 && got.StartTicks != want.StartTicks {
+		return false
+	}
+	return slices.Equal(got.Cmdline, want.Cmdline)
+}
+
+// processSupervisor runs one service's command and restarts it with backoff when it crashes.
+type processSupervisor struct {
+	dir            string
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	mu     sync.Mutex
+	record processRecord
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func newProcessSupervisor(dir string, record processRecord, initialBackoff, maxBackoff time.Duration) *processSupervisor {
+	return &processSupervisor{
+		dir:            dir,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		record:         record,
+		stopCh:         make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}
+}
+
+// snapshot returns a copy of the current record.
+func (s *processSupervisor) snapshot() processRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record
+}
+
+// update mutates the record and persists it.
+func (s *processSupervisor) update(mutate func(r *processRecord)) {
+	s.mu.Lock()
+	mutate(&s.record)
+	record := s.record
+	s.mu.Unlock()
+
+	if err := writeProcessRecord(s.dir, &record); err != nil {
+		log.Warn("persist process state of service %s failed: %v", record.Spec.ServiceId, err)
+	}
+}
+
+// start launches the supervision loop. adoptPID > 0 means a process left by a previous
+// shim instance is still alive: it is watched until it exits before a new one is started.
+func (s *processSupervisor) start(adoptPID int) {
+	go s.run(adoptPID)
+}
+
+// stop terminates the process and waits for the supervision loop to exit.
+func (s *processSupervisor) stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	<-s.doneCh
+}
+
+func (s *processSupervisor) run(adoptPID int) {
+	defer close(s.doneCh)
+	serviceID := s.snapshot().Spec.ServiceId
+
+	if adoptPID > 0 {
+		if stopped := s.watchAdopted(adoptPID); stopped {
+			return
+		}
+	}
+
+	backoff := s.initialBackoff
+	for {
+		select {
+		case <-s.stopCh:
+			s.update(func(r *processRecord) { r.State = processStateStopped; r.PID = 0 })
+			return
+		default:
+		}
+
+		startedAt := time.Now()
+		exitCode, stopped, err := s.runOnce()
+		if stopped {
+			return
+		}
+
+		if time.Since(startedAt) >= processStableRuntime {
+			backoff = s.initialBackoff
+			s.update(func(r *processRecord) { r.Restarts = 0 })
+		}
+		s.update(func(r *processRecord) {
+			r.State = processStateBackoff
+			r.PID = 0
+			r.Restarts++
+			r.LastExitCode = exitCode
+			r.LastExitAt = time.Now()
+			r.LastError = ""
+			if err != nil {
+				r.LastError = err.Error()
+			}
+		})
+		log.Warn("process of service %s exited (code %d, err: %v), restarting in %s", serviceID, exitCode, err, backoff)
+
+		select {
+		case <-s.stopCh:
+			s.update(func(r *processRecord) { r.State = processStateStopped })
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// runOnce starts the command and waits for it to exit or for stop to be requested.
+func (s *processSupervisor) runOnce() (exitCode int, stopped bool, err error) {
+	record := s.snapshot()
+	s.update(func(r *processRecord) { r.State = processStateStarting })
+
+	logFile, err := os.OpenFile(filepath.Join(s.dir, processLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return -1, false, fmt.Errorf("open log file: %w", err)
+	}
+	defer logFile.Close()
+	_, _ = fmt.Fprintf(logFile, "===== %s starting: %s %v =====\n", time.Now().Format(time.RFC3339), record.Command, record.Args)
+
+	cmd := exec.Command(record.Command, record.Args...)
+	cmd.Env = append(os.Environ(), record.Env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Dir = s.dir
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return -1, false, fmt.Errorf("start command: %w", err)
+	}
+	pid := cmd.Process.Pid
+	identity, err := readProcessIdentity(pid)
+	if err != nil && !errors.Is(err, errProcessIdentityUnsupported) {
+		log.Warn("read identity of process %d of service %s failed: %v", pid, record.Spec.ServiceId, err)
+	}
+	s.update(func(r *processRecord) {
+		r.State = processStateRunning
+		r.PID = pid
+		r.StartedAt = time.Now()
+		r.Identity = identity
+	})
+
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- cmd.Wait() }()
+
+	select {
+	case <-s.stopCh:
+		s.terminate(pid, waitCh)
+		s.update(func(r *processRecord) { r.State = processStateStopped; r.PID = 0 })
+		return 0, true, nil
+	case waitErr := <-waitCh:
+		exitCode = cmd.ProcessState.ExitCode()
+		var exitErr *exec.ExitError
+		if waitErr != nil && !errors.As(waitErr, &exitErr) {
+			return exitCode, false, waitErr
+		}
+		return exitCode, false, nil
+	}
+}
+
+// terminate sends SIGTERM, then SIGKILL after processStopTimeout.
+func (s *processSupervisor) terminate(pid int, waitCh <-chan error) {
+	if err := terminateProcess(pid); err != nil {
+		log.Warn("terminate process %d failed: %v", pid, err)
+	}
+	select {
+	case <-waitCh:
+	case <-time.After(processStopTimeout):
+		_ = killProcess(pid)
+		<-waitCh
+	}
+}
+
+// watchAdopted polls a process that is not our child. Returns true if stop was requested.
+// The process is only signaled while its identity still matches the record, so a pid
+// reused by an unrelated process is left alone.
+func (s *processSupervisor) watchAdopted(pid int) bool {
+	identity := s.snapshot().expectedIdentity()
+	ticker := time.NewTicker(adoptedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			if processIsOurs(pid, identity) {
+				_ = terminateProcess(pid)
+			}
+			deadline := time.Now().Add(processStopTimeout)
+			for processIsOurs(pid, identity) && time.Now().Before(deadline) {
+				time.Sleep(100 * time.Millisecond)
+			}
+			if processIsOurs(pid, identity) {
+				_ = killProcess(pid)
+			}
+			s.update(func(r *processRecord) { r.State = processStateStopped; r.PID = 0 })
+			return true
+		case <-ticker.C:
+			if !processIsOurs(pid, identity) {
+				log.Warn("adopted process %d of service %s exited, restarting", pid, s.snapshot().Spec.ServiceId)
+				return false
+			}
+		}
+	}
+}
+
+// writeProcessRecord persists the record atomically (write to a temp file, then rename).
+func writeProcessRecord(dir string, record *processRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, processRecordFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, processRecordFile))
+}
+
+// readProcessRecord loads a persisted record.
+func readProcessRecord(dir string) (*processRecord, error) {
+	data, err := os.ReadFile(filepath.Join(dir, processRecordFile))
+	if err != nil {
+		return nil, err
+	}
+	record := &processRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
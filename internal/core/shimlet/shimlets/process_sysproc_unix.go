@@ -0,0 +1,91 @@
+//go:build !windows
+
+package shimlets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts the child in its own process group so the whole
+// process tree (e.g. vLLM workers) can be signaled together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess asks the process group to exit gracefully.
+func terminateProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// killProcess forcibly kills the process group.
+func killProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// readProcessIdentity reads the start time (field 22 of /proc/<pid>/stat, in clock ticks
+// since boot) and the command line of a process.
+func readProcessIdentity(pid int) (processIdentity, error) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		return processIdentity{}, errProcessIdentityUnsupported
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processIdentity{}, err
+	}
+	// The command name in field 2 may contain spaces and parentheses, so fields are
+	// counted from the last ')'
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return processIdentity{}, fmt.Errorf("malformed stat of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	const startTimeField = 22 - 3 // fields after ')' start at field 3
+	if len(fields) <= startTimeField {
+		return processIdentity{}, fmt.Errorf("malformed stat of process %d", pid)
+	}
+	startTicks, err := strconv.ParseUint(fields[startTimeField], 10, 64)
+	if err != nil {
+		return processIdentity{}, fmt.Errorf("malformed start time of process %d: %w", pid, err)
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return processIdentity{}, err
+	}
+	return processIdentity{
+		StartTicks: startTicks,
+		Cmdline:    strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00"),
+	}, nil
+}
+
+func signalGroup(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return nil
+	}
+	// Negative pid signals the whole group; fall back to the process itself
+	// if it was not started as a group leader (e.g. adopted from an older state).
+	if err := syscall.Kill(-pid, sig); err == nil || !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	err := syscall.Kill(pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
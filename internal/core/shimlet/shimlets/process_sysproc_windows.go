@@ -0,0 +1,42 @@
+//go:build windows
+
+package shimlets
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills the process; Windows has no SIGTERM equivalent for console-less children.
+func terminateProcess(pid int) error {
+	return killProcess(pid)
+}
+
+// killProcess forcibly kills the process.
+func killProcess(pid int) error {
+	if pid <= 0 {
+		return nil
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
+
+// readProcessIdentity is not supported on Windows; adoption falls back to processAlive.
+func readProcessIdentity(pid int) (processIdentity, error) {
+	return processIdentity{}, errProcessIdentityUnsupported
+}
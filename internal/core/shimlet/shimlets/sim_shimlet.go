@@ -0,0 +1,312 @@
+package shimlets
+
+import (
+	"astron-xmod-shim/internal/config"
+	"astron-xmod-shim/internal/core/shimlet"
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ensure SimShimlet implements the Shimlet interface at compile time
+var _ shimlet.Shimlet = (*SimShimlet)(nil)
+
+func init() {
+	shimlet.Registry.AutoRegister(&SimShimlet{})
+}
+
+// SimOp identifies a SimShimlet operation for failure injection.
+type SimOp string
+
+const (
+	SimOpApply  SimOp = "apply"
+	SimOpStatus SimOp = "status"
+	SimOpDelete SimOp = "delete"
+)
+
+// Drift modes applied to a running service once DriftAfterMs has elapsed or InjectDrift is called.
+const (
+	SimDriftFailed  = "failed"  // the service reports PhaseFailed
+	SimDriftMissing = "missing" // the service disappears (PhaseUnknown)
+	SimDriftSpec    = "spec"    // the running spec no longer matches (replica count drops to 0)
+)
+
+const defaultSimEndpointTemplate = "http://{{service_id}}.sim.local:8000"
+
+// ErrSimInjected is returned by operations failed on purpose.
+var ErrSimInjected = errors.New("sim shimlet: injected failure")
+
+// simService is the in-memory state of one simulated deployment.
+type simService struct {
+	spec       dto.RequirementSpec
+	appliedAt  time.Time
+	deletedAt  time.Time // non-zero while terminating
+	driftMode  string    // non-empty once the service drifted
+	applyCount int
+}
+
+// SimShimlet keeps deployments in memory and plays them through pending → running (→ drift),
+// with configurable timing and injected failures. It needs no cluster, so it backs
+// end-to-end tests of the orchestrator and reconciler and the shim's demo mode.
+type SimShimlet struct {
+	conf *cfg.SimConfig
+
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	services map[string]*simService
+	failNext map[SimOp]int
+	now      func() time.Time
+}
+
+// ID returns the unique identifier for this shimlet.
+func (s *SimShimlet) ID() string { return "sim" }
+
+// Description returns a brief description of the shimlet.
+func (s *SimShimlet) Description() string { return "simulated in-memory shimlet" }
+
+// InitWithConfig reads the sim-specific config. An empty path uses the defaults
+// (ready immediately, no failures, no drift).
+func (s *SimShimlet) InitWithConfig(confPath string) error {
+	simCfg := &cfg.SimConfig{}
+	if confPath != "" {
+		loaded, err := config.GetConfFromFileDir[cfg.SimConfig](confPath)
+		if err != nil {
+			return err
+		}
+		simCfg = loaded
+	}
+	return s.initWithSimConfig(simCfg)
+}
+
+// initWithSimConfig applies defaults and resets the in-memory state.
+func (s *SimShimlet) initWithSimConfig(simCfg *cfg.SimConfig) error {
+	switch simCfg.DriftMode {
+	case "":
+		simCfg.DriftMode = SimDriftFailed
+	case SimDriftFailed, SimDriftMissing, SimDriftSpec:
+	default:
+		return fmt.Errorf("unsupported drift mode: %s", simCfg.DriftMode)
+	}
+	if simCfg.EndpointTemplate == "" {
+		simCfg.EndpointTemplate = defaultSimEndpointTemplate
+	}
+	seed := simCfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conf = simCfg
+	s.rnd = rand.New(rand.NewSource(seed))
+	s.services = make(map[string]*simService)
+	s.failNext = make(map[SimOp]int)
+	if s.now == nil {
+		s.now = time.Now
+	}
+	log.Info("sim shimlet initialized, ready after %dms, drift after %dms (%s)",
+		simCfg.ReadyAfterMs, simCfg.DriftAfterMs, simCfg.DriftMode)
+	return nil
+}
+
+// FailNext makes the next n calls of op fail with ErrSimInjected, on top of the configured rates.
+func (s *SimShimlet) FailNext(op SimOp, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext[op] += n
+}
+
+// InjectDrift makes a deployed service drift immediately with the given mode.
+func (s *SimShimlet) InjectDrift(serviceID, mode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch mode {
+	case SimDriftFailed, SimDriftMissing, SimDriftSpec:
+	default:
+		return fmt.Errorf("unsupported drift mode: %s", mode)
+	}
+	svc, ok := s.services[serviceID]
+	if !ok {
+		return fmt.Errorf("service %s is not deployed", serviceID)
+	}
+	s.driftLocked(serviceID, svc, mode)
+	return nil
+}
+
+// ApplyCount returns how many times Apply succeeded for the service.
+func (s *SimShimlet) ApplyCount(serviceID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if svc, ok := s.services[serviceID]; ok {
+		return svc.applyCount
+	}
+	return 0
+}
+
+// injectLocked reports whether the call should fail, consuming FailNext budget first.
+func (s *SimShimlet) injectLocked(op SimOp, rate float64) error {
+	if s.failNext[op] > 0 {
+		s.failNext[op]--
+		return fmt.Errorf("%w: %s", ErrSimInjected, op)
+	}
+	if rate > 0 && s.rnd.Float64() < rate {
+		return fmt.Errorf("%w: %s", ErrSimInjected, op)
+	}
+	return nil
+}
+
+// Apply records the spec and restarts the pending → running timeline, clearing any drift.
+func (s *SimShimlet) Apply(deploySpec *dto.RequirementSpec) error {
+	if s.conf == nil {
+		return errors.New("sim shimlet is not initialized")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.injectLocked(SimOpApply, s.conf.ApplyFailureRate); err != nil {
+		return err
+	}
+
+	svc, ok := s.services[deploySpec.ServiceId]
+	if !ok {
+		svc = &simService{}
+		s.services[deploySpec.ServiceId] = svc
+	}
+	svc.spec = *deploySpec
+	svc.appliedAt = s.now()
+	svc.deletedAt = time.Time{}
+	svc.driftMode = ""
+	svc.applyCount++
+	log.Info("sim service %s applied (model: %s)", deploySpec.ServiceId, deploySpec.ModelName)
+	return nil
+}
+
+// Delete moves the service to terminating; it disappears after TerminateAfterMs.
+func (s *SimShimlet) Delete(resourceId string) error {
+	if s.conf == nil {
+		return errors.New("sim shimlet is not initialized")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.injectLocked(SimOpDelete, s.conf.DeleteFailureRate); err != nil {
+		return err
+	}
+
+	svc, ok := s.services[resourceId]
+	if !ok {
+		log.Info("No sim service found for service %s", resourceId)
+		return nil
+	}
+	if s.conf.TerminateAfterMs <= 0 {
+		delete(s.services, resourceId)
+	} else if svc.deletedAt.IsZero() {
+		svc.deletedAt = s.now()
+	}
+	log.Info("sim service %s deleted", resourceId)
+	return nil
+}
+
+// Status advances the simulated timeline and reports the current phase.
+func (s *SimShimlet) Status(resourceId string) (*dto.RuntimeStatus, error) {
+	if s.conf == nil {
+		return nil, errors.New("sim shimlet is not initialized")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.injectLocked(SimOpStatus, s.conf.StatusFailureRate); err != nil {
+		return nil, err
+	}
+
+	svc, ok := s.services[resourceId]
+	if ok && s.advanceLocked(resourceId, svc) {
+		ok = false
+	}
+	if !ok {
+		return &dto.RuntimeStatus{
+			DeploySpec: dto.RequirementSpec{ServiceId: resourceId},
+			Status:     dto.PhaseUnknown,
+		}, nil
+	}
+
+	spec := svc.spec
+	if spec.ShimletName == "" {
+		spec.ShimletName = s.ID()
+	}
+	status := &dto.RuntimeStatus{DeploySpec: spec}
+	switch {
+	case !svc.deletedAt.IsZero():
+		status.Status = dto.PhaseTerminating
+	case svc.driftMode == SimDriftFailed:
+		status.Status = dto.PhaseFailed
+	case s.now().Sub(svc.appliedAt) < s.readyAfter():
+		status.Status = dto.PhasePending
+	default:
+		status.Status = dto.PhaseRunning
+		status.EndPoint = strings.NewReplacer(
+			"{{service_id}}", resourceId,
+			"{{model_name}}", spec.ModelName,
+		).Replace(s.conf.EndpointTemplate)
+	}
+	if svc.driftMode == SimDriftSpec {
+		status.DeploySpec.ReplicaCount = 0
+	}
+	return status, nil
+}
+
+// advanceLocked applies time-based transitions (termination, drift).
+// Returns true if the service no longer exists.
+func (s *SimShimlet) advanceLocked(serviceID string, svc *simService) bool {
+	now := s.now()
+	if !svc.deletedAt.IsZero() {
+		if now.Sub(svc.deletedAt) >= time.Duration(s.conf.TerminateAfterMs)*time.Millisecond {
+			delete(s.services, serviceID)
+			return true
+		}
+		return false
+	}
+	if s.conf.DriftAfterMs > 0 && svc.driftMode == "" {
+		runningSince := svc.appliedAt.Add(s.readyAfter())
+		if now.Sub(runningSince) >= time.Duration(s.conf.DriftAfterMs)*time.Millisecond {
+			s.driftLocked(serviceID, svc, s.conf.DriftMode)
+			return svc.driftMode == SimDriftMissing
+		}
+	}
+	return false
+}
+
+// driftLocked marks the service as drifted; missing services are removed.
+func (s *SimShimlet) driftLocked(serviceID string, svc *simService, mode string) {
+	log.Info("sim service %s drifted: %s", serviceID, mode)
+	svc.driftMode = mode
+	if mode == SimDriftMissing {
+		delete(s.services, serviceID)
+	}
+}
+
+func (s *SimShimlet) readyAfter() time.Duration {
+	return time.Duration(s.conf.ReadyAfterMs) * time.Millisecond
+}
+
+// ListDeployedServices 获取所有模拟部署的服务列表
+func (s *SimShimlet) ListDeployedServices() ([]string, error) {
+	if s.conf == nil {
+		return []string{}, errors.New("sim shimlet is not initialized")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	serviceIDs := make([]string, 0, len(s.services))
+	for serviceID, svc := range s.services {
+		if s.advanceLocked(serviceID, svc) {
+			continue
+		}
+		serviceIDs = append(serviceIDs, serviceID)
+	}
+	sort.Strings(serviceIDs)
+	return serviceIDs, nil
+}
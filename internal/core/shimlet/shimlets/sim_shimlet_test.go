@@ -0,0 +1,98 @@
+package shimlets
+
+import (
+	"testing"
+	"time"
+
+	cfg "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSimShimlet 返回使用可控时钟的 SimShimlet
+func newTestSimShimlet(t *testing.T, simCfg *cfg.SimConfig) (*SimShimlet, *time.Time) {
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &SimShimlet{now: func() time.Time { return clock }}
+	require.NoError(t, s.initWithSimConfig(simCfg))
+	return s, &clock
+}
+
+func simPhase(t *testing.T, s *SimShimlet, serviceID string) dto.DeployPhase {
+	status, err := s.Status(serviceID)
+	require.NoError(t, err)
+	return status.Status
+}
+
+func TestSimShimlet_Lifecycle(t *testing.T) {
+	s, clock := newTestSimShimlet(t, &cfg.SimConfig{ReadyAfterMs: 1000, TerminateAfterMs: 500})
+	assert.Equal(t, dto.PhaseUnknown, simPhase(t, s, "svc"))
+
+	require.NoError(t, s.Apply(&dto.RequirementSpec{ServiceId: "svc", ModelName: "qwen", ReplicaCount: 1}))
+	assert.Equal(t, dto.PhasePending, simPhase(t, s, "svc"))
+
+	*clock = clock.Add(time.Second)
+	status, err := s.Status("svc")
+	require.NoError(t, err)
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	assert.Equal(t, "http://svc.sim.local:8000", status.EndPoint)
+	assert.Equal(t, "qwen", status.DeploySpec.ModelName)
+	assert.Equal(t, "sim", status.DeploySpec.ShimletName)
+
+	ids, err := s.ListDeployedServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, ids)
+
+	require.NoError(t, s.Delete("svc"))
+	assert.Equal(t, dto.PhaseTerminating, simPhase(t, s, "svc"))
+	*clock = clock.Add(500 * time.Millisecond)
+	assert.Equal(t, dto.PhaseUnknown, simPhase(t, s, "svc"))
+	ids, _ = s.ListDeployedServices()
+	assert.Empty(t, ids)
+}
+
+func TestSimShimlet_FailureInjection(t *testing.T) {
+	s, _ := newTestSimShimlet(t, &cfg.SimConfig{})
+	s.FailNext(SimOpApply, 2)
+	spec := &dto.RequirementSpec{ServiceId: "svc", ModelName: "qwen"}
+	assert.ErrorIs(t, s.Apply(spec), ErrSimInjected)
+	assert.ErrorIs(t, s.Apply(spec), ErrSimInjected)
+	require.NoError(t, s.Apply(spec))
+	assert.Equal(t, 1, s.ApplyCount("svc"))
+
+	s.FailNext(SimOpStatus, 1)
+	_, err := s.Status("svc")
+	assert.ErrorIs(t, err, ErrSimInjected)
+
+	s.FailNext(SimOpDelete, 1)
+	assert.ErrorIs(t, s.Delete("svc"), ErrSimInjected)
+
+	// 失败率为 1 时每次调用都失败
+	always, _ := newTestSimShimlet(t, &cfg.SimConfig{ApplyFailureRate: 1, Seed: 42})
+	assert.ErrorIs(t, always.Apply(spec), ErrSimInjected)
+}
+
+func TestSimShimlet_Drift(t *testing.T) {
+	s, clock := newTestSimShimlet(t, &cfg.SimConfig{DriftAfterMs: 1000, DriftMode: SimDriftSpec})
+	require.NoError(t, s.Apply(&dto.RequirementSpec{ServiceId: "svc", ReplicaCount: 1}))
+
+	status, _ := s.Status("svc")
+	assert.Equal(t, 1, status.DeploySpec.ReplicaCount)
+	*clock = clock.Add(time.Second)
+	status, _ = s.Status("svc")
+	assert.Equal(t, dto.PhaseRunning, status.Status)
+	assert.Equal(t, 0, status.DeploySpec.ReplicaCount)
+
+	// 重新 Apply 清除漂移
+	require.NoError(t, s.Apply(&dto.RequirementSpec{ServiceId: "svc", ReplicaCount: 1}))
+	status, _ = s.Status("svc")
+	assert.Equal(t, 1, status.DeploySpec.ReplicaCount)
+
+	require.NoError(t, s.InjectDrift("svc", SimDriftFailed))
+	assert.Equal(t, dto.PhaseFailed, simPhase(t, s, "svc"))
+	require.NoError(t, s.InjectDrift("svc", SimDriftMissing))
+	assert.Equal(t, dto.PhaseUnknown, simPhase(t, s, "svc"))
+	assert.Error(t, s.InjectDrift("svc", SimDriftFailed))
+	assert.Error(t, s.initWithSimConfig(&cfg.SimConfig{DriftMode: "exploded"}))
+}
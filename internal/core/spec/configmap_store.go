@@ -0,0 +1,210 @@
+package spec
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/k8s"
+	"astron-xmod-shim/pkg/log"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	specConfigMapPrefix   = "xmod-spec-"
+	specConfigMapDataKey  = "spec.json"
+	specResourceLabel     = "resource"
+	specResourceValue     = "model-spec"
+	specServiceIDLabelKey = "astron-xmod-shim/service-id"
+)
+
+// writtenSpec 本副本写入成功、但 informer 缓存可能尚未同步的版本
+type writtenSpec struct {
+	resourceVersion string
+	spec            *dto.RequirementSpec
+}
+
+// ConfigMapStore 是 Store 的 Kubernetes ConfigMap 实现
+// 每个服务的部署期望保存在一个 ConfigMap 中，所有副本共享同一份期望状态。
+// 读操作走 informer 缓存；写操作基于 resourceVersion 乐观并发更新。
+// 由于 informer 同步存在延迟，本副本刚写入/删除的版本会暂存在本地，
+// 直到缓存追上为止，保证同一副本内“写后读”一致。
+type ConfigMapStore struct {
+	client    *k8s.K8sClient
+	namespace string
+
+	mu         sync.Mutex
+	written    map[string]writtenSpec
+	tombstones map[string]string // serviceId -> 删除前最后一个 resourceVersion
+}
+
+// NewConfigMapStore 创建基于 ConfigMap 的 Store
+func NewConfigMapStore(client *k8s.K8sClient, namespace string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:     client,
+		namespace:  namespace,
+		written:    make(map[string]writtenSpec),
+		tombstones: make(map[string]string),
+	}
+}
+
+func specConfigMapName(serviceID string) string {
+	return specConfigMapPrefix + serviceID
+}
+
+// Set 写入部署期望：以 spec.ResourceVersion 为前置条件更新（为空时只创建），
+// 期间被其他副本修改或删除时返回 ErrConflict，不覆盖对方的写入
+func (s *ConfigMapStore) Set(serviceID string, spec *dto.RequirementSpec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshal spec %s failed: %w", serviceID, err)
+	}
+
+	cm, err := s.client.UpsertConfigMap(s.namespace, specConfigMapName(serviceID), spec.ResourceVersion,
+		map[string]string{
+			specResourceLabel:     specResourceValue,
+			specServiceIDLabelKey: serviceID,
+		},
+		map[string]string{specConfigMapDataKey: string(data)},
+	)
+	if k8s_errors.IsConflict(err) {
+		return fmt.Errorf("%w: service %s: %v", ErrConflict, serviceID, err)
+	}
+	if err != nil {
+		return fmt.Errorf("persist spec %s to configmap failed: %w", serviceID, err)
+	}
+
+	// 暂存写入的版本，不修改调用方持有的部署期望
+	stored := *spec
+	stored.ResourceVersion = cm.ResourceVersion
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tombstones, serviceID)
+	s.written[serviceID] = writtenSpec{resourceVersion: cm.ResourceVersion, spec: &stored}
+	return nil
+}
+
+// Delete 删除服务的部署期望
+func (s *ConfigMapStore) Delete(serviceID string) {
+	name := specConfigMapName(serviceID)
+	lastVersion := ""
+	if cm, err := s.client.GetConfigMapFromCache(s.namespace, name); err == nil {
+		lastVersion = cm.ResourceVersion
+	}
+
+	if err := s.client.DeleteConfigMap(s.namespace, name); err != nil && !k8s_errors.IsNotFound(err) {
+		log.Error("delete spec configmap %s failed: %v", name, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.written, serviceID)
+	s.tombstones[serviceID] = lastVersion
+}
+
+// Get 从 informer 缓存读取部署期望
+func (s *ConfigMapStore) Get(serviceID string) *dto.RequirementSpec {
+	cm, err := s.client.GetConfigMapFromCache(s.namespace, specConfigMapName(serviceID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		// 缓存中不存在：可能尚未同步本副本的写入，也可能已被删除
+		delete(s.tombstones, serviceID)
+		if w, ok := s.written[serviceID]; ok {
+			return w.spec
+		}
+		return nil
+	}
+
+	if spec, ok := s.resolveLocked(serviceID, cm.ResourceVersion); ok {
+		return spec
+	}
+	return decodeSpec(cm)
+}
+
+// List 返回全部部署期望
+func (s *ConfigMapStore) List() []*dto.RequirementSpec {
+	selector := labels.Set{specResourceLabel: specResourceValue}.AsSelector().String()
+	cms, err := s.client.ListConfigMapsFromCache(s.namespace, selector)
+	if err != nil {
+		log.Error("list spec configmaps failed: %v", err)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(cms))
+	specs := make([]*dto.RequirementSpec, 0, len(cms))
+	for _, cm := range cms {
+		serviceID := cm.Labels[specServiceIDLabelKey]
+		seen[serviceID] = struct{}{}
+		if spec, ok := s.resolveLocked(serviceID, cm.ResourceVersion); ok {
+			if spec != nil {
+				specs = append(specs, spec)
+			}
+			continue
+		}
+		if spec := decodeSpec(cm); spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+	// 本副本已写入但缓存中还看不到的部署期望
+	for serviceID, w := range s.written {
+		if _, ok := seen[serviceID]; !ok {
+			specs = append(specs, w.spec)
+		}
+	}
+	return specs
+}
+
+// resolveLocked 根据缓存中的 resourceVersion 判断是否应使用本地暂存的版本
+// 返回 ok=true 表示结果已由本地状态决定（spec 为 nil 表示已删除）
+func (s *ConfigMapStore) resolveLocked(serviceID, cachedVersion string) (*dto.RequirementSpec, bool) {
+	if lastVersion, ok := s.tombstones[serviceID]; ok {
+		if lastVersion == "" || !isNewerVersion(cachedVersion, lastVersion) {
+			// 缓存中仍是删除前的版本
+			return nil, true
+		}
+		// 其他副本在删除后重新创建
+		delete(s.tombstones, serviceID)
+	}
+
+	if w, ok := s.written[serviceID]; ok {
+		if cachedVersion != w.resourceVersion && !isNewerVersion(cachedVersion, w.resourceVersion) {
+			return w.spec, true
+		}
+		// 缓存已追上（或被其他副本更新），本地暂存版本失效
+		delete(s.written, serviceID)
+	}
+	return nil, false
+}
+
+// isNewerVersion 比较两个 resourceVersion
+// resourceVersion 按约定是不透明字符串，但在 etcd 实现下为单调递增整数；无法解析时以缓存为准
+func isNewerVersion(a, b string) bool {
+	av, errA := strconv.ParseUint(a, 10, 64)
+	bv, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return true
+	}
+	return av > bv
+}
+
+// decodeSpec 解析 ConfigMap 中的部署期望，并记录其 resourceVersion 供写入时做乐观并发控制
+func decodeSpec(cm *corev1.ConfigMap) *dto.RequirementSpec {
+	spec := &dto.RequirementSpec{}
+	if err := json.Unmarshal([]byte(cm.Data[specConfigMapDataKey]), spec); err != nil {
+		log.Error("decode spec configmap %s failed: %v", cm.Name, err)
+		return nil
+	}
+	spec.ResourceVersion = cm.ResourceVersion
+	return spec
+}
@@ -0,0 +1,174 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/k8s"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testSpecNamespace = "xmod"
+
+// fakeSpecCluster 基于 fake clientset 的 ConfigMap 存储：reactor 模拟 API Server 的
+// resourceVersion 递增与乐观并发检查；informer 只收到手动推送的事件，用于模拟缓存同步延迟
+type fakeSpecCluster struct {
+	clientset *fake.Clientset
+	watcher   *watch.FakeWatcher
+	store     *ConfigMapStore
+	version   atomic.Uint64
+}
+
+func newFakeSpecCluster(t *testing.T) *fakeSpecCluster {
+	c := &fakeSpecCluster{clientset: fake.NewClientset(), watcher: watch.NewFakeWithChanSize(100, false)}
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+	c.clientset.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.CreateAction).GetObject().(*corev1.ConfigMap)
+		cm.ResourceVersion = c.nextVersion()
+		return false, nil, nil
+	})
+	c.clientset.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.UpdateAction).GetObject().(*corev1.ConfigMap)
+		current, err := c.clientset.Tracker().Get(gvr, cm.Namespace, cm.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		if current.(*corev1.ConfigMap).ResourceVersion != cm.ResourceVersion {
+			return true, nil, k8s_errors.NewConflict(gvr.GroupResource(), cm.Name, fmt.Errorf("stale version %s", cm.ResourceVersion))
+		}
+		cm.ResourceVersion = c.nextVersion()
+		return false, nil, nil
+	})
+	c.clientset.PrependWatchReactor("configmaps", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, c.watcher, nil
+	})
+
+	client, err := k8s.NewK8sClientForClientset(c.clientset)
+	require.NoError(t, err)
+	t.Cleanup(client.Stop)
+	c.store = NewConfigMapStore(client, testSpecNamespace)
+	return c
+}
+
+func (c *fakeSpecCluster) nextVersion() string {
+	return strconv.FormatUint(c.version.Add(1), 10)
+}
+
+// apiConfigMap 直接从 API Server 读取服务的 ConfigMap
+func (c *fakeSpecCluster) apiConfigMap(t *testing.T, serviceID string) *corev1.ConfigMap {
+	cm, err := c.clientset.CoreV1().ConfigMaps(testSpecNamespace).Get(t.Context(), specConfigMapName(serviceID), metav1.GetOptions{})
+	require.NoError(t, err)
+	return cm
+}
+
+// syncCache 将 ConfigMap 的变化推送给 informer，并等待缓存追上
+func (c *fakeSpecCluster) syncCache(t *testing.T, eventType watch.EventType, cm *corev1.ConfigMap) {
+	c.watcher.Action(eventType, cm)
+	require.Eventually(t, func() bool {
+		cached, err := c.store.client.GetConfigMapFromCache(testSpecNamespace, cm.Name)
+		if eventType == watch.Deleted {
+			return err != nil
+		}
+		return err == nil && cached.ResourceVersion == cm.ResourceVersion
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// 测试缓存同步前后本副本的写入都能被读到，缓存追上后以缓存为准
+func TestConfigMapStore_ReadYourWrites(t *testing.T) {
+	c := newFakeSpecCluster(t)
+	require.NoError(t, c.store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen"}))
+
+	// 缓存尚未同步：读到本地暂存的版本
+	got := c.store.Get("svc-1")
+	require.NotNil(t, got)
+	assert.Equal(t, "qwen", got.ModelName)
+	assert.Equal(t, "1", got.ResourceVersion)
+	require.Len(t, c.store.List(), 1)
+
+	// 缓存追上后本地暂存失效
+	c.syncCache(t, watch.Added, c.apiConfigMap(t, "svc-1"))
+	got = c.store.Get("svc-1")
+	require.NotNil(t, got)
+	assert.Equal(t, "qwen", got.ModelName)
+	assert.Equal(t, "1", got.ResourceVersion)
+	c.store.mu.Lock()
+	assert.Empty(t, c.store.written)
+	c.store.mu.Unlock()
+
+	// 基于读到的版本更新
+	got.ModelName = "llama"
+	require.NoError(t, c.store.Set("svc-1", got))
+	assert.Equal(t, "llama", c.store.Get("svc-1").ModelName)
+	assert.Equal(t, "2", c.store.Get("svc-1").ResourceVersion)
+}
+
+// 测试其他副本修改后，基于旧版本的写入返回冲突而不是覆盖对方
+func TestConfigMapStore_Conflict(t *testing.T) {
+	c := newFakeSpecCluster(t)
+	require.NoError(t, c.store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen"}))
+	c.syncCache(t, watch.Added, c.apiConfigMap(t, "svc-1"))
+	stale := c.store.Get("svc-1")
+
+	// 其他副本更新
+	other := c.apiConfigMap(t, "svc-1")
+	other.Data[specConfigMapDataKey] = `{"serviceId":"svc-1","modelName":"deepseek"}`
+	_, err := c.clientset.CoreV1().ConfigMaps(testSpecNamespace).Update(t.Context(), other, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	stale.ModelName = "llama"
+	err = c.store.Set("svc-1", stale)
+	require.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, c.apiConfigMap(t, "svc-1").Data[specConfigMapDataKey], "deepseek")
+
+	// 新建时服务已存在同样冲突
+	err = c.store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "llama"})
+	require.ErrorIs(t, err, ErrConflict)
+
+	// 缓存同步后重新读取再写入成功
+	c.syncCache(t, watch.Modified, c.apiConfigMap(t, "svc-1"))
+	fresh := c.store.Get("svc-1")
+	require.Equal(t, "deepseek", fresh.ModelName)
+	fresh.ModelName = "llama"
+	require.NoError(t, c.store.Set("svc-1", fresh))
+	assert.Equal(t, "llama", c.store.Get("svc-1").ModelName)
+}
+
+// 测试删除后缓存同步前不再读到旧版本，缓存同步后清除墓碑
+func TestConfigMapStore_DeleteTombstone(t *testing.T) {
+	c := newFakeSpecCluster(t)
+	require.NoError(t, c.store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen"}))
+	require.NoError(t, c.store.Set("svc-2", &dto.RequirementSpec{ServiceId: "svc-2", ModelName: "llama"}))
+	deleted := c.apiConfigMap(t, "svc-1")
+	c.syncCache(t, watch.Added, deleted)
+	c.syncCache(t, watch.Added, c.apiConfigMap(t, "svc-2"))
+
+	c.store.Delete("svc-1")
+	// 缓存中仍是删除前的版本
+	assert.Nil(t, c.store.Get("svc-1"))
+	specs := c.store.List()
+	require.Len(t, specs, 1)
+	assert.Equal(t, "svc-2", specs[0].ServiceId)
+
+	c.syncCache(t, watch.Deleted, deleted)
+	assert.Nil(t, c.store.Get("svc-1"))
+	c.store.mu.Lock()
+	assert.Empty(t, c.store.tombstones)
+	c.store.mu.Unlock()
+
+	// 删除后重新创建
+	require.NoError(t, c.store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen"}))
+	assert.Equal(t, "qwen", c.store.Get("svc-1").ModelName)
+}
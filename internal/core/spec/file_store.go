@@ -0,0 +1,193 @@
+package spec
+
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	specFileSuffix    = ".json"
+	tmpFileSuffix     = ".tmp"
+	corruptFileSuffix = ".corrupt"
+)
+
+// FileStore 是 Store 的本地文件实现
+// 每个服务的部署期望保存为数据目录下的一个 JSON 文件，写入时先写临时文件并 fsync，
+// 再通过 rename 原子替换，保证任意时刻崩溃都不会留下半写的部署期望。
+// 读操作由内存缓存提供，启动时从磁盘全量加载。
+type FileStore struct {
+	mu      sync.RWMutex
+	dir     string
+	specMap map[string]*dto.RequirementSpec
+}
+
+// NewFileStore 打开（或创建）数据目录，并完成崩溃恢复与全量加载
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spec store dir %s failed: %w", dir, err)
+	}
+	s := &FileStore{
+		dir:     dir,
+		specMap: make(map[string]*dto.RequirementSpec),
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover 清理崩溃遗留的临时文件，并加载全部部署期望
+// 无法解析的文件会被重命名为 *.corrupt 保留现场，不影响其他服务的恢复
+func (s *FileStore) recover() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("read spec store dir %s failed: %w", s.dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(s.dir, name)
+
+		// 写入过程中崩溃遗留的临时文件，对应的 rename 未完成，直接丢弃
+		if strings.HasSuffix(name, tmpFileSuffix) {
+			if err := os.Remove(path); err != nil {
+				log.Warn("remove stale spec tmp file %s failed: %v", path, err)
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, specFileSuffix) {
+			continue
+		}
+
+		spec, err := readSpecFile(path)
+		if err != nil {
+			log.Error("load spec file %s failed, mark as corrupt: %v", path, err)
+			if err := os.Rename(path, path+corruptFileSuffix); err != nil {
+				log.Warn("rename corrupt spec file %s failed: %v", path, err)
+			}
+			continue
+		}
+
+		serviceID, err := url.PathUnescape(strings.TrimSuffix(name, specFileSuffix))
+		if err != nil || serviceID != spec.ServiceId {
+			log.Warn("spec file %s does not match serviceId %s, skip", path, spec.ServiceId)
+			continue
+		}
+		s.specMap[serviceID] = spec
+	}
+
+	log.Info("spec store loaded %d specs from %s", len(s.specMap), s.dir)
+	return nil
+}
+
+func readSpecFile(path string) (*dto.RequirementSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &dto.RequirementSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Set 持久化部署期望，落盘失败时保留内存中的新值并记录错误
+func (s *FileStore) Set(serviceID string, spec *dto.RequirementSpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writeSpecFile(serviceID, spec); err != nil {
+		log.Error("persist spec %s failed: %v", serviceID, err)
+	}
+	s.specMap[serviceID] = spec
+	return nil
+}
+
+func (s *FileStore) Get(serviceID string) *dto.RequirementSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.specMap[serviceID]
+}
+
+// Delete 删除服务的部署期望
+func (s *FileStore) Delete(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.specPath(serviceID)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Error("remove spec file %s failed: %v", path, err)
+	} else if err := syncDir(s.dir); err != nil {
+		log.Warn("sync spec store dir %s failed: %v", s.dir, err)
+	}
+	delete(s.specMap, serviceID)
+}
+
+// List 返回全部部署期望
+func (s *FileStore) List() []*dto.RequirementSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	specs := make([]*dto.RequirementSpec, 0, len(s.specMap))
+	for _, spec := range s.specMap {
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// specPath 对 serviceId 做转义，避免路径穿越
+func (s *FileStore) specPath(serviceID string) string {
+	return filepath.Join(s.dir, url.PathEscape(serviceID)+specFileSuffix)
+}
+
+// writeSpecFile 原子写入：临时文件 -> fsync -> rename -> fsync 目录
+func (s *FileStore) writeSpecFile(serviceID string, spec *dto.RequirementSpec) error {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal spec failed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, url.PathEscape(serviceID)+".*"+tmpFileSuffix)
+	if err != nil {
+		return fmt.Errorf("create tmp file failed: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// rename 成功后临时文件已不存在，Remove 返回的错误可忽略
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write tmp file failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync tmp file failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close tmp file failed: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.specPath(serviceID)); err != nil {
+		return fmt.Errorf("rename tmp file failed: %w", err)
+	}
+	return syncDir(s.dir)
+}
+
+// syncDir fsync 目录，确保 rename/remove 本身已落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,61 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// 测试写入后重新打开仍能读到部署期望
+func TestFileStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1", ModelName: "qwen", GoalSetName: "opensource-llm-deploy"})
+	store.Set("svc-2", &dto.RequirementSpec{ServiceId: "svc-2", ModelName: "llama"})
+	store.Delete("svc-2")
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	got := reopened.Get("svc-1")
+	require.NotNil(t, got)
+	assert.Equal(t, "qwen", got.ModelName)
+	assert.Equal(t, "opensource-llm-deploy", got.GoalSetName)
+	assert.Nil(t, reopened.Get("svc-2"))
+	assert.Len(t, reopened.List(), 1)
+}
+
+// 测试崩溃恢复：清理临时文件，隔离损坏文件
+func TestFileStore_Recover(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	store.Set("svc-1", &dto.RequirementSpec{ServiceId: "svc-1"})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "svc-2.123.tmp"), []byte(`{"serviceId":`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "svc-3.json"), []byte(`{broken`), 0o644))
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	assert.Len(t, reopened.List(), 1)
+	assert.NotNil(t, reopened.Get("svc-1"))
+
+	_, err = os.Stat(filepath.Join(dir, "svc-2.123.tmp"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "svc-3.json.corrupt"))
+	assert.NoError(t, err)
+}
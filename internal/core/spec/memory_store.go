@@ -2,10 +2,12 @@ package spec
 
 import (
 	dto "astron-xmod-shim/internal/dto/deploy"
+	"sync"
 )
 
 // MemoryStore 是 Store 的简单内存实现
 type MemoryStore struct {
+	mu      sync.RWMutex
 	specMap map[string]*dto.RequirementSpec
 }
 
@@ -17,19 +19,37 @@ func NewMemoryStore() *MemoryStore {
 }
 
 // Set 保存用户部署期望 以及 runtime shimlet 和 部署 goal set (目标集合)
-func (m *MemoryStore) Set(serviceID string, spec *dto.RequirementSpec) {
+func (m *MemoryStore) Set(serviceID string, spec *dto.RequirementSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.specMap[serviceID] = spec
+	return nil
 }
 
 func (m *MemoryStore) Get(serviceID string) *dto.RequirementSpec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.specMap[serviceID]
 }
 
 // Delete 删除服务的状态记录
 func (m *MemoryStore) Delete(serviceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.specMap, serviceID)
 }
 
+// List 返回全部部署期望
+func (m *MemoryStore) List() []*dto.RequirementSpec {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	specs := make([]*dto.RequirementSpec, 0, len(m.specMap))
+	for _, spec := range m.specMap {
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
 func (m *MemoryStore) GetStatus(id string) {
 
 	// TODO 判断goal set 所有 goals is achieved
@@ -1,12 +1,68 @@
 package spec
 
 import (
+	confSpec "astron-xmod-shim/internal/dto/config"
 	dto "astron-xmod-shim/internal/dto/deploy"
+	"astron-xmod-shim/pkg/k8s"
+	"errors"
+	"fmt"
 )
 
-// Store 是 StateManager 的简单内存实现
+// ErrConflict 写入的部署期望基于的版本已被其他写入方修改或删除，需重新读取后再写入
+var ErrConflict = errors.New("spec was modified concurrently")
+
+const (
+	// StoreTypeMemory 内存存储，进程重启后部署期望丢失
+	StoreTypeMemory = "memory"
+	// StoreTypeFile 本地文件存储，进程重启后可恢复部署期望
+	StoreTypeFile = "file"
+	// StoreTypeConfigMap Kubernetes ConfigMap 存储，多副本共享部署期望
+	StoreTypeConfigMap = "configmap"
+
+	defaultFileStoreDir       = "./data/specs"
+	defaultConfigMapNamespace = "default"
+)
+
+// Store 部署期望存储接口
 type Store interface {
-	Set(serviceID string, spec *dto.RequirementSpec)
+	// Set 写入部署期望。多副本共享的实现以 spec.ResourceVersion（Get 读取时填充，新建时为空）
+	// 做乐观并发控制，期间被其他写入方修改时返回 ErrConflict
+	Set(serviceID string, spec *dto.RequirementSpec) error
 	Delete(serviceID string)
 	Get(serviceID string) *dto.RequirementSpec
+	// List 返回当前保存的全部部署期望
+	List() []*dto.RequirementSpec
+}
+
+// NewStore 根据配置创建对应类型的 Store
+// configmap 类型使用全局 k8s 配置连接集群
+func NewStore(globalCfg *confSpec.GlobalConfig) (Store, error) {
+	cfg := &globalCfg.SpecStore
+	storeType := StoreTypeMemory
+	if cfg.Type != "" {
+		storeType = cfg.Type
+	}
+
+	switch storeType {
+	case StoreTypeMemory:
+		return NewMemoryStore(), nil
+	case StoreTypeFile:
+		dir := cfg.Dir
+		if dir == "" {
+			dir = defaultFileStoreDir
+		}
+		return NewFileStore(dir)
+	case StoreTypeConfigMap:
+		namespace := cfg.Namespace
+		if namespace == "" {
+			namespace = defaultConfigMapNamespace
+		}
+		client, err := k8s.NewK8sClient(&globalCfg.K8s)
+		if err != nil {
+			return nil, fmt.Errorf("init k8s client for spec store failed: %w", err)
+		}
+		return NewConfigMapStore(client, namespace), nil
+	default:
+		return nil, fmt.Errorf("unsupported spec store type: %s", storeType)
+	}
 }
@@ -5,7 +5,15 @@ import (
 	"astron-xmod-shim/pkg/log"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
+)
+
+// 实例初始化失败后的退避时间：期间直接返回上次的错误，避免每次调用都重新连接外部系统
+const (
+	initialInitBackoff = time.Second
+	maxInitBackoff     = time.Minute
 )
 
 // TypeReg 是一个泛型注册中心
@@ -16,6 +24,16 @@ type TypeReg[T interface {
 	mu                   sync.Mutex
 	constructorMap       map[string]func() T
 	singletonInstanceMap map[string]T
+	initEntries          map[string]*initEntry
+	decorators           []func(id string, instance T) T
+}
+
+// initEntry 单个实例的初始化状态：各实例在自己的锁内初始化，慢初始化不阻塞其他实例
+type initEntry struct {
+	mu       sync.Mutex
+	err      error     // 上次初始化失败的错误
+	retryAt  time.Time // 在此之前直接返回 err
+	failures int       // 连续失败次数，决定退避时间
 }
 
 // New 创建一个新的 Registry
@@ -26,6 +44,7 @@ func New[T interface {
 	return &TypeReg[T]{
 		constructorMap:       make(map[string]func() T),
 		singletonInstanceMap: make(map[string]T),
+		initEntries:          make(map[string]*initEntry),
 	}
 }
 
@@ -68,6 +87,64 @@ func (r *TypeReg[T]) AutoRegister(instance T) {
 	fmt.Printf("AutoRegister success: type=%s, id=%s\n", instanceType.String(), id)
 }
 
+// Register 以指定 ID 注册构造函数，用于同一类型按配置生成多个实例（如外部插件 RemoteShimlet）
+func (r *TypeReg[T]) Register(id string, constructor func() T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.constructorMap[id] = constructor
+}
+
+// Decorate 注册装饰器，单例初始化完成后依次包装（如添加指标采集），id 为实例名
+func (r *TypeReg[T]) Decorate(decorator func(id string, instance T) T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decorators = append(r.decorators, decorator)
+}
+
+// Has 判断 ID 是否可用：已注册的类型，或配置中 type 为已注册类型的具名实例
+func (r *TypeReg[T]) Has(id string) bool {
+	typeID := typeOf(id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.constructorMap[typeID]
+	return ok
+}
+
+// TypeOf 返回实例对应的注册类型
+func (r *TypeReg[T]) TypeOf(id string) string {
+	return typeOf(id)
+}
+
+// Initialized 判断实例是否已创建并初始化
+func (r *TypeReg[T]) Initialized(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.singletonInstanceMap[id]
+	return ok
+}
+
+// typeOf 配置了 type 的具名实例（如同一类型连接多个集群）使用 type，否则实例名即类型
+func typeOf(id string) string {
+	if globalCfg := config.Get(); globalCfg != nil {
+		if typeID := globalCfg.Shimlets[id].Type; typeID != "" {
+			return typeID
+		}
+	}
+	return id
+}
+
+// IDs 返回全部已注册的类型 ID（按字典序）
+func (r *TypeReg[T]) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.constructorMap))
+	for id := range r.constructorMap {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // NewUninitialized 根据 ID 创建一个新实例
 func (r *TypeReg[T]) newUninitialized(id string) T {
 	if c, ok := r.constructorMap[id]; ok {
@@ -77,22 +154,83 @@ func (r *TypeReg[T]) newUninitialized(id string) T {
 	return zero
 }
 
+// GetSingleton 获取实例，不存在时按配置创建并初始化
+// id 为实例名：每个实例使用各自的 config-path，同一类型可以有多个实例。
+// 初始化在实例自己的锁内进行，不持有注册中心的全局锁；失败后按指数退避，退避期间返回上次的错误
 func (r *TypeReg[T]) GetSingleton(id string) (T, error) {
-	var zero T
+	r.mu.Lock()
+	if singleton, exists := r.singletonInstanceMap[id]; exists {
+		r.mu.Unlock()
+		return singleton, nil
+	}
+	entry, ok := r.initEntries[id]
+	if !ok {
+		entry = &initEntry{}
+		r.initEntries[id] = entry
+	}
+	r.mu.Unlock()
 
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	// 等待锁期间其他调用方可能已完成初始化
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	singleton, exists := r.singletonInstanceMap[id]
-	if !exists {
-		// 实例不存在，创建并初始化
-		singleton = r.newUninitialized(id)
-		confPath := config.Get().Shimlets[id].ConfigPath
-		if err := singleton.InitWithConfig(confPath); err != nil {
-			log.Error("singleton init error: ", err)
-			return zero, err // 返回零值和错误
+	r.mu.Unlock()
+	if exists {
+		return singleton, nil
+	}
+	if entry.err != nil && time.Now().Before(entry.retryAt) {
+		return singleton, fmt.Errorf("%w (init of %s failed, retrying after %s)", entry.err, id, entry.retryAt.Format(time.RFC3339))
+	}
+
+	singleton, err := r.initSingleton(id)
+	if err != nil {
+		entry.failures++
+		backoff := initialInitBackoff << min(entry.failures-1, 6)
+		if backoff > maxInitBackoff {
+			backoff = maxInitBackoff
 		}
-		r.singletonInstanceMap[id] = singleton
+		entry.err = err
+		entry.retryAt = time.Now().Add(backoff)
+		return singleton, err
 	}
 
+	r.mu.Lock()
+	r.singletonInstanceMap[id] = singleton
+	delete(r.initEntries, id)
+	r.mu.Unlock()
+	return singleton, nil
+}
+
+// initSingleton 按配置创建实例、初始化并依次应用装饰器
+func (r *TypeReg[T]) initSingleton(id string) (T, error) {
+	var zero T
+
+	globalCfg := config.Get()
+	if globalCfg == nil {
+		return zero, fmt.Errorf("config is not loaded, cannot init %s", id)
+	}
+	typeID := id
+	if instanceCfg := globalCfg.Shimlets[id]; instanceCfg.Type != "" {
+		typeID = instanceCfg.Type
+	}
+
+	r.mu.Lock()
+	_, registered := r.constructorMap[typeID]
+	singleton := r.newUninitialized(typeID)
+	decorators := append([]func(id string, instance T) T(nil), r.decorators...)
+	r.mu.Unlock()
+	if !registered {
+		return zero, fmt.Errorf("type %s is not registered", typeID)
+	}
+
+	confPath := globalCfg.Shimlets[id].ConfigPath
+	if err := singleton.InitWithConfig(confPath); err != nil {
+		log.Error("singleton init error: ", err)
+		return zero, err // 返回零值和错误
+	}
+	for _, decorate := range decorators {
+		singleton = decorate(id, singleton)
+	}
 	return singleton, nil
 }
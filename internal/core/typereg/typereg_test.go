@@ -0,0 +1,124 @@
+package typereg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/config"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试实例：slow 初始化阻塞到测试放行，broken 初始化总是失败
+const typeregTestConfig = `
+shimlets:
+  slow:
+    config-path: ""
+  fast:
+    config-path: ""
+  broken:
+    config-path: ""
+`
+
+var errBroken = errors.New("broken init")
+
+type testInstance struct {
+	id          string
+	release     chan struct{}
+	brokenInits *atomic.Int32
+}
+
+func (i *testInstance) ID() string { return i.id }
+
+func (i *testInstance) InitWithConfig(string) error {
+	switch i.id {
+	case "slow":
+		<-i.release
+	case "broken":
+		i.brokenInits.Add(1)
+		return errBroken
+	}
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	dir, err := os.MkdirTemp("", "xmod-typereg")
+	if err != nil {
+		panic(err)
+	}
+	confPath := filepath.Join(dir, "conf.yaml")
+	if err := os.WriteFile(confPath, []byte(typeregTestConfig), 0o644); err != nil {
+		panic(err)
+	}
+	config.SetConfigPath(confPath)
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestReg 注册测试实例，slow 的初始化阻塞到 release 关闭
+func newTestReg(release chan struct{}, brokenInits *atomic.Int32) *TypeReg[*testInstance] {
+	r := New[*testInstance]()
+	for _, id := range []string{"slow", "fast", "broken"} {
+		r.Register(id, func() *testInstance {
+			return &testInstance{id: id, release: release, brokenInits: brokenInits}
+		})
+	}
+	return r
+}
+
+// 测试慢初始化不阻塞其他实例，并发调用方共享同一个实例
+func TestGetSingleton_InitOutsideGlobalLock(t *testing.T) {
+	release := make(chan struct{})
+	r := newTestReg(release, &atomic.Int32{})
+	slowCh := make(chan *testInstance, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			instance, err := r.GetSingleton("slow")
+			assert.NoError(t, err)
+			slowCh <- instance
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		instance, err := r.GetSingleton("fast")
+		assert.NoError(t, err)
+		assert.Equal(t, "fast", instance.ID())
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("init of fast was blocked by slow")
+	}
+	assert.False(t, r.Initialized("slow"))
+
+	close(release)
+	first, second := <-slowCh, <-slowCh
+	assert.Same(t, first, second)
+	assert.True(t, r.Initialized("slow"))
+}
+
+// 测试初始化失败后在退避期间直接返回上次的错误
+func TestGetSingleton_FailureBackoff(t *testing.T) {
+	var brokenInits atomic.Int32
+	r := newTestReg(nil, &brokenInits)
+	_, err := r.GetSingleton("broken")
+	require.ErrorIs(t, err, errBroken)
+	_, err = r.GetSingleton("broken")
+	require.ErrorIs(t, err, errBroken)
+	assert.Contains(t, err.Error(), "retrying after")
+	assert.Equal(t, int32(1), brokenInits.Load())
+
+	_, err = r.GetSingleton("missing")
+	assert.ErrorContains(t, err, "type missing is not registered")
+}
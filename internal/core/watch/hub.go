@@ -0,0 +1,132 @@
+package watch
+
+import (
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"errors"
+	"sync"
+)
+
+const (
+	// SubscriberName watch 在事件总线上的订阅者名称
+	SubscriberName = "watch"
+
+	defaultHistorySize = 1024
+	watcherBufferSize  = 64
+)
+
+// ErrHistoryExpired 续读的序号早于保留的历史事件，或晚于最新的事件（shim 重启后序号重新计数），
+// 客户端需要重新获取全量状态
+var ErrHistoryExpired = errors.New("requested event sequence is no longer retained")
+
+// Watcher 一个 watch 连接，Events 关闭表示连接被终止（Overflowed 为 true 时客户端消费过慢）
+type Watcher struct {
+	serviceID  string // 为空表示关注全部服务
+	ch         chan event.ServiceEvent
+	overflowed bool
+}
+
+// Events 返回事件通道
+func (w *Watcher) Events() <-chan event.ServiceEvent {
+	return w.ch
+}
+
+// Overflowed 是否因消费过慢被断开，客户端可按最后的序号续读
+func (w *Watcher) Overflowed() bool {
+	return w.overflowed
+}
+
+func (w *Watcher) match(ev event.ServiceEvent) bool {
+	return w.serviceID == "" || w.serviceID == ev.ServiceID
+}
+
+// Hub 保留最近的服务事件并向 watch 连接扇出，支持按事件序号续读
+type Hub struct {
+	mu          sync.Mutex
+	history     []event.ServiceEvent
+	historySize int
+	trimmedSeq  uint64 // 已从历史中淘汰的最大序号
+	latestSeq   uint64 // 最新事件的序号
+	watchers    map[*Watcher]struct{}
+}
+
+var GlobalHub *Hub
+
+// NewHub 创建 watch hub，historySize 为保留的历史事件数
+func NewHub(historySize int) *Hub {
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+	return &Hub{
+		history:     make([]event.ServiceEvent, 0, historySize),
+		historySize: historySize,
+		watchers:    make(map[*Watcher]struct{}),
+	}
+}
+
+// Handle 处理事件总线上的事件
+func (h *Hub) Handle(ev event.ServiceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.history) == h.historySize {
+		h.trimmedSeq = h.history[0].Seq
+		h.history = append(h.history[:0], h.history[1:]...)
+	}
+	h.history = append(h.history, ev)
+	h.latestSeq = ev.Seq
+
+	for w := range h.watchers {
+		if !w.match(ev) {
+			continue
+		}
+		select {
+		case w.ch <- ev:
+		default:
+			// 不阻塞事件总线，断开过慢的连接，由客户端续读
+			w.overflowed = true
+			h.removeLocked(w)
+		}
+	}
+}
+
+// Watch 注册 watch 连接，返回 since 之后的历史事件；since 为 0 表示只接收新事件
+func (h *Hub) Watch(serviceID string, since uint64) (*Watcher, []event.ServiceEvent, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w := &Watcher{
+		serviceID: serviceID,
+		ch:        make(chan event.ServiceEvent, watcherBufferSize),
+	}
+
+	var backlog []event.ServiceEvent
+	if since > 0 {
+		// 序号超过最新事件说明客户端读到的是重启前的事件，其间的事件已无法续读
+		if since < h.trimmedSeq || since > h.latestSeq {
+			return nil, nil, ErrHistoryExpired
+		}
+		for _, ev := range h.history {
+			if ev.Seq > since && w.match(ev) {
+				backlog = append(backlog, ev)
+			}
+		}
+	}
+
+	h.watchers[w] = struct{}{}
+	return w, backlog, nil
+}
+
+// Stop 注销 watch 连接
+func (h *Hub) Stop(w *Watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(w)
+}
+
+func (h *Hub) removeLocked(w *Watcher) {
+	if _, ok := h.watchers[w]; !ok {
+		return
+	}
+	delete(h.watchers, w)
+	close(w.ch)
+}
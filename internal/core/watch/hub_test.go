@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"testing"
+
+	event "astron-xmod-shim/internal/dto/eventbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publish(h *Hub, from, to uint64, serviceID string) {
+	for seq := from; seq <= to; seq++ {
+		h.Handle(event.ServiceEvent{Seq: seq, ServiceID: serviceID, Type: event.EventPhaseChanged})
+	}
+}
+
+// 测试按序号续读：返回 since 之后属于该服务的历史事件，之后接收新事件
+func TestHub_Resume(t *testing.T) {
+	h := NewHub(10)
+	publish(h, 1, 3, "a")
+	publish(h, 4, 5, "b")
+
+	w, backlog, err := h.Watch("a", 1)
+	require.NoError(t, err)
+	require.Len(t, backlog, 2)
+	assert.Equal(t, uint64(2), backlog[0].Seq)
+	assert.Equal(t, uint64(3), backlog[1].Seq)
+
+	publish(h, 6, 6, "b")
+	publish(h, 7, 7, "a")
+	ev := <-w.Events()
+	assert.Equal(t, uint64(7), ev.Seq)
+	h.Stop(w)
+
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}
+
+// 测试续读序号已被淘汰时返回 ErrHistoryExpired
+func TestHub_HistoryExpired(t *testing.T) {
+	h := NewHub(3)
+	publish(h, 1, 5, "a")
+
+	_, _, err := h.Watch("", 1)
+	assert.ErrorIs(t, err, ErrHistoryExpired)
+
+	_, backlog, err := h.Watch("", 2)
+	require.NoError(t, err)
+	assert.Len(t, backlog, 3)
+}
+
+// 测试重启后序号重新计数：续读序号超过最新事件时同样返回 ErrHistoryExpired
+func TestHub_SequenceAheadAfterRestart(t *testing.T) {
+	h := NewHub(3)
+	_, _, err := h.Watch("", 42)
+	assert.ErrorIs(t, err, ErrHistoryExpired)
+
+	publish(h, 1, 2, "a")
+	_, _, err = h.Watch("", 42)
+	assert.ErrorIs(t, err, ErrHistoryExpired)
+
+	_, backlog, err := h.Watch("", 2)
+	require.NoError(t, err)
+	assert.Empty(t, backlog)
+}
+
+// 测试消费过慢的连接被断开并标记
+func TestHub_Overflow(t *testing.T) {
+	h := NewHub(0)
+	w, _, err := h.Watch("", 0)
+	require.NoError(t, err)
+
+	publish(h, 1, watcherBufferSize+1, "a")
+	count := 0
+	for range w.Events() {
+		count++
+	}
+	assert.Equal(t, watcherBufferSize, count)
+	assert.True(t, w.Overflowed())
+}
@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"astron-xmod-shim/internal/core/spec"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SubscriberName 回调通知在事件总线上的订阅者名称
+	SubscriberName = "webhook"
+
+	SignatureHeader = "X-Xmod-Signature"
+	EventHeader     = "X-Xmod-Event"
+	DeliveryHeader  = "X-Xmod-Delivery"
+
+	defaultMaxRetries     = 5
+	defaultInitialBackoff = time.Second
+	defaultTimeout        = 5 * time.Second
+	maxBackoff            = time.Minute
+
+	// maxDeliveriesPerService 每个服务保留的投递记录数
+	maxDeliveriesPerService = 50
+)
+
+// Notifier 订阅部署阶段变化事件，向请求级与全局回调地址投递签名的 JSON 通知
+type Notifier struct {
+	specStore      spec.Store
+	urls           []string
+	secret         []byte
+	maxRetries     int
+	initialBackoff time.Duration
+	client         *http.Client
+
+	seq        atomic.Uint64
+	mu         sync.RWMutex
+	deliveries map[string][]*dto.WebhookDelivery
+
+	// queues 每个服务与回调地址一条投递队列，同一队列内按事件顺序串行投递
+	queueMu sync.Mutex
+	queues  map[string]*deliveryQueue
+}
+
+// deliveryQueue 待投递的通知，队列为空时对应的投递协程退出
+type deliveryQueue struct {
+	jobs []deliveryJob
+}
+
+type deliveryJob struct {
+	delivery *dto.WebhookDelivery
+	body     []byte
+}
+
+var GlobalNotifier *Notifier
+
+// NewNotifier 创建回调通知器
+func NewNotifier(cfg *confSpec.WebhookConfig, specStore spec.Store) *Notifier {
+	n := &Notifier{
+		specStore:      specStore,
+		urls:           cfg.URLs,
+		secret:         []byte(cfg.Secret),
+		maxRetries:     cfg.MaxRetries,
+		initialBackoff: time.Duration(cfg.InitialBackoffMs) * time.Millisecond,
+		deliveries:     make(map[string][]*dto.WebhookDelivery),
+		queues:         make(map[string]*deliveryQueue),
+	}
+	if n.maxRetries <= 0 {
+		n.maxRetries = defaultMaxRetries
+	}
+	if n.initialBackoff <= 0 {
+		n.initialBackoff = defaultInitialBackoff
+	}
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	n.client = &http.Client{Timeout: timeout}
+	return n
+}
+
+// Filter 只关注部署阶段变化事件
+func (n *Notifier) Filter(ev event.ServiceEvent) bool {
+	return ev.Type == event.EventPhaseChanged
+}
+
+// Handle 处理事件总线上的事件，投递交给按服务与回调地址划分的队列，
+// 重试不会阻塞事件总线，同一地址收到的通知保持事件顺序
+func (n *Notifier) Handle(ev event.ServiceEvent) {
+	if !n.Filter(ev) {
+		return
+	}
+
+	payload := dto.WebhookPayload{
+		Event:       string(ev.Type),
+		Seq:         ev.Seq,
+		ServiceID:   ev.ServiceID,
+		GoalSetName: ev.GoalSetName,
+		From:        ev.From,
+		To:          ev.To,
+		Endpoint:    ev.EndPoint,
+		Error:       ev.Error,
+		Timestamp:   ev.Timestamp,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Error("marshal webhook payload of service %s failed: %v", ev.ServiceID, err)
+		return
+	}
+
+	for _, url := range n.targets(ev) {
+		delivery := n.newDelivery(ev, url)
+		n.enqueue(deliveryJob{delivery: delivery, body: body})
+	}
+}
+
+// Forget 服务下线后清理其投递记录，已排队的通知仍会投递
+func (n *Notifier) Forget(serviceID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.deliveries, serviceID)
+}
+
+// enqueue 追加到服务与回调地址对应的队列，队列不存在时启动投递协程
+func (n *Notifier) enqueue(job deliveryJob) {
+	key := job.delivery.ServiceID + "|" + job.delivery.URL
+	n.queueMu.Lock()
+	defer n.queueMu.Unlock()
+	if queue, ok := n.queues[key]; ok {
+		queue.jobs = append(queue.jobs, job)
+		return
+	}
+	queue := &deliveryQueue{jobs: []deliveryJob{job}}
+	n.queues[key] = queue
+	go n.drain(key, queue)
+}
+
+// drain 按入队顺序逐条投递，前一条结束（成功或放弃）后才投递下一条
+func (n *Notifier) drain(key string, queue *deliveryQueue) {
+	for {
+		n.queueMu.Lock()
+		if len(queue.jobs) == 0 {
+			delete(n.queues, key)
+			n.queueMu.Unlock()
+			return
+		}
+		job := queue.jobs[0]
+		queue.jobs[0] = deliveryJob{}
+		queue.jobs = queue.jobs[1:]
+		n.queueMu.Unlock()
+
+		n.deliver(job.delivery, job.body)
+	}
+}
+
+// Deliveries 返回服务的投递记录快照，按创建时间倒序
+func (n *Notifier) Deliveries(serviceID string) []dto.WebhookDelivery {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	records := n.deliveries[serviceID]
+	result := make([]dto.WebhookDelivery, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		result = append(result, *records[i])
+	}
+	return result
+}
+
+// targets 请求级回调地址优先，其次为全局地址，重复地址只投递一次。
+// 请求级地址取自事件，事件未携带时再查部署期望
+func (n *Notifier) targets(ev event.ServiceEvent) []string {
+	urls := make([]string, 0, len(n.urls)+1)
+	callbackURL := ev.CallbackURL
+	if callbackURL == "" {
+		if deploySpec := n.specStore.Get(ev.ServiceID); deploySpec != nil {
+			callbackURL = deploySpec.CallbackURL
+		}
+	}
+	if callbackURL != "" {
+		urls = append(urls, callbackURL)
+	}
+	for _, url := range n.urls {
+		if len(urls) > 0 && urls[0] == url {
+			continue
+		}
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+func (n *Notifier) newDelivery(ev event.ServiceEvent, url string) *dto.WebhookDelivery {
+	now := time.Now()
+	delivery := &dto.WebhookDelivery{
+		ID:        fmt.Sprintf("%d-%d", ev.Seq, n.seq.Add(1)),
+		ServiceID: ev.ServiceID,
+		URL:       url,
+		EventSeq:  ev.Seq,
+		From:      ev.From,
+		To:        ev.To,
+		Status:    dto.DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// 服务已下线（Forget 已清理记录）后到达的删除完成通知照常投递，但不再保留记录，
+	// 避免记录表随服务增删无限增长
+	if (ev.To == dto.PhaseTerminated || ev.To == dto.PhaseUnknown) && n.specStore.Get(ev.ServiceID) == nil {
+		return delivery
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	records := append(n.deliveries[ev.ServiceID], delivery)
+	if len(records) > maxDeliveriesPerService {
+		records = records[len(records)-maxDeliveriesPerService:]
+	}
+	n.deliveries[ev.ServiceID] = records
+	return delivery
+}
+
+// deliver 投递一次通知，失败按指数退避重试
+func (n *Notifier) deliver(delivery *dto.WebhookDelivery, body []byte) {
+	backoff := n.initialBackoff
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		statusCode, err := n.post(delivery, body)
+		retryable := err != nil || shouldRetry(statusCode)
+		if err == nil && statusCode/100 != 2 {
+			err = fmt.Errorf("unexpected status code %d", statusCode)
+		}
+		n.update(delivery, statusCode, err, retryable && attempt < n.maxRetries)
+		if err == nil {
+			return
+		}
+		if !retryable {
+			break
+		}
+		log.Warn("webhook delivery %s to %s failed (attempt %d): %v", delivery.ID, delivery.URL, attempt+1, err)
+	}
+	log.Error("webhook delivery %s to %s for service %s gave up", delivery.ID, delivery.URL, delivery.ServiceID)
+}
+
+func (n *Notifier) post(delivery *dto.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.EventPhaseChanged))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// update 更新投递记录，pending 为 true 表示还会继续重试
+func (n *Notifier) update(delivery *dto.WebhookDelivery, statusCode int, err error, pending bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.UpdatedAt = time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = dto.DeliverySucceeded
+		delivery.LastError = ""
+	case pending:
+		delivery.Status = dto.DeliveryPending
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = dto.DeliveryFailed
+		delivery.LastError = err.Error()
+	}
+}
+
+// shouldRetry 5xx、408 与 429 可重试，其他状态码视为接收方明确拒绝
+func shouldRetry(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests
+}
+
+// Sign 计算请求体的 HMAC-SHA256 签名，格式为 sha256=<hex>
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名，供接收方（或测试）使用
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"astron-xmod-shim/internal/core/spec"
+	confSpec "astron-xmod-shim/internal/dto/config"
+	dto "astron-xmod-shim/internal/dto/deploy"
+	event "astron-xmod-shim/internal/dto/eventbus"
+	"astron-xmod-shim/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.Init(&confSpec.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// 测试请求级回调地址收到签名的通知，5xx 后按退避重试直至成功
+func TestNotifier_DeliverWithRetry(t *testing.T) {
+	secret := "s3cret"
+	var calls atomic.Int32
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, Verify([]byte(secret), body, r.Header.Get(SignatureHeader)))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- body
+	}))
+	defer server.Close()
+
+	store := spec.NewMemoryStore()
+	store.Set("svc", &dto.RequirementSpec{ServiceId: "svc", CallbackURL: server.URL})
+	notifier := NewNotifier(&confSpec.WebhookConfig{Secret: secret, InitialBackoffMs: 1}, store)
+
+	notifier.Handle(event.ServiceEvent{Seq: 7, Type: event.EventPhaseChanged, ServiceID: "svc", From: dto.PhasePending, To: dto.PhaseRunning})
+	select {
+	case body := <-received:
+		assert.Contains(t, string(body), `"to":"running"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+
+	require.Eventually(t, func() bool {
+		deliveries := notifier.Deliveries("svc")
+		return len(deliveries) == 1 && deliveries[0].Status == dto.DeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+	delivery := notifier.Deliveries("svc")[0]
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, uint64(7), delivery.EventSeq)
+}
+
+// 测试 4xx 视为接收方拒绝，不再重试
+func TestNotifier_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(&confSpec.WebhookConfig{URLs: []string{server.URL}, InitialBackoffMs: 1}, spec.NewMemoryStore())
+	notifier.Handle(event.ServiceEvent{Type: event.EventPhaseChanged, ServiceID: "svc", To: dto.PhaseFailed})
+
+	require.Eventually(t, func() bool {
+		deliveries := notifier.Deliveries("svc")
+		return len(deliveries) == 1 && deliveries[0].Status == dto.DeliveryFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// 测试同一回调地址按事件顺序投递：前一条重试期间后续通知排队等待
+func TestNotifier_DeliverInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	var firstCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload dto.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if payload.Seq == 1 && firstCalls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Lock()
+		received = append(received, string(payload.To))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	store := spec.NewMemoryStore()
+	store.Set("svc", &dto.RequirementSpec{ServiceId: "svc"})
+	notifier := NewNotifier(&confSpec.WebhookConfig{URLs: []string{server.URL}, InitialBackoffMs: 5}, store)
+	phases := []dto.DeployPhase{dto.PhasePending, dto.PhaseRunning, dto.PhaseFailed}
+	for i, phase := range phases {
+		notifier.Handle(event.ServiceEvent{Seq: uint64(i + 1), Type: event.EventPhaseChanged, ServiceID: "svc", To: phase})
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == len(phases)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"pending", "running", "failed"}, received)
+	require.Eventually(t, func() bool {
+		notifier.queueMu.Lock()
+		defer notifier.queueMu.Unlock()
+		return len(notifier.queues) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// 测试服务下线后清理投递记录，之后的通知照常投递但不再记录
+func TestNotifier_ForgetDeletedService(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	store := spec.NewMemoryStore()
+	store.Set("svc", &dto.RequirementSpec{ServiceId: "svc"})
+	notifier := NewNotifier(&confSpec.WebhookConfig{URLs: []string{server.URL}, InitialBackoffMs: 1}, store)
+	notifier.Handle(event.ServiceEvent{Seq: 1, Type: event.EventPhaseChanged, ServiceID: "svc", To: dto.PhaseRunning})
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, notifier.Deliveries("svc"), 1)
+
+	store.Delete("svc")
+	notifier.Forget("svc")
+	assert.Empty(t, notifier.Deliveries("svc"))
+
+	notifier.Handle(event.ServiceEvent{Seq: 2, Type: event.EventPhaseChanged, ServiceID: "svc", To: dto.PhaseTerminated})
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, notifier.Deliveries("svc"))
+}
+
+// 测试服务删除后，删除完成通知仍投递到事件携带的请求级回调地址
+func TestNotifier_CallbackAfterDelete(t *testing.T) {
+	received := make(chan dto.WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload dto.WebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	// 仅配置请求级回调地址，部署期望已在订阅协程处理事件前删除
+	notifier := NewNotifier(&confSpec.WebhookConfig{InitialBackoffMs: 1}, spec.NewMemoryStore())
+	notifier.Handle(event.ServiceEvent{
+		Seq:         1,
+		Type:        event.EventPhaseChanged,
+		ServiceID:   "svc",
+		To:          dto.PhaseTerminated,
+		CallbackURL: server.URL,
+	})
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, "svc", payload.ServiceID)
+		assert.Equal(t, dto.PhaseTerminated, payload.To)
+	case <-time.After(5 * time.Second):
+		t.Fatal("terminated notification was not delivered to the callback")
+	}
+}
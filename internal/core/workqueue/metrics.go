@@ -0,0 +1,60 @@
+package workqueue
+
+import (
+	"astron-xmod-shim/pkg/metrics"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// queueName 队列在指标中的名称
+const queueName = "reconcile"
+
+var (
+	depthMetric = metrics.Default.NewGaugeVec("xmod_workqueue_depth",
+		"Current depth of the workqueue.", "name")
+	addsMetric = metrics.Default.NewCounterVec("xmod_workqueue_adds_total",
+		"Total number of adds handled by the workqueue.", "name")
+	latencyMetric = metrics.Default.NewHistogramVec("xmod_workqueue_queue_duration_seconds",
+		"How long in seconds an item stays in the workqueue before being requested.",
+		[]float64{0.001, 0.01, 0.1, 1, 10, 60, 300}, "name")
+	workDurationMetric = metrics.Default.NewHistogramVec("xmod_workqueue_work_duration_seconds",
+		"How long in seconds processing an item from the workqueue takes.",
+		[]float64{0.001, 0.01, 0.1, 1, 10, 60, 300}, "name")
+	unfinishedWorkMetric = metrics.Default.NewGaugeVec("xmod_workqueue_unfinished_work_seconds",
+		"How many seconds of work has been done that is in progress.", "name")
+	longestRunningMetric = metrics.Default.NewGaugeVec("xmod_workqueue_longest_running_processor_seconds",
+		"How many seconds the longest running processor has been running.", "name")
+	retriesMetric = metrics.Default.NewCounterVec("xmod_workqueue_retries_total",
+		"Total number of delayed re-adds handled by the workqueue.", "name")
+)
+
+// metricsProvider 将 client-go workqueue 的指标接入 metrics.Default
+type metricsProvider struct{}
+
+func (metricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return depthMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return addsMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return latencyMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workDurationMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return unfinishedWorkMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return longestRunningMetric.WithLabelValues(name)
+}
+
+func (metricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return retriesMetric.WithLabelValues(name)
+}
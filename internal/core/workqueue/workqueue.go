@@ -14,7 +14,7 @@ type Queue struct {
 }
 
 // New 创建一个带默认指数退避限流器的命名工作队列。
-// 默认配置：初始重试延迟 5ms，最大延迟 1000 秒。
+// 默认配置：初始重试延迟 5ms，最大延迟 1000 秒；队列指标注册到 metrics.Default。
 func New() *Queue {
 	rateLimiter := workqueue.NewTypedItemExponentialFailureRateLimiter[string](
 		5*time.Millisecond,
@@ -22,7 +22,10 @@ func New() *Queue {
 	)
 	q := workqueue.NewTypedRateLimitingQueueWithConfig(
 		rateLimiter,
-		workqueue.TypedRateLimitingQueueConfig[string]{},
+		workqueue.TypedRateLimitingQueueConfig[string]{
+			Name:            queueName,
+			MetricsProvider: metricsProvider{},
+		},
 	)
 	return &Queue{wq: q}
 }
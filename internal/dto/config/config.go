@@ -1,5 +1,7 @@
 package dto
 
+import deploy "astron-xmod-shim/internal/dto/deploy"
+
 // GlobalConfig 应用主配置结构体，显式添加mapstructure标签
 type GlobalConfig struct {
 	K8s            K8sConfig                `yaml:"k8s" mapstructure:"k8s"`
@@ -8,6 +10,13 @@ type GlobalConfig struct {
 	CurrentShimlet string                   `yaml:"current-shimlet" mapstructure:"current-shimlet"`
 	Shimlets       map[string]ShimletConfig `yaml:"shimlets" mapstructure:"shimlets"`
 	ModelManage    ModelManageConfig        `yaml:"model-manage" mapstructure:"model-manage"`
+	SpecStore      SpecStoreConfig          `yaml:"spec-store" mapstructure:"spec-store"`
+	Adoption       AdoptionConfig           `yaml:"adoption" mapstructure:"adoption"`
+	EventBus       EventBusConfig           `yaml:"event-bus" mapstructure:"event-bus"`
+	Webhook        WebhookConfig            `yaml:"webhook" mapstructure:"webhook"`
+	Engines        map[string]EngineConfig  `yaml:"engines" mapstructure:"engines"` // 按推理引擎覆盖默认配置
+	SmokeTest      SmokeTestConfig          `yaml:"smoke-test" mapstructure:"smoke-test"`
+	GoalSets       GoalSetsConfig           `yaml:"goal-sets" mapstructure:"goal-sets"`
 }
 
 // K8sConfig Kubernetes客户端配置
@@ -17,6 +26,8 @@ type K8sConfig struct {
 	QPS        float32 `yaml:"qps" mapstructure:"qps"`
 	Burst      int     `yaml:"burst" mapstructure:"burst"`
 	Timeout    int64   `yaml:"timeout" mapstructure:"timeout"`
+	// Defaults K8sShimlet 的默认部署参数，可被 RequirementSpec.K8s 按请求覆盖
+	Defaults deploy.K8sOptions `yaml:"defaults" mapstructure:"defaults"`
 }
 
 // Server HTTP服务器配置
@@ -37,10 +48,167 @@ type LogConfig struct {
 
 // ShimletConfig 插件配置（动态）
 type ShimletConfig struct {
-	ConfigPath string `yaml:"config-path" mapstructure:"config-path"`
+	Type       string        `yaml:"type" mapstructure:"type"` // shimlet 类型（如 k8s），为空时与配置项名称相同；同一类型可配置多个具名实例
+	ConfigPath string        `yaml:"config-path" mapstructure:"config-path"`
+	Plugin     *PluginConfig `yaml:"plugin" mapstructure:"plugin"` // 配置后该 shimlet 由外部插件进程实现
+}
+
+// PluginConfig 外部 shimlet 插件配置，插件通过按行分隔的 JSON 协议与 shim 通信
+type PluginConfig struct {
+	Path    string   `yaml:"path" mapstructure:"path"`       // 插件可执行文件，配置后由 shim 启动并通过 stdin/stdout 通信
+	Args    []string `yaml:"args" mapstructure:"args"`       // 插件启动参数
+	Env     []string `yaml:"env" mapstructure:"env"`         // 额外的环境变量，格式 KEY=VALUE
+	Address string   `yaml:"address" mapstructure:"address"` // 已运行插件的地址 unix:///path/to.sock 或 tcp://host:port，与 path 二选一
+	// CallTimeoutMs 单次调用超时，默认 30000ms
+	CallTimeoutMs      int `yaml:"call-timeout-ms" mapstructure:"call-timeout-ms"`
+	HealthIntervalMs   int `yaml:"health-interval-ms" mapstructure:"health-interval-ms"`     // 健康检查间隔，默认 10000ms
+	ReconnectBackoffMs int `yaml:"reconnect-backoff-ms" mapstructure:"reconnect-backoff-ms"` // 断线后首次重连间隔，之后指数退避，默认 1000ms
+	MaxBackoffMs       int `yaml:"max-backoff-ms" mapstructure:"max-backoff-ms"`             // 最大重连间隔，默认 30000ms
 }
 
 // ModelManageConfig 模型管理配置
 type ModelManageConfig struct {
 	ModelRoot string `yaml:"model-root" mapstructure:"model-root"`
 }
+
+// SpecStoreConfig 部署期望（RequirementSpec）存储配置
+type SpecStoreConfig struct {
+	Type      string `yaml:"type" mapstructure:"type"`           // memory / file / configmap，默认 memory
+	Dir       string `yaml:"dir" mapstructure:"dir"`             // file 类型的数据目录
+	Namespace string `yaml:"namespace" mapstructure:"namespace"` // configmap 类型使用的命名空间
+}
+
+// AdoptionConfig 启动时接管运行时中已部署服务的配置
+type AdoptionConfig struct {
+	// OrphanPolicy 对没有部署期望的孤儿服务的处理策略：adopt（接管，默认）/ ignore（忽略）/ gc（回收）
+	OrphanPolicy string `yaml:"orphan-policy" mapstructure:"orphan-policy"`
+}
+
+// EventBusConfig 事件总线订阅者的默认缓冲配置
+type EventBusConfig struct {
+	BufferSize     int    `yaml:"buffer-size" mapstructure:"buffer-size"`           // 每个订阅者的缓冲事件数，默认 256
+	Policy         string `yaml:"policy" mapstructure:"policy"`                     // 缓冲区满时的策略：drop-newest（默认）/ drop-oldest / block
+	BlockTimeoutMs int    `yaml:"block-timeout-ms" mapstructure:"block-timeout-ms"` // block 策略的最长阻塞时间，默认 100ms
+	HistorySize    int    `yaml:"history-size" mapstructure:"history-size"`         // watch 断线续读保留的历史事件数，默认 1024
+}
+
+// WebhookConfig 部署阶段变化的回调通知配置
+type WebhookConfig struct {
+	URLs             []string `yaml:"urls" mapstructure:"urls"`                             // 全局回调地址，对全部服务生效
+	Secret           string   `yaml:"secret" mapstructure:"secret"`                         // HMAC-SHA256 签名密钥，为空时不签名
+	MaxRetries       int      `yaml:"max-retries" mapstructure:"max-retries"`               // 投递失败后的最大重试次数，默认 5
+	InitialBackoffMs int      `yaml:"initial-backoff-ms" mapstructure:"initial-backoff-ms"` // 首次重试间隔，之后指数退避，默认 1000ms
+	TimeoutMs        int      `yaml:"timeout-ms" mapstructure:"timeout-ms"`                 // 单次请求超时，默认 5000ms
+}
+
+// EngineConfig 推理引擎配置
+type EngineConfig struct {
+	Image string `yaml:"image" mapstructure:"image"` // 引擎镜像，为空时使用内置默认镜像
+}
+
+// SmokeTestConfig 部署后冒烟测试配置：服务 running 后请求 /v1/models 与一次 chat completion，通过后部署才完成
+type SmokeTestConfig struct {
+	Enabled   *bool  `yaml:"enabled" mapstructure:"enabled"`       // 是否启用，默认 true
+	Prompt    string `yaml:"prompt" mapstructure:"prompt"`         // 测试请求的内容，默认 "ping"
+	MaxTokens int    `yaml:"max-tokens" mapstructure:"max-tokens"` // 生成的最大 token 数，默认 4
+	TimeoutMs int    `yaml:"timeout-ms" mapstructure:"timeout-ms"` // 单次请求超时，默认 60000ms
+}
+
+// GoalSetsConfig 声明式目标集合配置
+type GoalSetsConfig struct {
+	Dir string `yaml:"dir" mapstructure:"dir"` // 目标集合定义文件（*.yaml / *.yml）所在目录，为空时只使用内置目标集合
+}
+
+// GoalSetFileConfig 声明式目标集合定义文件，由内置的目标原语组合而成
+type GoalSetFileConfig struct {
+	Name       string       `yaml:"name" mapstructure:"name"`
+	MaxRetries *int         `yaml:"max-retries" mapstructure:"max-retries"` // 目标执行失败的最大重试次数，默认 10
+	TimeoutMs  int64        `yaml:"timeout-ms" mapstructure:"timeout-ms"`   // 整体超时，默认 3600000ms
+	Rollback   bool         `yaml:"rollback" mapstructure:"rollback"`       // 记录最近一次正常的部署期望，更新超时后自动回滚
+	Teardown   bool         `yaml:"teardown" mapstructure:"teardown"`       // 下线目标集合，收敛后删除服务的部署期望与进度
+	Goals      []GoalConfig `yaml:"goals" mapstructure:"goals"`             // 目标，按 depends-on 依赖执行，未声明依赖时按顺序执行
+}
+
+// GoalConfig 声明式目标
+type GoalConfig struct {
+	Name string `yaml:"name" mapstructure:"name"`
+	// Type 目标原语：builtin / shimlet-apply / wait-for-phase / http-check / exec-hook / set-context-value
+	Type            string `yaml:"type" mapstructure:"type"`
+	Retries         int    `yaml:"retries" mapstructure:"retries"`                     // 单次执行内失败后的立即重试次数，默认 0
+	RetryIntervalMs int    `yaml:"retry-interval-ms" mapstructure:"retry-interval-ms"` // 立即重试的间隔，默认 1000ms
+	TimeoutMs       int    `yaml:"timeout-ms" mapstructure:"timeout-ms"`               // 单次尝试（等待类目标为整个等待过程）的超时
+	HoldPhase       string `yaml:"hold-phase" mapstructure:"hold-phase"`               // 目标未达成且服务已 running 时上报的部署阶段，如 verifying
+	// DependsOn 依赖的目标，互不依赖的目标并发执行；文件中没有任何目标声明依赖时按顺序执行
+	DependsOn []string         `yaml:"depends-on" mapstructure:"depends-on"`
+	Params    GoalParamsConfig `yaml:"params" mapstructure:"params"`
+	// Compensate 回滚时执行的补偿动作，可选
+	Compensate *CompensateConfig `yaml:"compensate" mapstructure:"compensate"`
+}
+
+// CompensateConfig 目标的补偿动作：回滚时对本轮执行过的目标按依赖的逆序执行一次
+type CompensateConfig struct {
+	Type            string           `yaml:"type" mapstructure:"type"` // http-check / exec-hook
+	Retries         int              `yaml:"retries" mapstructure:"retries"`
+	RetryIntervalMs int              `yaml:"retry-interval-ms" mapstructure:"retry-interval-ms"`
+	TimeoutMs       int              `yaml:"timeout-ms" mapstructure:"timeout-ms"`
+	Params          GoalParamsConfig `yaml:"params" mapstructure:"params"`
+}
+
+// GoalParamsConfig 目标原语参数，字符串参数支持占位符
+// {{service_id}} {{model_name}} {{model_path}} {{shimlet}} {{endpoint}} {{ctx.<key>}}
+type GoalParamsConfig struct {
+	Goal         string            `yaml:"goal" mapstructure:"goal"`                   // builtin：引用的内置目标
+	Phase        string            `yaml:"phase" mapstructure:"phase"`                 // wait-for-phase：等待的部署阶段，默认 running
+	URL          string            `yaml:"url" mapstructure:"url"`                     // http-check：请求地址
+	Method       string            `yaml:"method" mapstructure:"method"`               // http-check：请求方法，默认 GET
+	Headers      map[string]string `yaml:"headers" mapstructure:"headers"`             // http-check：请求头
+	Body         string            `yaml:"body" mapstructure:"body"`                   // http-check：请求体
+	ExpectStatus int               `yaml:"expect-status" mapstructure:"expect-status"` // http-check：期望的状态码，默认任意 2xx
+	Command      []string          `yaml:"command" mapstructure:"command"`             // exec-hook：在 shim 所在主机执行的命令及参数
+	Env          []string          `yaml:"env" mapstructure:"env"`                     // exec-hook：额外的环境变量，格式 KEY=VALUE
+	Key          string            `yaml:"key" mapstructure:"key"`                     // set-context-value：上下文键
+	Value        string            `yaml:"value" mapstructure:"value"`                 // set-context-value：上下文值
+}
+
+// DockerConfig DockerShimlet 专用配置
+type DockerConfig struct {
+	Host           string `yaml:"host" mapstructure:"host"`                         // Engine API 地址：unix:///var/run/docker.sock（默认）或 tcp://host:2375
+	APIVersion     string `yaml:"api-version" mapstructure:"api-version"`           // Engine API 版本，默认 v1.43
+	Timeout        int64  `yaml:"timeout" mapstructure:"timeout"`                   // 请求超时（秒），默认 60
+	Image          string `yaml:"image" mapstructure:"image"`                       // 推理服务镜像，为空时使用引擎镜像
+	HostIP         string `yaml:"host-ip" mapstructure:"host-ip"`                   // 对外暴露 endpoint 使用的主机地址，默认 127.0.0.1
+	PortRangeStart int    `yaml:"port-range-start" mapstructure:"port-range-start"` // 主机端口分配范围，默认 30000-32767
+	PortRangeEnd   int    `yaml:"port-range-end" mapstructure:"port-range-end"`
+	GPUDriver      string `yaml:"gpu-driver" mapstructure:"gpu-driver"` // DeviceRequests 使用的驱动，默认 nvidia
+	Network        string `yaml:"network" mapstructure:"network"`       // 容器网络，为空时使用 Docker 默认网络
+}
+
+// ProcessConfig ProcessShimlet 专用配置
+type ProcessConfig struct {
+	StateDir string `yaml:"state-dir" mapstructure:"state-dir"` // 进程状态与日志目录，默认 ./data/process
+	Command  string `yaml:"command" mapstructure:"command"`     // 推理服务命令，为空或请求指定了 engine 时由推理引擎生成
+	// Args 命令参数，支持占位符 {{model_name}} {{model_path}} {{model_dir}} {{port}} {{host}} {{service_id}} {{context_length}}
+	Args             []string `yaml:"args" mapstructure:"args"`
+	Env              []string `yaml:"env" mapstructure:"env"`                           // 额外的环境变量，格式 KEY=VALUE
+	Host             string   `yaml:"host" mapstructure:"host"`                         // 服务监听与 endpoint 使用的地址，默认 127.0.0.1
+	PortRangeStart   int      `yaml:"port-range-start" mapstructure:"port-range-start"` // 端口分配范围，默认 30000-32767
+	PortRangeEnd     int      `yaml:"port-range-end" mapstructure:"port-range-end"`
+	RestartBackoffMs int      `yaml:"restart-backoff-ms" mapstructure:"restart-backoff-ms"` // 崩溃后首次重启间隔，之后指数退避，默认 1000ms
+	MaxBackoffMs     int      `yaml:"max-backoff-ms" mapstructure:"max-backoff-ms"`         // 最大重启间隔，默认 60000ms
+	FailedRestarts   int      `yaml:"failed-restarts" mapstructure:"failed-restarts"`       // 连续崩溃达到该次数后视为 failed，默认 3
+}
+
+// SimConfig SimShimlet 专用配置，内存中模拟部署，用于端到端测试与演示
+type SimConfig struct {
+	ReadyAfterMs     int     `yaml:"ready-after-ms" mapstructure:"ready-after-ms"`         // Apply 后经过该时长进入 running，0 表示立即就绪
+	TerminateAfterMs int     `yaml:"terminate-after-ms" mapstructure:"terminate-after-ms"` // Delete 后保持 terminating 的时长
+	ApplyFailureRate float64 `yaml:"apply-failure-rate" mapstructure:"apply-failure-rate"` // 注入 Apply 失败的概率，取值 0~1
+	// StatusFailureRate 注入 Status 失败的概率，取值 0~1
+	StatusFailureRate float64 `yaml:"status-failure-rate" mapstructure:"status-failure-rate"`
+	DeleteFailureRate float64 `yaml:"delete-failure-rate" mapstructure:"delete-failure-rate"` // 注入 Delete 失败的概率，取值 0~1
+	DriftAfterMs      int     `yaml:"drift-after-ms" mapstructure:"drift-after-ms"`           // 服务 running 该时长后发生漂移，0 表示不漂移
+	DriftMode         string  `yaml:"drift-mode" mapstructure:"drift-mode"`                   // 漂移方式：failed / missing / spec，默认 failed
+	// EndpointTemplate running 时返回的 endpoint，支持占位符 {{service_id}} {{model_name}}
+	EndpointTemplate string `yaml:"endpoint-template" mapstructure:"endpoint-template"`
+	Seed             int64  `yaml:"seed" mapstructure:"seed"` // 随机数种子，0 表示按当前时间
+}
@@ -0,0 +1,21 @@
+package dto
+
+// GoalSetInfo 已注册的目标集合
+type GoalSetInfo struct {
+	Name       string     `json:"name"`
+	Source     string     `json:"source"` // builtin 或声明式定义文件路径
+	MaxRetries int        `json:"maxRetries"`
+	TimeoutMs  int64      `json:"timeoutMs"`
+	Rollback   bool       `json:"rollback"` // 是否记录最近一次正常版本并在更新超时后自动回滚
+	Teardown   bool       `json:"teardown"` // 是否为下线目标集合，收敛后删除服务的部署期望与进度
+	Goals      []GoalInfo `json:"goals"`
+}
+
+// GoalInfo 目标集合中的目标
+type GoalInfo struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"` // 原语类型，Go 代码中定义的目标为 builtin
+	HoldPhase  DeployPhase `json:"holdPhase,omitempty"`
+	DependsOn  []string    `json:"dependsOn"`  // 依赖的目标，依赖全部达成后才执行
+	Compensate bool        `json:"compensate"` // 是否声明了回滚时执行的补偿动作
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// K8sOptions K8sShimlet 的部署参数
+// 同时用于 k8s-shimlet.yaml 中的 defaults 与 RequirementSpec 中的单次覆盖：
+// 标量字段非空时覆盖默认值；nodeSelector、affinity、tolerations、imagePullSecrets 设置后整体替换默认值；
+// annotations、labels 与默认值合并，同名键以请求为准
+type K8sOptions struct {
+	Namespace          string              `json:"namespace,omitempty" yaml:"namespace" mapstructure:"namespace"`
+	Image              string              `json:"image,omitempty" yaml:"image" mapstructure:"image"`
+	ImagePullPolicy    string              `json:"imagePullPolicy,omitempty" yaml:"image-pull-policy" mapstructure:"image-pull-policy"` // Always / IfNotPresent / Never
+	ImagePullSecrets   []string            `json:"imagePullSecrets,omitempty" yaml:"image-pull-secrets" mapstructure:"image-pull-secrets"`
+	NodeSelector       map[string]string   `json:"nodeSelector,omitempty" yaml:"node-selector" mapstructure:"node-selector"`
+	Affinity           *corev1.Affinity    `json:"affinity,omitempty" yaml:"affinity" mapstructure:"affinity"`
+	Tolerations        []corev1.Toleration `json:"tolerations,omitempty" yaml:"tolerations" mapstructure:"tolerations"`
+	PriorityClassName  string              `json:"priorityClassName,omitempty" yaml:"priority-class-name" mapstructure:"priority-class-name"`
+	ServiceAccountName string              `json:"serviceAccountName,omitempty" yaml:"service-account-name" mapstructure:"service-account-name"`
+	Annotations        map[string]string   `json:"annotations,omitempty" yaml:"annotations" mapstructure:"annotations"` // 附加到 shim 创建的全部资源与 Pod 模板
+	Labels             map[string]string   `json:"labels,omitempty" yaml:"labels" mapstructure:"labels"`                // 附加到 shim 创建的全部资源与 Pod 模板，不能覆盖 app / managed-by
+	Expose             *K8sExpose          `json:"expose,omitempty" yaml:"expose" mapstructure:"expose"`                // 服务暴露方式，按字段覆盖默认值
+	Probes             *K8sProbes          `json:"probes,omitempty" yaml:"probes" mapstructure:"probes"`                // 健康检查探针，按字段覆盖默认值
+}
+
+// DeepCopy 返回 K8s 部署参数的深拷贝
+func (o *K8sOptions) DeepCopy() *K8sOptions {
+	if o == nil {
+		return nil
+	}
+	out := *o
+	out.ImagePullSecrets = slices.Clone(o.ImagePullSecrets)
+	out.NodeSelector = maps.Clone(o.NodeSelector)
+	out.Affinity = o.Affinity.DeepCopy()
+	if o.Tolerations != nil {
+		out.Tolerations = make([]corev1.Toleration, len(o.Tolerations))
+		for i := range o.Tolerations {
+			o.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+	out.Annotations = maps.Clone(o.Annotations)
+	out.Labels = maps.Clone(o.Labels)
+	if o.Expose != nil {
+		expose := *o.Expose
+		out.Expose = &expose
+	}
+	if o.Probes != nil {
+		probes := *o.Probes
+		if probes.Enabled != nil {
+			enabled := *probes.Enabled
+			probes.Enabled = &enabled
+		}
+		out.Probes = &probes
+	}
+	return &out
+}
+
+// K8sExpose 推理服务的暴露方式
+type K8sExpose struct {
+	Mode string `json:"mode,omitempty" yaml:"mode" mapstructure:"mode"` // cluster-ip / node-port / ingress / gateway / host-network，默认 host-network
+	Port int    `json:"port,omitempty" yaml:"port" mapstructure:"port"` // 容器与 Service 端口，默认 8000；host-network 模式使用分配的主机端口
+	// PortRangeStart node-port 与 host-network 模式的端口分配范围，默认 30000-32767
+	PortRangeStart int    `json:"portRangeStart,omitempty" yaml:"port-range-start" mapstructure:"port-range-start"`
+	PortRangeEnd   int    `json:"portRangeEnd,omitempty" yaml:"port-range-end" mapstructure:"port-range-end"`
+	NodeAddress    string `json:"nodeAddress,omitempty" yaml:"node-address" mapstructure:"node-address"` // node-port 模式 endpoint 使用的节点地址，默认取就绪节点的 InternalIP
+	// Host ingress / gateway 模式的域名，支持占位符 {{service_id}} {{namespace}}
+	Host             string `json:"host,omitempty" yaml:"host" mapstructure:"host"`
+	Path             string `json:"path,omitempty" yaml:"path" mapstructure:"path"` // 路由路径前缀，默认 /
+	IngressClassName string `json:"ingressClassName,omitempty" yaml:"ingress-class-name" mapstructure:"ingress-class-name"`
+	TLSSecretName    string `json:"tlsSecretName,omitempty" yaml:"tls-secret-name" mapstructure:"tls-secret-name"` // ingress 模式的 TLS 证书 Secret，配置后 endpoint 使用 https
+	GatewayName      string `json:"gatewayName,omitempty" yaml:"gateway-name" mapstructure:"gateway-name"`         // gateway 模式 HTTPRoute 挂载的 Gateway
+	GatewayNamespace string `json:"gatewayNamespace,omitempty" yaml:"gateway-namespace" mapstructure:"gateway-namespace"`
+}
+
+// K8sProbes 推理服务的启动、存活与就绪探针
+// 启动与存活探针访问引擎的健康检查路径，就绪探针访问 readinessPath，模型可以对外服务后 Pod 才就绪
+type K8sProbes struct {
+	Enabled       *bool  `json:"enabled,omitempty" yaml:"enabled" mapstructure:"enabled"`                     // 是否配置探针，默认 true
+	ReadinessPath string `json:"readinessPath,omitempty" yaml:"readiness-path" mapstructure:"readiness-path"` // 就绪探针路径，默认 /v1/models
+	PeriodSeconds int    `json:"periodSeconds,omitempty" yaml:"period-seconds" mapstructure:"period-seconds"` // 探测间隔，默认 10 秒
+	// StartupTimeoutSeconds 等待模型加载完成的时间，超时后容器被重启；默认按模型大小估算
+	StartupTimeoutSeconds int `json:"startupTimeoutSeconds,omitempty" yaml:"startup-timeout-seconds" mapstructure:"startup-timeout-seconds"`
+}
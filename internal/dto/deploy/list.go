@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// ServiceSummary 服务列表中的单个服务信息
+type ServiceSummary struct {
+	ServiceId        string            `json:"serviceId"`
+	ModelName        string            `json:"modelName"`
+	Phase            DeployPhase       `json:"phase"`        // reconciler 最近观测到的阶段，过滤与排序均基于该值
+	RuntimePhase     DeployPhase       `json:"runtimePhase"` // 列表查询时 shimlet 实时上报的阶段，查询失败或超时为空
+	Endpoint         string            `json:"endpoint"`
+	ReplicaCount     int               `json:"replicaCount"`
+	AcceleratorType  string            `json:"acceleratorType"`
+	AcceleratorCount int               `json:"acceleratorCount"`
+	ShimletName      string            `json:"shimletName"`
+	GoalSetName      string            `json:"goalSetName"`
+	Labels           map[string]string `json:"labels"`
+	CreateTime       time.Time         `json:"createTime"`
+	UpdateTime       time.Time         `json:"updateTime"`
+}
+
+// ServiceListQuery 服务列表查询条件
+type ServiceListQuery struct {
+	ModelName   string            // 按模型名过滤
+	Phase       DeployPhase       // 按运行阶段过滤
+	ShimletName string            // 按 shimlet 过滤
+	Labels      map[string]string // 按标签过滤，需全部匹配
+	SortBy      string            // 排序字段：createTime（默认）/ updateTime / serviceId / modelName / phase
+	Order       string            // asc / desc（默认）
+	Limit       int               // 每页数量
+	Cursor      string            // 上一页返回的 nextCursor
+}
+
+// ServiceList 服务列表分页结果
+type ServiceList struct {
+	Items      []ServiceSummary `json:"items"`
+	Total      int              `json:"total"`      // 过滤后的总数
+	NextCursor string           `json:"nextCursor"` // 为空表示没有下一页
+}
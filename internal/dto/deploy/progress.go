@@ -0,0 +1,126 @@
+package dto
+
+import "time"
+
+// ServiceProgress 服务在当前目标集合下的收敛进度
+type ServiceProgress struct {
+	ServiceID      string         `json:"serviceId"`
+	GoalSetName    string         `json:"goalSetName"`
+	Retries        int            `json:"retries"`        // 本轮收敛中 Ensure 失败的次数
+	FirstSubmitted time.Time      `json:"firstSubmitted"` // 本轮收敛的起始时间，超时从此刻计算
+	LastAttempt    time.Time      `json:"lastAttempt"`
+	LastError      string         `json:"lastError"`
+	Failed         bool           `json:"failed"` // 超过重试次数或超时后进入终态，需通过 retry 接口恢复
+	FailedAt       time.Time      `json:"failedAt"`
+	Phase          DeployPhase    `json:"phase"`                    // 最近一次观测到的部署阶段
+	Goals          []GoalProgress `json:"goals"`                    // 按目标集合顺序排列
+	RollbackReason string         `json:"rollbackReason,omitempty"` // 本轮收敛由回滚发起时的原因
+}
+
+// GoalProgress 单个目标的执行记录
+type GoalProgress struct {
+	Name         string    `json:"name"`
+	Achieved     bool      `json:"achieved"`  // 最近一次 IsAchieved 的结果
+	LastError    string    `json:"lastError"` // 最近一次 Ensure 的错误，成功后清空
+	Attempts     int       `json:"attempts"`  // Ensure 调用次数
+	FirstChecked time.Time `json:"firstChecked"`
+	LastChecked  time.Time `json:"lastChecked"`
+	LastEnsured  time.Time `json:"lastEnsured"`
+	AchievedAt   time.Time `json:"achievedAt"`
+	Detail       any       `json:"detail,omitempty"` // 目标最近一次 Ensure 记录的结果，如冒烟测试的耗时
+	DependsOn    []string  `json:"dependsOn"`        // 依赖的目标，依赖全部达成后才执行
+	Running      bool      `json:"running"`          // Ensure 正在执行
+}
+
+// GoalNode 目标依赖图中的节点
+type GoalNode struct {
+	Name      string   `json:"name"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// SmokeTestResult 部署后冒烟测试的结果
+type SmokeTestResult struct {
+	Endpoint        string    `json:"endpoint"`
+	Model           string    `json:"model"` // /v1/models 返回的模型名
+	Passed          bool      `json:"passed"`
+	Error           string    `json:"error,omitempty"`
+	SkipReason      string    `json:"skipReason,omitempty"` // 未实际请求的原因，如 shim 无法访问集群内地址
+	ModelsLatencyMs int64     `json:"modelsLatencyMs"`      // /v1/models 耗时
+	LatencyMs       int64     `json:"latencyMs"`            // chat completion 总耗时
+	FirstTokenMs    int64     `json:"firstTokenMs"`         // chat completion 首个 token 耗时
+	CheckedAt       time.Time `json:"checkedAt"`
+}
+
+// HookResult 声明式目标中 HTTP 检查与 exec 钩子的执行结果
+type HookResult struct {
+	Target     string    `json:"target"` // 请求的 URL 或执行的命令
+	Passed     bool      `json:"passed"`
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts"`             // 本次执行的尝试次数（含重试）
+	StatusCode int       `json:"statusCode,omitempty"` // HTTP 检查的响应状态码
+	ExitCode   *int      `json:"exitCode,omitempty"`   // exec 钩子的退出码
+	Output     string    `json:"output,omitempty"`     // 响应体或命令输出的末尾部分
+	LatencyMs  int64     `json:"latencyMs"`            // 最后一次尝试的耗时
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// CurrentGoal 返回第一个当前目标（见 ActiveGoals），全部达成时返回空
+func (p *ServiceProgress) CurrentGoal() string {
+	if active := p.ActiveGoals(); len(active) > 0 {
+		return active[0]
+	}
+	return ""
+}
+
+// ActiveGoals 返回当前目标：依赖已全部达成而自身尚未达成、可并发执行的目标，按目标集合顺序排列。
+// 目标已达成且其依赖均已达成才视为达成，依赖未达成的目标不会执行
+func (p *ServiceProgress) ActiveGoals() []string {
+	goals := make(map[string]*GoalProgress, len(p.Goals))
+	for i := range p.Goals {
+		goals[p.Goals[i].Name] = &p.Goals[i]
+	}
+	settled := make(map[string]bool, len(p.Goals))
+	var isSettled func(name string, depth int) bool
+	isSettled = func(name string, depth int) bool {
+		g, ok := goals[name]
+		// 依赖不在进度中，或路径长度超过目标数（循环依赖），视为未达成
+		if !ok || depth > len(p.Goals) {
+			return false
+		}
+		if result, ok := settled[name]; ok {
+			return result
+		}
+		result := g.Achieved && depsSettled(g.DependsOn, func(dep string) bool { return isSettled(dep, depth+1) })
+		settled[name] = result
+		return result
+	}
+
+	var active []string
+	for _, g := range p.Goals {
+		if !g.Achieved && depsSettled(g.DependsOn, func(dep string) bool { return isSettled(dep, 0) }) {
+			active = append(active, g.Name)
+		}
+	}
+	return active
+}
+
+// depsSettled 判断依赖是否全部达成
+func depsSettled(deps []string, settled func(string) bool) bool {
+	for _, dep := range deps {
+		if !settled(dep) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompletedGoals 返回已达成的目标名称
+func (p *ServiceProgress) CompletedGoals() []string {
+	completed := make([]string, 0, len(p.Goals))
+	for _, g := range p.Goals {
+		if g.Achieved {
+			completed = append(completed, g.Name)
+		}
+	}
+	return completed
+}
@@ -0,0 +1,14 @@
+package dto
+
+// ShimletInfo 已配置的 shimlet 实例信息
+type ShimletInfo struct {
+	Name        string `json:"name"`        // 实例名，即 RequirementSpec.ShimletName
+	Type        string `json:"type"`        // shimlet 类型，如 k8s / docker
+	Description string `json:"description"` // 初始化后由 shimlet 提供
+	Current     bool   `json:"current"`     // 是否为 current-shimlet（未指定 shimletName 时使用）
+	Initialized bool   `json:"initialized"` // 是否已创建并初始化
+	Services    int    `json:"services"`    // 归属该实例的部署期望数量
+	// DeployedServices 运行时中已部署的服务（仅已初始化的实例）
+	DeployedServices []string `json:"deployedServices"`
+	Error            string   `json:"error,omitempty"` // 查询运行时失败的原因
+}
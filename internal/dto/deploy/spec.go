@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
 // ResourceRequirements 定义资源需求
 type ResourceRequirements struct {
 	AcceleratorType  string `json:"acceleratorType"`  // 显卡类型
@@ -17,6 +23,128 @@ type RequirementSpec struct {
 	Env                  []Env                 `json:"env"`
 	GoalSetName          string                `json:"goalSetName"`
 	ShimletName          string                `json:"shimletName"`
+	Labels               map[string]string     `json:"labels"`                  // 用户自定义标签，用于服务检索
+	CallbackURL          string                `json:"callbackUrl"`             // 部署阶段变化时回调的地址，可选
+	Engine               string                `json:"engine,omitempty"`        // 推理引擎：vllm（默认）/ sglang / tgi / llamacpp
+	EngineOptions        *EngineOptions        `json:"engineOptions,omitempty"` // 推理引擎参数，可选
+	Parallelism          *ParallelismSpec      `json:"parallelism,omitempty"`   // 并行与多节点部署，可选
+	K8s                  *K8sOptions           `json:"k8s,omitempty"`           // K8sShimlet 部署参数覆盖，可选
+	CreateTime           time.Time             `json:"createTime"`              // 首次提交时间
+	UpdateTime           time.Time             `json:"updateTime"`              // 最近一次提交时间
+	// LastKnownGood 最近一次收敛成功的部署期望快照，由 shim 维护，用于回滚；提交时忽略
+	LastKnownGood *RequirementSpec `json:"lastKnownGood,omitempty"`
+	// RolledBack 部署期望由回滚生成，超时后不再自动回滚
+	RolledBack bool `json:"rolledBack,omitempty"`
+	// VerifiedUpdateTime 部署后冒烟测试通过或跳过时的部署期望版本（UpdateTime），由 shim 维护；提交时忽略
+	VerifiedUpdateTime time.Time `json:"verifiedUpdateTime,omitzero"`
+	// VerificationSkipped 该版本的冒烟测试被跳过（shim 无法访问集群内地址）而非通过
+	VerificationSkipped bool `json:"verificationSkipped,omitempty"`
+	// ConvergeStartTime 与 ConvergeRetries 本轮收敛的起始时间与 Ensure 失败次数，由 shim 维护，
+	// 重启后接续计算超时与重试次数；提交时忽略
+	ConvergeStartTime time.Time `json:"convergeStartTime,omitzero"`
+	ConvergeRetries   int       `json:"convergeRetries,omitempty"`
+	// ResourceVersion 存储中的版本，由 Store 读取时填充，写入时作为乐观并发的前置条件；不序列化
+	ResourceVersion string `json:"-"`
+}
+
+// Snapshot 返回用作回滚目标的部署期望快照，不包含快照自身的回滚信息
+func (s *RequirementSpec) Snapshot() *RequirementSpec {
+	snapshot := *s
+	snapshot.LastKnownGood = nil
+	snapshot.RolledBack = false
+	snapshot.VerifiedUpdateTime, snapshot.VerificationSkipped = time.Time{}, false
+	snapshot.ConvergeStartTime, snapshot.ConvergeRetries = time.Time{}, 0
+	snapshot.ResourceVersion = ""
+	return &snapshot
+}
+
+// Verified 判断当前版本的部署期望是否已通过部署后冒烟测试，跳过的不算通过
+func (s *RequirementSpec) Verified() bool {
+	return s.checkedCurrent() && !s.VerificationSkipped
+}
+
+// VerificationSkippedCurrent 判断当前版本的部署后冒烟测试是否被跳过
+func (s *RequirementSpec) VerificationSkippedCurrent() bool {
+	return s.checkedCurrent() && s.VerificationSkipped
+}
+
+// 当前版本部署后冒烟测试的状态，见 VerificationState
+const (
+	VerificationStatePassed  = "passed"
+	VerificationStateSkipped = "skipped"
+)
+
+// VerificationState 返回当前版本部署后冒烟测试的状态，尚未验证时为空
+func (s *RequirementSpec) VerificationState() string {
+	switch {
+	case s.Verified():
+		return VerificationStatePassed
+	case s.VerificationSkippedCurrent():
+		return VerificationStateSkipped
+	default:
+		return ""
+	}
+}
+
+// checkedCurrent 当前版本是否已执行（或跳过）部署后冒烟测试
+func (s *RequirementSpec) checkedCurrent() bool {
+	return !s.VerifiedUpdateTime.IsZero() && s.VerifiedUpdateTime.Equal(s.UpdateTime)
+}
+
+// DeepCopy 返回部署期望的深拷贝，修改拷贝不影响存储中的部署期望
+func (s *RequirementSpec) DeepCopy() *RequirementSpec {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	if s.ResourceRequirements != nil {
+		resources := *s.ResourceRequirements
+		out.ResourceRequirements = &resources
+	}
+	out.Env = slices.Clone(s.Env)
+	out.Labels = maps.Clone(s.Labels)
+	if s.EngineOptions != nil {
+		options := *s.EngineOptions
+		if options.TrustRemoteCode != nil {
+			trust := *options.TrustRemoteCode
+			options.TrustRemoteCode = &trust
+		}
+		options.ExtraArgs = slices.Clone(options.ExtraArgs)
+		out.EngineOptions = &options
+	}
+	if s.Parallelism != nil {
+		parallelism := *s.Parallelism
+		out.Parallelism = &parallelism
+	}
+	out.K8s = s.K8s.DeepCopy()
+	out.LastKnownGood = s.LastKnownGood.DeepCopy()
+	return &out
+}
+
+// RollbackTarget 返回可回滚到的部署期望快照；当前部署期望即为最近一次正常版本时返回 nil
+func (s *RequirementSpec) RollbackTarget() *RequirementSpec {
+	if s.LastKnownGood == nil || s.LastKnownGood.UpdateTime.Equal(s.UpdateTime) {
+		return nil
+	}
+	return s.LastKnownGood
+}
+
+// EngineOptions 推理引擎参数，引擎不支持的字段会被忽略
+type EngineOptions struct {
+	Image                string   `json:"image,omitempty"`                // 覆盖引擎镜像，优先于 shimlet 与引擎配置
+	Dtype                string   `json:"dtype,omitempty"`                // 权重精度，默认 auto
+	Quantization         string   `json:"quantization,omitempty"`         // 量化方式，如 awq / gptq / fp8
+	GPUMemoryUtilization float64  `json:"gpuMemoryUtilization,omitempty"` // 显存占用比例，取值 0~1
+	TrustRemoteCode      *bool    `json:"trustRemoteCode,omitempty"`      // 是否信任模型自带代码，默认 true
+	ExtraArgs            []string `json:"extraArgs,omitempty"`            // 追加到启动参数末尾
+}
+
+// ParallelismSpec 模型并行方式
+// resourceRequirements.acceleratorCount 为每个节点（Pod）的显卡数，一个副本由 nodes 个 Pod 组成
+type ParallelismSpec struct {
+	TensorParallelSize   int `json:"tensorParallelSize,omitempty"`   // 张量并行度，默认为全部显卡数 / 流水线并行度
+	PipelineParallelSize int `json:"pipelineParallelSize,omitempty"` // 流水线并行度，默认等于 nodes
+	Nodes                int `json:"nodes,omitempty"`                // 每个副本的节点（Pod）数，默认 1；大于 1 时以 Ray 组成多节点集群
 }
 
 type Env struct {
@@ -9,6 +9,7 @@ const (
 	PhasePending     DeployPhase = "pending"
 	PhaseCreating    DeployPhase = "creating"
 	PhaseRunning     DeployPhase = "running"
+	PhaseVerifying   DeployPhase = "verifying" // 运行时已就绪，等待部署后验证（如冒烟测试）通过
 	PhaseFailed      DeployPhase = "failed"
 	PhaseTerminating DeployPhase = "terminating"
 	PhaseTerminated  DeployPhase = "terminated"
@@ -20,3 +21,13 @@ type RuntimeStatus struct {
 	Status     DeployPhase     `json:"contextLength"`
 	EndPoint   string          `json:"endPoint"`
 }
+
+// Valid 判断是否为已定义的部署阶段
+func (p DeployPhase) Valid() bool {
+	switch p {
+	case PhaseUnknown, PhasePending, PhaseCreating, PhaseRunning, PhaseVerifying,
+		PhaseFailed, PhaseTerminating, PhaseTerminated:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// WebhookDeliveryStatus 回调投递状态
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookPayload 部署阶段变化时回调的请求体
+type WebhookPayload struct {
+	Event       string      `json:"event"`
+	Seq         uint64      `json:"seq"`
+	ServiceID   string      `json:"serviceId"`
+	GoalSetName string      `json:"goalSetName,omitempty"`
+	From        DeployPhase `json:"from"`
+	To          DeployPhase `json:"to"`
+	Endpoint    string      `json:"endpoint"`
+	Error       string      `json:"error"`
+	Timestamp   time.Time   `json:"timestamp"`
+}
+
+// WebhookDelivery 一次回调投递的记录
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	ServiceID      string                `json:"serviceId"`
+	URL            string                `json:"url"`
+	EventSeq       uint64                `json:"eventSeq"`
+	From           DeployPhase           `json:"from"`
+	To             DeployPhase           `json:"to"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode int                   `json:"lastStatusCode"`
+	LastError      string                `json:"lastError"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
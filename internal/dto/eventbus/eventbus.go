@@ -1,10 +1,33 @@
 package eventbus
 
-import dto "astron-xmod-shim/internal/dto/deploy"
+import (
+	dto "astron-xmod-shim/internal/dto/deploy"
+	"time"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventPhaseChanged  EventType = "phase-changed"  // DeployPhase 发生变化
+	EventGoalCompleted EventType = "goal-completed" // 目标由未达成变为达成
+	EventError         EventType = "error"          // Ensure 失败或服务操作出错
+	EventRolledBack    EventType = "rolled-back"    // 服务回滚到最近一次正常的部署期望
+)
 
 // ServiceEvent represents a state change event
 type ServiceEvent struct {
-	ServiceID string
-	To        dto.DeployPhase
-	EndPoint  string
+	Seq         uint64          `json:"seq"` // 由 EventBus 分配，单调递增
+	Type        EventType       `json:"type"`
+	ServiceID   string          `json:"serviceId"`
+	GoalSetName string          `json:"goalSetName,omitempty"`
+	From        dto.DeployPhase `json:"from,omitempty"`
+	To          dto.DeployPhase `json:"to,omitempty"`
+	Goal        string          `json:"goal,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	EndPoint    string          `json:"endPoint,omitempty"`
+	Timestamp   time.Time       `json:"timestamp"`
+	// CallbackURL 发布时服务的请求级回调地址，不对外输出；服务下线后部署期望已删除，
+	// 通知仍能投递到该地址
+	CallbackURL string `json:"-"`
 }
@@ -0,0 +1,332 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	config "astron-xmod-shim/internal/dto/config"
+)
+
+const (
+	defaultHost       = "unix:///var/run/docker.sock"
+	defaultAPIVersion = "v1.43"
+	defaultTimeout    = 60 * time.Second
+)
+
+// ErrNotFound Engine API 返回 404（容器或镜像不存在）
+var ErrNotFound = errors.New("docker: not found")
+
+// DockerClient Docker Engine API 的最小客户端，仅覆盖 shimlet 需要的接口
+type DockerClient struct {
+	httpClient *http.Client
+	baseURL    string // 包含 API 版本前缀，如 http://docker/v1.43
+}
+
+// NewDockerClient 根据配置创建客户端，支持 unix socket 与 tcp/http 地址
+func NewDockerClient(cfg *config.DockerConfig) (*DockerClient, error) {
+	if cfg == nil {
+		return nil, errors.New("Docker配置不能为空")
+	}
+	host := cfg.Host
+	if host == "" {
+		host = defaultHost
+	}
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAPIVersion
+	}
+	if !strings.HasPrefix(apiVersion, "v") {
+		apiVersion = "v" + apiVersion
+	}
+	timeout := defaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %s: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	var baseURL string
+	switch hostURL.Scheme {
+	case "unix":
+		socketPath := hostURL.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		baseURL = "http://docker"
+	case "tcp", "http":
+		baseURL = "http://" + hostURL.Host
+	case "https":
+		baseURL = "https://" + hostURL.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme: %s", hostURL.Scheme)
+	}
+
+	return &DockerClient{
+		httpClient: &http.Client{Transport: transport, Timeout: timeout},
+		baseURL:    baseURL + "/" + apiVersion,
+	}, nil
+}
+
+// Port 容器端口映射
+type Port struct {
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// ContainerSummary GET /containers/json 的列表项
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	Labels map[string]string `json:"Labels"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Ports  []Port            `json:"Ports"`
+}
+
+// ContainerState 容器运行状态
+type ContainerState struct {
+	Status     string `json:"Status"` // created / running / paused / restarting / removing / exited / dead
+	Running    bool   `json:"Running"`
+	Restarting bool   `json:"Restarting"`
+	ExitCode   int    `json:"ExitCode"`
+	Error      string `json:"Error"`
+	StartedAt  string `json:"StartedAt"`
+}
+
+// ContainerConfig 创建容器时的配置
+type ContainerConfig struct {
+	Image        string              `json:"Image"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"` // 为空时使用镜像的 entrypoint
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+}
+
+// PortBinding 主机端口绑定
+type PortBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
+	HostPort string `json:"HostPort"`
+}
+
+// DeviceRequest GPU 等设备请求
+type DeviceRequest struct {
+	Driver       string     `json:"Driver,omitempty"`
+	Count        int        `json:"Count,omitempty"` // -1 表示全部设备
+	DeviceIDs    []string   `json:"DeviceIDs,omitempty"`
+	Capabilities [][]string `json:"Capabilities,omitempty"`
+}
+
+// RestartPolicy 容器重启策略
+type RestartPolicy struct {
+	Name string `json:"Name"`
+}
+
+// HostConfig 容器的主机侧配置
+type HostConfig struct {
+	Binds          []string                 `json:"Binds,omitempty"`
+	PortBindings   map[string][]PortBinding `json:"PortBindings,omitempty"`
+	DeviceRequests []DeviceRequest          `json:"DeviceRequests,omitempty"`
+	RestartPolicy  RestartPolicy            `json:"RestartPolicy"`
+	NetworkMode    string                   `json:"NetworkMode,omitempty"`
+	ShmSize        int64                    `json:"ShmSize,omitempty"`
+}
+
+// CreateContainerRequest POST /containers/create 的请求体
+type CreateContainerRequest struct {
+	ContainerConfig
+	HostConfig HostConfig `json:"HostConfig"`
+}
+
+// ContainerInspect GET /containers/{id}/json 的响应
+type ContainerInspect struct {
+	ID         string          `json:"Id"`
+	Name       string          `json:"Name"`
+	State      ContainerState  `json:"State"`
+	Config     ContainerConfig `json:"Config"`
+	HostConfig HostConfig      `json:"HostConfig"`
+}
+
+// ListContainers 列出带有指定标签的容器，all 为 true 时包含已停止的容器
+func (c *DockerClient) ListContainers(labelFilters []string, all bool) ([]ContainerSummary, error) {
+	query := url.Values{}
+	if all {
+		query.Set("all", "true")
+	}
+	if len(labelFilters) > 0 {
+		filters, _ := json.Marshal(map[string][]string{"label": labelFilters})
+		query.Set("filters", string(filters))
+	}
+	var containers []ContainerSummary
+	if err := c.do(http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// InspectContainer 查询容器详情
+func (c *DockerClient) InspectContainer(id string) (*ContainerInspect, error) {
+	inspect := &ContainerInspect{}
+	if err := c.do(http.MethodGet, "/containers/"+url.PathEscape(id)+"/json", nil, nil, inspect); err != nil {
+		return nil, err
+	}
+	return inspect, nil
+}
+
+// CreateContainer 创建容器，返回容器 ID
+func (c *DockerClient) CreateContainer(name string, req *CreateContainerRequest) (string, error) {
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
+	}
+	var resp struct {
+		ID string `json:"Id"`
+	}
+	if err := c.do(http.MethodPost, "/containers/create", query, req, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// StartContainer 启动容器
+func (c *DockerClient) StartContainer(id string) error {
+	return c.do(http.MethodPost, "/containers/"+url.PathEscape(id)+"/start", nil, nil, nil)
+}
+
+// RenameContainer 重命名容器
+func (c *DockerClient) RenameContainer(id, name string) error {
+	query := url.Values{}
+	query.Set("name", name)
+	return c.do(http.MethodPost, "/containers/"+url.PathEscape(id)+"/rename", query, nil, nil)
+}
+
+// StopContainer 停止容器，超过 timeout 后强制终止
+func (c *DockerClient) StopContainer(id string, timeout time.Duration) error {
+	query := url.Values{}
+	query.Set("t", fmt.Sprintf("%d", int(timeout.Seconds())))
+	return c.do(http.MethodPost, "/containers/"+url.PathEscape(id)+"/stop", query, nil, nil)
+}
+
+// RemoveContainer 删除容器（force 为 true 时运行中的容器也会被删除）
+func (c *DockerClient) RemoveContainer(id string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	return c.do(http.MethodDelete, "/containers/"+url.PathEscape(id), query, nil, nil)
+}
+
+// PullImage 拉取镜像，等待拉取完成
+func (c *DockerClient) PullImage(image string) error {
+	query := url.Values{}
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	query.Set("fromImage", name)
+	query.Set("tag", tag)
+
+	resp, err := c.send(http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 拉取进度以 JSON 流返回，失败时状态码仍为 200，需要检查流中的 error
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var progress struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&progress); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read pull progress of %s failed: %w", image, err)
+		}
+		if progress.Error != "" {
+			return fmt.Errorf("pull image %s failed: %s", image, progress.Error)
+		}
+	}
+}
+
+// do 发送请求并解析 JSON 响应，out 为 nil 时丢弃响应体
+func (c *DockerClient) do(method, path string, query url.Values, body, out any) error {
+	resp, err := c.send(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send 发送请求，非成功状态码转换为错误；成功时由调用方关闭响应体
+func (c *DockerClient) send(method, path string, query url.Values, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker %s %s failed: %w", method, path, err)
+	}
+
+	// 304 表示容器已处于目标状态（如重复 start/stop），视为成功
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s %s: %s", ErrNotFound, method, path, readMessage(resp.Body))
+	}
+	return nil, fmt.Errorf("docker %s %s failed with status %d: %s", method, path, resp.StatusCode, readMessage(resp.Body))
+}
+
+// readMessage 提取 Engine API 错误响应中的 message
+func readMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &errResp) == nil && errResp.Message != "" {
+		return errResp.Message
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -12,6 +12,8 @@ import (
 
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors" // Critical: Must import this package
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -28,7 +30,7 @@ import (
 
 // K8sClient 通用K8s客户端，直接包含所有Informer组件和客户端实例
 type K8sClient struct {
-	client *kubernetes.Clientset // 原生clientset
+	client kubernetes.Interface // 原生clientset
 
 	// Informer相关组件
 	podInformer    cache.SharedIndexInformer                    // Pod Informer
@@ -36,6 +38,8 @@ type K8sClient struct {
 	cmInformer     cache.SharedIndexInformer                    // CM Informer
 	podLister      cache.GenericLister                          // Pod缓存查询器
 	deployLister   cache.GenericLister                          // Deployment缓存查询器
+	stsInformer    cache.SharedIndexInformer                    // StatefulSet Informer（多节点部署）
+	stsLister      cache.GenericLister                          // StatefulSet缓存查询器
 	nodeInformer   cache.SharedIndexInformer                    // 节点Informer（新增）
 	nodeLister     cache.GenericLister                          // 节点缓存查询器（新增）
 	cmLister       cache.GenericLister                          // CM缓存查询器
@@ -63,7 +67,11 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("创建K8s clientset失败: %w", err)
 	}
+	return NewK8sClientForClientset(clientset)
+}
 
+// NewK8sClientForClientset 基于已创建的 clientset 初始化客户端与 Informer（测试中可传入 fake clientset）
+func NewK8sClientForClientset(clientset kubernetes.Interface) (*K8sClient, error) {
 	// 4. 初始化客户端实例（直接初始化所有字段）
 	client := &K8sClient{
 		client:  clientset,
@@ -72,14 +80,15 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 
 	// 5. 初始化Pod Informer及Lister（使用NewFilteredListWatchFromClient替代手动List/Watch）
 	client.podInformer = cache.NewSharedIndexInformer(
-		// 使用官方推荐方法创建ListWatch，自动处理List/Watch逻辑
-		cache.NewFilteredListWatchFromClient(
-			clientset.CoreV1().RESTClient(), // 传入Pod资源的RESTClient
-			"pods",                          // 资源名称（字符串）
-			metav1.NamespaceAll,             // 监听所有命名空间
-			func(opts *metav1.ListOptions) { // 全局筛选器（无筛选可留空）
+		// 基于 typed client 创建ListWatch，监听所有命名空间
+		&cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return clientset.CoreV1().Pods(metav1.NamespaceAll).Watch(ctx, opts)
 			},
-		),
+		},
 		&corev1.Pod{}, // 资源对象类型
 		5*time.Minute, // 缓存重同步间隔
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, // 命名空间索引
@@ -91,12 +100,14 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 
 	// 6. 初始化Deployment Informer及Lister（同样使用推荐方法）
 	client.deployInformer = cache.NewSharedIndexInformer(
-		cache.NewFilteredListWatchFromClient(
-			clientset.AppsV1().RESTClient(), // 传入Deployment资源的RESTClient
-			"deployments",                   // 资源名称（字符串）
-			metav1.NamespaceAll,
-			func(opts *metav1.ListOptions) {}, // 无筛选
-		),
+		&cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return clientset.AppsV1().Deployments(metav1.NamespaceAll).Watch(ctx, opts)
+			},
+		},
 		&appsv1.Deployment{},
 		5*time.Minute,
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
@@ -106,14 +117,35 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 		appsv1.SchemeGroupVersion.WithResource("deployments").GroupResource(),
 	)
 
+	// 6.1 初始化StatefulSet Informer及Lister（多节点部署使用 StatefulSet）
+	client.stsInformer = cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return clientset.AppsV1().StatefulSets(metav1.NamespaceAll).Watch(ctx, opts)
+			},
+		},
+		&appsv1.StatefulSet{},
+		5*time.Minute,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	client.stsLister = cache.NewGenericLister(
+		client.stsInformer.GetIndexer(),
+		appsv1.SchemeGroupVersion.WithResource("statefulsets").GroupResource(),
+	)
+
 	// 7. 初始化CM Informer及Lister（同样使用推荐方法）
 	client.cmInformer = cache.NewSharedIndexInformer(
-		cache.NewFilteredListWatchFromClient(
-			clientset.CoreV1().RESTClient(), // 传入Deployment资源的RESTClient
-			"configmaps",                    // 资源名称（字符串）
-			metav1.NamespaceAll,
-			func(opts *metav1.ListOptions) {}, // 无筛选
-		),
+		&cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().ConfigMaps(metav1.NamespaceAll).List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return clientset.CoreV1().ConfigMaps(metav1.NamespaceAll).Watch(ctx, opts)
+			},
+		},
 		&corev1.ConfigMap{},
 		5*time.Minute,
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
@@ -125,12 +157,14 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 
 	//8. 新增：初始化Node Informer及Lister
 	client.nodeInformer = cache.NewSharedIndexInformer(
-		cache.NewFilteredListWatchFromClient(
-			clientset.CoreV1().RESTClient(), // 节点属于corev1资源
-			"nodes",                         // 资源名称（节点是集群级资源，无命名空间）
-			metav1.NamespaceAll,
-			func(opts *metav1.ListOptions) {}, // 无筛选
-		),
+		&cache.ListWatch{ // 节点是集群级资源，无命名空间
+			ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().Nodes().List(ctx, opts)
+			},
+			WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+				return clientset.CoreV1().Nodes().Watch(ctx, opts)
+			},
+		},
 		&corev1.Node{},   // 资源对象类型
 		5*time.Minute,    // 缓存重同步间隔
 		cache.Indexers{}, // 节点无需命名空间索引（集群级资源）
@@ -159,6 +193,7 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 		client.stopper,
 		client.podInformer.HasSynced,
 		client.deployInformer.HasSynced,
+		client.stsInformer.HasSynced,
 		client.cmInformer.HasSynced,
 		client.nodeInformer.HasSynced,
 	) {
@@ -171,8 +206,11 @@ func NewK8sClient(cfg *config.K8sConfig) (*K8sClient, error) {
 // buildRestConfig 根据配置选择集群内/外配置
 func buildRestConfig(cfg *config.K8sConfig) (*rest.Config, error) {
 	if cfg.Kubeconfig != "" {
-		// 集群外：使用指定kubeconfig
-		return clientcmd.BuildConfigFromFlags("", cfg.Kubeconfig)
+		// 集群外：使用指定kubeconfig，配置了 Context 时使用该上下文，否则使用 current-context
+		return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: cfg.Kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: cfg.Context},
+		).ClientConfig()
 	}
 	// 集群内：使用serviceaccount
 	return rest.InClusterConfig()
@@ -286,6 +324,7 @@ func (c *K8sClient) startInformerSystem() {
 	// 启动Informer（独立协程）
 	go c.podInformer.Run(c.stopper)
 	go c.deployInformer.Run(c.stopper)
+	go c.stsInformer.Run(c.stopper)
 	go c.cmInformer.Run(c.stopper)
 	go c.nodeInformer.Run(c.stopper)
 	// 启动事件处理协程
@@ -373,8 +412,33 @@ func (c *K8sClient) ListDeployments(namespace string, opts metav1.ListOptions) (
 	return deploys, nil
 }
 
+// ListStatefulSets 从缓存查询指定命名空间的StatefulSet
+func (c *K8sClient) ListStatefulSets(namespace string, opts metav1.ListOptions) ([]*appsv1.StatefulSet, error) {
+	selector := labels.Everything()
+	if opts.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(opts.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("解析标签选择器失败: %w", err)
+		}
+	}
+
+	objs, err := c.stsLister.ByNamespace(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("查询StatefulSet缓存失败: %w", err)
+	}
+
+	statefulSets := make([]*appsv1.StatefulSet, 0, len(objs))
+	for _, obj := range objs {
+		if sts, ok := obj.(*appsv1.StatefulSet); ok {
+			statefulSets = append(statefulSets, sts)
+		}
+	}
+	return statefulSets, nil
+}
+
 // GetClientSet 暴露原生clientset（用于直接调用K8s API）
-func (c *K8sClient) GetClientSet() *kubernetes.Clientset {
+func (c *K8sClient) GetClientSet() kubernetes.Interface {
 	return c.client
 }
 
@@ -386,7 +450,10 @@ func eventKey(resource, eventType string, obj interface{}) string {
 	return fmt.Sprintf("%s/%s/unknown", resource, eventType)
 }
 
-func (c *K8sClient) UpsertConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+// UpsertConfigMap 创建或更新 ConfigMap，基于调用方读取到的 resourceVersion 做乐观并发控制：
+// resourceVersion 为空时只创建，ConfigMap 已存在则返回冲突；非空时只在当前版本与之一致时更新，
+// 期间已被其他写入方修改或删除时返回冲突（k8s_errors.IsConflict），由调用方重新读取后决定是否重试
+func (c *K8sClient) UpsertConfigMap(namespace, name, resourceVersion string, labels, data map[string]string) (*corev1.ConfigMap, error) {
 	// 1. 验证参数合法性
 	if err := validateConfigMapName(name); err != nil {
 		return nil, fmt.Errorf("ConfigMap名称不合法: %w", err)
@@ -394,50 +461,59 @@ func (c *K8sClient) UpsertConfigMap(namespace, name string) (*corev1.ConfigMap,
 	if namespace == "" {
 		return nil, errors.New("命名空间不能为空")
 	}
-	// TODO 对接 llm cm
 
-	// 3. 构建目标ConfigMap对象
+	// 2. 构建目标ConfigMap对象
 	targetCM := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
-			Labels: map[string]string{
-				"app":        "",
-				"resource":   "llm-cm",
-				"managed-by": "astron-xmod-shim",
-			},
+			Labels:    map[string]string{"managed-by": "astron-xmod-shim"},
 			Annotations: map[string]string{
 				"last-updated": time.Now().Format(time.RFC3339),
 			},
 		},
-		Data: map[string]string{
-			"llm.json": string("strategyJSON"),
-		},
+		Data: data,
 	}
+	for k, v := range labels {
+		targetCM.Labels[k] = v
+	}
+	groupResource := corev1.SchemeGroupVersion.WithResource("configmaps").GroupResource()
 
-	// 4. 尝试获取现有ConfigMap
+	// 3. 从 API Server 获取最新版本（缓存可能落后，不能作为乐观锁依据）
 	existingCM, err := c.client.CoreV1().ConfigMaps(namespace).Get(
 		context.Background(),
 		name,
 		metav1.GetOptions{},
 	)
 
-	// 5. 处理存在/不存在的情况（使用errors.IsNotFound判断）
+	// 4. 处理存在/不存在的情况（使用errors.IsNotFound判断）
 	if err != nil {
-		// 资源不存在，创建新的
-		if k8s_errors.IsNotFound(err) {
-			return c.client.CoreV1().ConfigMaps(namespace).Create(
-				context.Background(),
-				targetCM,
-				metav1.CreateOptions{},
-			)
+		if !k8s_errors.IsNotFound(err) {
+			// 其他错误（如权限不足）
+			return nil, fmt.Errorf("查询ConfigMap失败: %w", err)
+		}
+		if resourceVersion != "" {
+			// 调用方读取后已被删除
+			return nil, k8s_errors.NewConflict(groupResource, name, fmt.Errorf("deleted since version %s", resourceVersion))
+		}
+		// 资源不存在，创建新的；并发创建时返回 AlreadyExists，按冲突处理
+		created, err := c.client.CoreV1().ConfigMaps(namespace).Create(
+			context.Background(),
+			targetCM,
+			metav1.CreateOptions{},
+		)
+		if k8s_errors.IsAlreadyExists(err) {
+			return nil, k8s_errors.NewConflict(groupResource, name, err)
 		}
-		// 其他错误（如权限不足）
-		return nil, fmt.Errorf("查询ConfigMap失败: %w", err)
+		return created, err
+	}
+	if existingCM.ResourceVersion != resourceVersion {
+		return nil, k8s_errors.NewConflict(groupResource, name,
+			fmt.Errorf("expected version %q, found %q", resourceVersion, existingCM.ResourceVersion))
 	}
 
-	// 6. 资源已存在，更新（保留ResourceVersion实现乐观锁）
-	targetCM.ResourceVersion = existingCM.ResourceVersion
+	// 5. 资源已存在，更新（携带调用方的ResourceVersion，由 API Server 保证乐观锁）
+	targetCM.ResourceVersion = resourceVersion
 	// Preserve existing labels (avoid overwriting other labels)
 	for k, v := range existingCM.Labels {
 		if _, ok := targetCM.Labels[k]; !ok {
@@ -493,6 +569,31 @@ func (c *K8sClient) GetConfigMapFromCache(namespace, name string) (*corev1.Confi
 	return cm, nil
 }
 
+// ListConfigMapsFromCache Query ConfigMaps with specified labels in namespace from informer cache
+func (c *K8sClient) ListConfigMapsFromCache(namespace, labelSelector string) ([]*corev1.ConfigMap, error) {
+	selector := labels.Everything()
+	if labelSelector != "" {
+		var err error
+		selector, err = labels.Parse(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse label selector: %w", err)
+		}
+	}
+
+	objs, err := c.cmLister.ByNamespace(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ConfigMaps from cache: %w", err)
+	}
+
+	cms := make([]*corev1.ConfigMap, 0, len(objs))
+	for _, obj := range objs {
+		if cm, ok := obj.(*corev1.ConfigMap); ok {
+			cms = append(cms, cm)
+		}
+	}
+	return cms, nil
+}
+
 func (c *K8sClient) DeleteConfigMap(ns string, name string) error {
 	return c.client.CoreV1().ConfigMaps(ns).Delete(
 		context.Background(),
@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	config "astron-xmod-shim/internal/dto/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+users:
+- name: admin
+  user:
+    token: test-token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+`
+
+// 测试 kubeconfig 默认使用 current-context，配置 Context 时使用指定的上下文
+func TestBuildRestConfig_Context(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(testKubeconfig), 0o600))
+
+	restCfg, err := buildRestConfig(&config.K8sConfig{Kubeconfig: path})
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com:6443", restCfg.Host)
+
+	restCfg, err = buildRestConfig(&config.K8sConfig{Kubeconfig: path, Context: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com:6443", restCfg.Host)
+
+	_, err = buildRestConfig(&config.K8sConfig{Kubeconfig: path, Context: "missing"})
+	assert.Error(t, err)
+}
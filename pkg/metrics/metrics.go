@@ -0,0 +1,429 @@
+// Package metrics 提供轻量的指标注册与 Prometheus 文本格式输出
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets 默认的直方图分桶（秒）
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Sample 采集函数返回的一条样本
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collector 一个指标族
+type collector interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry 指标注册中心，同名指标重复注册时返回已有的指标；
+// 同名但类型不同的重复注册属于编程错误，直接 panic
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建指标注册中心
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default 全局默认注册中心
+var Default = NewRegistry()
+
+func register[T collector](r *Registry, c T) T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.collectors[c.name()]
+	if !ok {
+		r.collectors[c.name()] = c
+		return c
+	}
+	same, ok := existing.(T)
+	if !ok {
+		panic(fmt.Sprintf("metric %s: duplicate registration as %T, already registered as %T", c.name(), c, existing))
+	}
+	return same
+}
+
+// WriteText 以 Prometheus 文本格式输出全部指标，按名称排序
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// desc 指标族的公共描述
+type desc struct {
+	metricName string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, d.metricType)
+	return err
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", d.metricName, len(d.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// labels 生成 {k="v",...}，extra 为额外的标签（如直方图的 le）
+func (d *desc) labels(labelValues []string, extra ...string) string {
+	if len(labelValues) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labelValues)+len(extra)/2)
+	for i, v := range labelValues {
+		parts = append(parts, d.labelNames[i]+`="`+escapeLabel(v)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// series 按标签值保存的单条时间序列
+type series[T any] struct {
+	labelValues []string
+	metric      T
+}
+
+// vec 按标签值懒创建时间序列
+type vec[T any] struct {
+	desc
+	mu      sync.RWMutex
+	series  map[string]*series[T]
+	newFunc func() T
+}
+
+func (v *vec[T]) with(labelValues []string) T {
+	key := v.key(labelValues)
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s.metric
+	}
+	s = &series[T]{labelValues: append([]string(nil), labelValues...), metric: v.newFunc()}
+	v.series[key] = s
+	return s.metric
+}
+
+// sorted 返回按标签值排序的时间序列
+func (v *vec[T]) sorted() []*series[T] {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*series[T], 0, len(keys))
+	for _, key := range keys {
+		result = append(result, v.series[key])
+	}
+	return result
+}
+
+// Counter 单调递增的计数器
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc 加一
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加计数，delta 必须非负
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec 带标签的计数器族
+type CounterVec struct {
+	vec[*Counter]
+}
+
+// NewCounterVec 注册计数器族
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{vec[*Counter]{
+		desc:    desc{metricName: name, help: help, metricType: typeCounter, labelNames: labelNames},
+		series:  make(map[string]*series[*Counter]),
+		newFunc: func() *Counter { return &Counter{} },
+	}}
+	return register(r, c)
+}
+
+// WithLabelValues 获取（必要时创建）指定标签值的计数器
+func (c *CounterVec) WithLabelValues(labelValues ...string) *Counter {
+	return c.with(labelValues)
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	if err := c.writeHeader(w); err != nil {
+		return err
+	}
+	for _, s := range c.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labels(s.labelValues), formatFloat(s.metric.Value())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gauge 可增可减的仪表
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+// Add 增加（delta 可为负数）
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+// Inc 加一
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec 减一
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// GaugeVec 带标签的仪表族
+type GaugeVec struct {
+	vec[*Gauge]
+}
+
+// NewGaugeVec 注册仪表族
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{vec[*Gauge]{
+		desc:    desc{metricName: name, help: help, metricType: typeGauge, labelNames: labelNames},
+		series:  make(map[string]*series[*Gauge]),
+		newFunc: func() *Gauge { return &Gauge{} },
+	}}
+	return register(r, g)
+}
+
+// WithLabelValues 获取（必要时创建）指定标签值的仪表
+func (g *GaugeVec) WithLabelValues(labelValues ...string) *Gauge {
+	return g.with(labelValues)
+}
+
+func (g *GaugeVec) write(w io.Writer) error {
+	if err := g.writeHeader(w); err != nil {
+		return err
+	}
+	for _, s := range g.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labels(s.labelValues), formatFloat(s.metric.Value())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GaugeFunc 在输出时通过回调采集的仪表族，适合从已有状态（如进度记录）派生的指标
+type GaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc 注册回调仪表族
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, collect func() []Sample) *GaugeFunc {
+	g := &GaugeFunc{
+		desc:    desc{metricName: name, help: help, metricType: typeGauge, labelNames: labelNames},
+		collect: collect,
+	}
+	return register(r, g)
+}
+
+func (g *GaugeFunc) write(w io.Writer) error {
+	if err := g.writeHeader(w); err != nil {
+		return err
+	}
+	samples := g.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	for _, s := range samples {
+		g.key(s.LabelValues)
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labels(s.LabelValues), formatFloat(s.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram 累积分桶直方图
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if value <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// HistogramVec 带标签的直方图族
+type HistogramVec struct {
+	vec[*Histogram]
+}
+
+// NewHistogramVec 注册直方图族，buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{vec[*Histogram]{
+		desc:   desc{metricName: name, help: help, metricType: typeHistogram, labelNames: labelNames},
+		series: make(map[string]*series[*Histogram]),
+		newFunc: func() *Histogram {
+			return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		},
+	}}
+	return register(r, h)
+}
+
+// WithLabelValues 获取（必要时创建）指定标签值的直方图
+func (h *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	return h.with(labelValues)
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	if err := h.writeHeader(w); err != nil {
+		return err
+	}
+	for _, s := range h.sorted() {
+		s.metric.mu.Lock()
+		counts := append([]uint64(nil), s.metric.counts...)
+		count, sum := s.metric.count, s.metric.sum
+		s.metric.mu.Unlock()
+
+		for i, upper := range s.metric.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(s.labelValues, "le", formatFloat(upper)), counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(s.labelValues, "le", "+Inf"), count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			h.metricName, h.labels(s.labelValues), formatFloat(sum), h.metricName, h.labels(s.labelValues), count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试 Prometheus 文本格式输出
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Total requests.", "code")
+	requests.WithLabelValues("200").Add(2)
+	requests.WithLabelValues(`5"0`).Inc()
+	r.NewGaugeVec("test_depth", "Queue depth.").WithLabelValues().Set(3)
+	r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{1, 0.1}, "route").WithLabelValues("/a").Observe(0.5)
+	r.NewGaugeFunc("test_services", "Services.", []string{"phase"}, func() []Sample {
+		return []Sample{{LabelValues: []string{"running"}, Value: 1}}
+	})
+
+	// 重复注册返回已有指标
+	assert.Same(t, requests, r.NewCounterVec("test_requests_total", "Total requests.", "code"))
+
+	var sb strings.Builder
+	require.NoError(t, r.WriteText(&sb))
+	expected := `# HELP test_depth Queue depth.
+# TYPE test_depth gauge
+test_depth 3
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 0
+test_duration_seconds_bucket{route="/a",le="1"} 1
+test_duration_seconds_bucket{route="/a",le="+Inf"} 1
+test_duration_seconds_sum{route="/a"} 0.5
+test_duration_seconds_count{route="/a"} 1
+# HELP test_requests_total Total requests.
+# TYPE test_requests_total counter
+test_requests_total{code="200"} 2
+test_requests_total{code="5\"0"} 1
+# HELP test_services Services.
+# TYPE test_services gauge
+test_services{phase="running"} 1
+`
+	assert.Equal(t, expected, sb.String())
+}
+
+// 测试标签值数量不匹配时 panic
+func TestVec_LabelMismatch(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Test.", "a", "b")
+	assert.Panics(t, func() { c.WithLabelValues("x") })
+}
+
+// 测试同名指标重复注册：类型相同返回已有指标，类型不同 panic
+func TestRegistry_DuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "Test.", "a")
+	assert.Same(t, c, r.NewCounterVec("test_total", "Test.", "a"))
+	assert.PanicsWithValue(t, "metric test_total: duplicate registration as *metrics.GaugeVec, already registered as *metrics.CounterVec", func() {
+		r.NewGaugeVec("test_total", "Test.", "a")
+	})
+}